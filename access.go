@@ -0,0 +1,44 @@
+package serialfinder
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// AccessInfo describes whether the current user can open a device node
+type AccessInfo struct {
+	// Path is the device node the port resolves to
+	Path  string
+	Mode  fs.FileMode
+	Owner string
+	// Group is the group owning the node, e.g. dialout or uucp
+	Group string
+	// Readable and Writable report whether the current user may open the node for reading and writing
+	Readable bool
+	Writable bool
+	// InGroup reports whether the current process runs with Group as one of its groups
+	InGroup bool
+	// GroupPending reports that the user is listed in Group but the current session
+	// started before that, so a new login is needed for the membership to apply
+	GroupPending bool
+}
+
+// CanOpen reports whether the node can be opened for reading and writing
+func (a AccessInfo) CanOpen() bool {
+	return a.Readable && a.Writable
+}
+
+// Hint returns an actionable message explaining how to gain access to the node, or an
+// empty string if the node can already be opened
+func (a AccessInfo) Hint() string {
+	switch {
+	case a.CanOpen():
+		return ""
+	case a.GroupPending:
+		return fmt.Sprintf("you were added to the %s group after logging in; log out and back in to open %s", a.Group, a.Path)
+	case !a.InGroup && a.Group != "" && a.Group != "root" && a.Mode&0o060 == 0o060:
+		return fmt.Sprintf("add yourself to the %s group to open %s: sudo usermod -aG %s $USER", a.Group, a.Path, a.Group)
+	default:
+		return fmt.Sprintf("%s (mode %s, owner %s, group %s) is not accessible to the current user", a.Path, a.Mode.Perm(), a.Owner, a.Group)
+	}
+}
@@ -0,0 +1,76 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// CheckAccess reports whether the current user can open the device node behind port,
+// along with the ownership and mode bits needed to explain why not
+func CheckAccess(port string) (AccessInfo, error) {
+	path, err := filepath.EvalSymlinks(port)
+	if err != nil {
+		return AccessInfo{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return AccessInfo{}, err
+	}
+
+	access := AccessInfo{
+		Path:     path,
+		Mode:     info.Mode(),
+		Readable: unix.Access(path, unix.R_OK) == nil,
+		Writable: unix.Access(path, unix.W_OK) == nil,
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return access, nil
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	gid := strconv.FormatUint(uint64(stat.Gid), 10)
+	access.Owner = uid
+	if u, err := user.LookupId(uid); err == nil {
+		access.Owner = u.Username
+	}
+	access.Group = gid
+	if g, err := user.LookupGroupId(gid); err == nil {
+		access.Group = g.Name
+	}
+
+	// Groups of the running process
+	access.InGroup = os.Getegid() == int(stat.Gid)
+	if groups, err := os.Getgroups(); err == nil {
+		for _, g := range groups {
+			if g == int(stat.Gid) {
+				access.InGroup = true
+			}
+		}
+	}
+
+	// Groups the user is configured with, which only apply to new sessions
+	if !access.InGroup {
+		if u, err := user.Current(); err == nil {
+			if groupIDs, err := u.GroupIds(); err == nil {
+				for _, g := range groupIDs {
+					if g == gid {
+						access.GroupPending = true
+					}
+				}
+			}
+		}
+	}
+
+	return access, nil
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+// +build !linux
+
+package serialfinder
+
+import "errors"
+
+// CheckAccess reports whether the current user can open the device node behind port.
+// It is only implemented on Linux, where access is governed by group membership.
+func CheckAccess(port string) (AccessInfo, error) {
+	return AccessInfo{}, errors.ErrUnsupported
+}
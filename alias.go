@@ -0,0 +1,96 @@
+package serialfinder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrUnknownAlias is returned by FindByAlias for a name missing from the alias file
+	ErrUnknownAlias = errors.New("serialfinder: unknown alias")
+	// ErrDeviceNotFound is returned when the device a name refers to is not attached
+	ErrDeviceNotFound = errors.New("serialfinder: device not found")
+)
+
+// Aliases maps meaningful names, such as "left-bench-probe", to the StableID or serial
+// number of a device
+type Aliases map[string]string
+
+// DefaultAliasesPath returns the alias file used unless WithAliasFile says otherwise:
+// serialfinder/aliases.json in the user configuration directory, e.g.
+// ~/.config/serialfinder/aliases.json on Linux
+func DefaultAliasesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "serialfinder", "aliases.json"), nil
+}
+
+// LoadAliases reads an alias file, a JSON object mapping names to StableIDs or serial
+// numbers:
+//
+//	{"left-bench-probe": "A50285BI", "gps": "3f1c9a0e5b7d2c4a8e6f1b3d5c7a9e0f"}
+func LoadAliases(path string) (Aliases, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var aliases Aliases
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return aliases, nil
+}
+
+// Match reports whether the device is the one the alias refers to
+func (a Aliases) Match(alias string, device SerialDeviceInfo) bool {
+	target, ok := a[alias]
+	if !ok || target == "" {
+		return false
+	}
+	return string(device.StableID()) == target || device.SerialNumber == target
+}
+
+// FindByAlias returns the device an alias refers to. See Finder.FindByAlias.
+func FindByAlias(ctx context.Context, alias string, opts ...Option) (SerialDeviceInfo, error) {
+	return NewFinder(opts...).FindByAlias(ctx, alias)
+}
+
+// FindByAlias returns the device an alias of the alias file refers to, so scripts can name
+// devices in a way that survives re-enumeration and reinstalls. The file is read on each
+// call. When a serial number is shared by several ports of a composite device, the first
+// one listed is returned; alias its StableID to pick another. It returns an error wrapping
+// ErrUnknownAlias for names missing from the file, and ErrDeviceNotFound when the device is
+// not attached.
+func (f *Finder) FindByAlias(ctx context.Context, alias string) (SerialDeviceInfo, error) {
+	path := f.opts.aliasFile
+	if path == "" {
+		var err error
+		if path, err = DefaultAliasesPath(); err != nil {
+			return SerialDeviceInfo{}, err
+		}
+	}
+	aliases, err := LoadAliases(path)
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+	if _, ok := aliases[alias]; !ok {
+		return SerialDeviceInfo{}, fmt.Errorf("%w: %s", ErrUnknownAlias, alias)
+	}
+
+	devices, err := f.List(ctx, Filter{})
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+	for _, device := range devices {
+		if aliases.Match(alias, device) {
+			return device, nil
+		}
+	}
+	return SerialDeviceInfo{}, fmt.Errorf("%w: %s (%s)", ErrDeviceNotFound, alias, aliases[alias])
+}
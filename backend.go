@@ -0,0 +1,63 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+)
+
+// backend is a source of serial devices for one platform or mechanism
+type backend interface {
+	// list returns the devices present on the system. Implementations may use the filter to
+	// skip work, but the Finder applies it again so every backend filters identically.
+	list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error)
+}
+
+// portLookuper is implemented by backends that can describe one port without listing every
+// device. lookupPort returns false for ports it cannot describe that way; the Finder then
+// lists the devices to find it.
+type portLookuper interface {
+	lookupPort(ctx context.Context, port string, o options) (SerialDeviceInfo, bool, error)
+}
+
+// Backend is a source of devices that can be plugged in behind a Finder, such as a
+// corporate asset database. grpcfinder.Client is one, for devices of another machine.
+type Backend interface {
+	// List returns the devices of the source. It may use the filter to skip work; the
+	// Finder applies it again, so a backend may also ignore it.
+	List(ctx context.Context, f Filter) ([]SerialDeviceInfo, error)
+}
+
+// customBackend adapts a Backend to the internal interface
+type customBackend struct {
+	Backend
+}
+
+func (b customBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	return b.List(ctx, f)
+}
+
+// RegisterBackend makes a backend selectable with WithBackend under the given name. Like
+// database/sql.Register, it is meant to be called from init functions, and it panics if the
+// name is empty or already taken, including by a built-in backend of the platform.
+func RegisterBackend(name BackendName, b Backend) {
+	if name == BackendDefault {
+		panic("serialfinder: RegisterBackend with an empty name")
+	}
+	if b == nil {
+		panic("serialfinder: RegisterBackend with a nil backend")
+	}
+
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	if _, taken := platformBackends[name]; taken {
+		panic(fmt.Sprintf("serialfinder: RegisterBackend called twice for %q", name))
+	}
+	platformBackends[name] = func() backend { return customBackend{b} }
+}
+
+// WithCustomBackend discovers devices with the given backend instead of a registered one
+func WithCustomBackend(b Backend) Option {
+	return func(o *options) {
+		o.customBackend = b
+	}
+}
@@ -0,0 +1,49 @@
+package serialfinder
+
+import (
+	"errors"
+	"sync"
+)
+
+// BackendName selects the mechanism used to discover devices
+type BackendName string
+
+const (
+	// BackendDefault is the recommended backend of the current platform
+	BackendDefault BackendName = ""
+	// BackendUdev reads the udev database in /run/udev/data on Linux
+	BackendUdev BackendName = "udev"
+	// BackendLibUSB enriches the default backend with USB descriptors read through libusb.
+	// It is only available when built with the gousb tag, which requires cgo.
+	BackendLibUSB BackendName = "libusb"
+	// BackendWMI queries Win32_PnPEntity through WMI on Windows, for systems where policy
+	// restricts registry access
+	BackendWMI BackendName = "wmi"
+	// BackendSystemProfiler reads `system_profiler SPUSBDataType -json` on macOS. The default
+	// backend falls back to it when the output of ioreg cannot be used.
+	BackendSystemProfiler BackendName = "system_profiler"
+)
+
+// ErrUnknownBackend is returned when the selected backend is not available on this platform
+var ErrUnknownBackend = errors.New("serialfinder: backend not available on this platform")
+
+// ErrUnsupportedPlatform is returned by every scan on operating systems serialfinder has no
+// backend for, such as js/wasm or plan9. The package still compiles there, so programs can
+// depend on it without build constraints of their own.
+var ErrUnsupportedPlatform = errors.New("serialfinder: platform not supported")
+
+// platformBackends holds the constructors of the non-default backends available on this
+// platform. Platform files add to it from init functions, and RegisterBackend at any time.
+var (
+	backendsMu       sync.RWMutex
+	platformBackends = map[BackendName]func() backend{}
+)
+
+// WithBackend selects the backend used to discover devices, a built-in one or one added
+// with RegisterBackend. Scans fail with ErrUnknownBackend if the backend is not available
+// on the current platform.
+func WithBackend(name BackendName) Option {
+	return func(o *options) {
+		o.backend = name
+	}
+}
@@ -0,0 +1,39 @@
+package serialfinder
+
+import "strings"
+
+// Mode is the firmware a device runs, when it can be told
+type Mode string
+
+const (
+	// ModeUnknown is reported for devices that look the same in every mode
+	ModeUnknown Mode = ""
+	// ModeApplication is reported for devices running their own firmware
+	ModeApplication Mode = "application"
+	// ModeBootloader is reported for devices in their ROM download or flashing mode
+	ModeBootloader Mode = "bootloader"
+)
+
+// bootloaderIDs are USB devices that present no serial port while in their bootloader, yet
+// are reported with WithBootloaders so tools can guide users through flashing
+var bootloaderIDs = map[string]string{
+	"2E8A:0003": "RP2040 BOOTSEL",
+	"2E8A:000F": "RP2350 BOOTSEL",
+}
+
+// bootloaderDevice returns the device to report for a USB device with the given IDs, and
+// false if it is not a known bootloader
+func bootloaderDevice(vid, pid string) (SerialDeviceInfo, bool) {
+	vid, pid = strings.ToUpper(vid), strings.ToUpper(pid)
+	description, ok := bootloaderIDs[vid+":"+pid]
+	if !ok {
+		return SerialDeviceInfo{}, false
+	}
+	return SerialDeviceInfo{
+		Vid:         vid,
+		Pid:         pid,
+		Transport:   TransportUSB,
+		Description: description,
+		Mode:        ModeBootloader,
+	}, true
+}
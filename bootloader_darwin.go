@@ -0,0 +1,64 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// listBootloaders finds the known bootloaders in the IOUSB plane of the I/O Registry, which
+// holds the USB devices without their interfaces
+func listBootloaders(ctx context.Context, o options) ([]SerialDeviceInfo, error) {
+	cmd := exec.CommandContext(ctx, "ioreg", "-a", "-p", "IOUSB", "-l")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := runCommand(ctx, cmd, o.toolTimeout()); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to run ioreg: %w", err)
+	}
+	return parseIOUSBBootloaders(out.Bytes())
+}
+
+// parseIOUSBBootloaders extracts the known bootloaders from the plist printed by
+// `ioreg -a -p IOUSB -l`
+func parseIOUSBBootloaders(data []byte) ([]SerialDeviceInfo, error) {
+	root, err := decodePlist(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []SerialDeviceInfo
+	var walk func(node any)
+	walk = func(node any) {
+		switch n := node.(type) {
+		case []any:
+			for _, item := range n {
+				walk(item)
+			}
+		case map[string]any:
+			vid, hasVid := n["idVendor"].(int64)
+			pid, hasPid := n["idProduct"].(int64)
+			if hasVid && hasPid {
+				if device, ok := bootloaderDevice(fmt.Sprintf("%04X", vid), fmt.Sprintf("%04X", pid)); ok {
+					device.SerialNumber, _ = n["USB Serial Number"].(string)
+					device.Manufacturer, _ = n["USB Vendor Name"].(string)
+					device.Product, _ = n["USB Product Name"].(string)
+					if location, ok := n["locationID"].(int64); ok {
+						device.Location = fmt.Sprintf("0x%08x", location)
+						device.Topology = parseLocationIDTopology(location)
+					}
+					devices = append(devices, device)
+				}
+			}
+			walk(n["IORegistryEntryChildren"])
+		}
+	}
+	walk(root)
+	return devices, nil
+}
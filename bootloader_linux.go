@@ -0,0 +1,51 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// listBootloaders finds the known bootloaders among the USB devices in sysfs
+func listBootloaders(ctx context.Context, o options) ([]SerialDeviceInfo, error) {
+	entries, err := os.ReadDir("/sys/bus/usb/devices")
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []SerialDeviceInfo
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// Interfaces are named like 1-1.4:1.0 and root hubs like usb1
+		name := entry.Name()
+		if strings.Contains(name, ":") || strings.HasPrefix(name, "usb") {
+			continue
+		}
+		usbDir := filepath.Join("/sys/bus/usb/devices", name)
+		device, ok := bootloaderDevice(readSysfsString(hostSysfs{}, usbDir, "idVendor"), readSysfsString(hostSysfs{}, usbDir, "idProduct"))
+		if !ok {
+			continue
+		}
+
+		device.SerialNumber = readSysfsString(hostSysfs{}, usbDir, "serial")
+		device.Manufacturer = readSysfsString(hostSysfs{}, usbDir, "manufacturer")
+		device.Product = readSysfsString(hostSysfs{}, usbDir, "product")
+		device.Location = name
+		device.Topology = parseSysfsTopology(name)
+		device.Power = readPowerInfo(hostSysfs{}, usbDir)
+		if info, err := os.Stat(usbDir); err == nil {
+			device.ConnectedAt = info.ModTime()
+		}
+		devices = append(devices, device)
+	}
+	return devices, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+import "context"
+
+// listBootloaders reports no bootloaders on platforms without a USB scan
+func listBootloaders(ctx context.Context, o options) ([]SerialDeviceInfo, error) {
+	return nil, nil
+}
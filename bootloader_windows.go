@@ -0,0 +1,44 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import (
+	"context"
+	"strings"
+)
+
+// listBootloaders finds the present instances of the known bootloaders in Enum\USB
+func listBootloaders(ctx context.Context, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+	reg := &hostRegistry{}
+	defer reg.Close()
+	for ids := range bootloaderIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vid, pid, _ := strings.Cut(ids, ":")
+		deviceID := `VID_` + vid + `&PID_` + pid
+		instances, err := reg.SubKeyNames(`SYSTEM\CurrentControlSet\Enum\USB\` + deviceID)
+		if err != nil {
+			continue
+		}
+
+		for _, instance := range instances {
+			instanceID := `USB\` + deviceID + `\` + instance
+			if present, err := devNodePresentWindows(instanceID); err != nil || !present {
+				continue
+			}
+			device, _ := bootloaderDevice(vid, pid)
+			// Instances of devices without a serial number are generated, e.g. 5&1b2c3d4e&0&2
+			if !strings.Contains(instance, "&") {
+				device.SerialNumber = instance
+			}
+			device.Location = instanceID
+			device.Topology = topologyWindows(instanceID)
+			device.ConnectedAt = arrivalTimeWindows(instanceID)
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
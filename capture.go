@@ -0,0 +1,207 @@
+package serialfinder
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// captureVersion is the version of the capture archive layout written by Capture
+const captureVersion = 1
+
+// captureManifestName is the archive member describing a capture
+const captureManifestName = "manifest.json"
+
+// ErrInvalidCapture is returned when replaying a file that is not a capture archive, or
+// one written by an incompatible version
+var ErrInvalidCapture = errors.New("serialfinder: not a capture archive of a supported version")
+
+// captureManifest describes a capture archive
+type captureManifest struct {
+	Version int       `json:"version"`
+	OS      string    `json:"os"`
+	Created time.Time `json:"created"`
+}
+
+// capturedFile is one member of a capture archive: a file, a directory or a symbolic link
+// whose data is its target
+type capturedFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// captureArchive collects the raw inputs of a scan, keyed by their slash-separated name in
+// the archive
+type captureArchive struct {
+	// mu guards files, which the Linux backend records from several goroutines
+	mu    sync.Mutex
+	files map[string]capturedFile
+}
+
+func newCaptureArchive() *captureArchive {
+	return &captureArchive{files: make(map[string]capturedFile)}
+}
+
+// add records a member, keeping the data already recorded for a file unless new data is given
+func (a *captureArchive) add(name string, file capturedFile) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if old, ok := a.files[name]; ok && file.data == nil && old.mode == file.mode {
+		file.data = old.data
+	}
+	a.files[name] = file
+}
+
+// addJSON records a member holding v encoded as JSON
+func (a *captureArchive) addJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	a.add(name, capturedFile{data: data, mode: 0o644, modTime: time.Now()})
+	return nil
+}
+
+// writeTo writes the members as a zip archive, after a manifest describing the capture
+func (a *captureArchive) writeTo(w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	manifest, err := json.MarshalIndent(captureManifest{Version: captureVersion, OS: runtime.GOOS, Created: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	mw, err := zw.Create(captureManifestName)
+	if err != nil {
+		return err
+	}
+	if _, err := mw.Write(manifest); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(a.files))
+	for name := range a.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		file := a.files[name]
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: file.modTime}
+		if file.mode.IsDir() {
+			header.Name += "/"
+			header.Method = zip.Store
+		}
+		header.SetMode(file.mode)
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// Capture writes the raw inputs of a scan of this machine to w as a zip archive: the sysfs
+// files and links read on Linux, the output of ioreg on macOS, and the registry keys and
+// device node states read on Windows. Replaying the archive with WithReplay reproduces the
+// scan on any machine, which helps diagnose enumeration bugs reported by users. The inputs
+// of every option are recorded, so the replay can use any of them.
+func Capture(ctx context.Context, w io.Writer) error {
+	archive := newCaptureArchive()
+	if err := captureInputs(ctx, archive); err != nil {
+		return err
+	}
+	return archive.writeTo(w)
+}
+
+// WithReplay makes the Finder reproduce the scan recorded by Capture in the archive at path
+// instead of scanning this machine. Options that query the live system, such as
+// WithVerifyOpen and WithBootloaders, have no effect.
+func WithReplay(path string) Option {
+	return func(o *options) {
+		o.replay = path
+	}
+}
+
+// replayBackend reproduces the scan recorded in a capture archive
+type replayBackend struct {
+	path string
+}
+
+func (b replayBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	archive, err := zip.OpenReader(b.path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	data, err := fs.ReadFile(archive, captureManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	var manifest captureManifest
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Version != captureVersion {
+		return nil, ErrInvalidCapture
+	}
+
+	switch manifest.OS {
+	case "linux", "android":
+		return replaySysfs(ctx, capturedSysfs{fsys: zipLinkFS{&archive.Reader}}, f, o)
+	case "darwin":
+		return replayIoreg(ctx, archive, f, o)
+	case "windows":
+		scan, err := replayRegistry(archive)
+		if err != nil {
+			return nil, err
+		}
+		return scan.list(ctx, f, o)
+	}
+	return nil, fmt.Errorf("%w: captured on %s", ErrInvalidCapture, manifest.OS)
+}
+
+// ioregCaptureName is the archive member holding the output of ioreg on macOS
+const ioregCaptureName = "ioreg.txt"
+
+// replayIoreg reproduces the scan of the ioreg backend from the output of ioreg stored in
+// fsys, in the text or the XML format
+func replayIoreg(ctx context.Context, fsys fs.FS, f Filter, o options) ([]SerialDeviceInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	output, err := fs.ReadFile(fsys, ioregCaptureName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseIoregOutput(output, f, o)
+}
+
+// zipLinkFS exposes the symbolic links stored in a zip archive, whose data is their target
+type zipLinkFS struct {
+	*zip.Reader
+}
+
+func (z zipLinkFS) ReadLink(name string) (string, error) {
+	info, err := fs.Stat(z.Reader, name)
+	if err != nil {
+		return "", err
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	target, err := fs.ReadFile(z.Reader, name)
+	return string(target), err
+}
@@ -0,0 +1,28 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// captureInputs records the output of ioreg the default backend parses
+func captureInputs(ctx context.Context, archive *captureArchive) error {
+	cmd := exec.CommandContext(ctx, "ioreg", "-r", "-t", "-c", "IOSerialBSDClient", "-l")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := runCommand(ctx, cmd, defaultCommandTimeout); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("failed to run ioreg: %w", err)
+	}
+
+	archive.add(ioregCaptureName, capturedFile{data: out.Bytes(), mode: 0o644, modTime: time.Now()})
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import "context"
+
+// captureInputs records the sysfs files and links the Linux backends read
+func captureInputs(ctx context.Context, archive *captureArchive) error {
+	return captureSysfs(ctx, archive)
+}
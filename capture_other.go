@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+import "context"
+
+// captureInputs fails with ErrUnsupportedPlatform, as there is no scan to record
+func captureInputs(ctx context.Context, archive *captureArchive) error {
+	return ErrUnsupportedPlatform
+}
@@ -0,0 +1,224 @@
+package serialfinder
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// The archive members holding the registry keys and device nodes read on Windows
+const (
+	registryCaptureName = "registry.json"
+	devNodesCaptureName = "devnodes.json"
+)
+
+// capturedKey is a registry key as far as the Windows backend read it
+type capturedKey struct {
+	SubKeys    []string          `json:"subkeys,omitempty"`
+	ValueNames []string          `json:"value_names,omitempty"`
+	Values     map[string]string `json:"values,omitempty"`
+}
+
+// capturedRegistry answers registry reads from the keys recorded by recordingRegistry,
+// keyed by their path below HKEY_LOCAL_MACHINE
+type capturedRegistry map[string]*capturedKey
+
+func (c capturedRegistry) key(path string) (*capturedKey, error) {
+	if key, ok := c[path]; ok {
+		return key, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+}
+
+func (c capturedRegistry) SubKeyNames(path string) ([]string, error) {
+	key, err := c.key(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.SubKeys, nil
+}
+
+func (c capturedRegistry) ValueNames(path string) ([]string, error) {
+	key, err := c.key(path)
+	if err != nil {
+		return nil, err
+	}
+	return key.ValueNames, nil
+}
+
+func (c capturedRegistry) StringValue(path, name string) (string, error) {
+	key, err := c.key(path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := key.Values[name]
+	if !ok {
+		return "", &fs.PathError{Op: "read", Path: path + `\` + name, Err: fs.ErrNotExist}
+	}
+	return value, nil
+}
+
+// recordingRegistry reads the registry through reg and records every key it reads
+type recordingRegistry struct {
+	reg      registryTree
+	captured capturedRegistry
+}
+
+// key returns the recorded key at path, adding it if needed
+func (r recordingRegistry) key(path string) *capturedKey {
+	key, ok := r.captured[path]
+	if !ok {
+		key = &capturedKey{}
+		r.captured[path] = key
+	}
+	return key
+}
+
+func (r recordingRegistry) SubKeyNames(path string) ([]string, error) {
+	names, err := r.reg.SubKeyNames(path)
+	if err == nil {
+		r.key(path).SubKeys = names
+	}
+	return names, err
+}
+
+func (r recordingRegistry) ValueNames(path string) ([]string, error) {
+	names, err := r.reg.ValueNames(path)
+	if err == nil {
+		r.key(path).ValueNames = names
+	}
+	return names, err
+}
+
+func (r recordingRegistry) StringValue(path, name string) (string, error) {
+	value, err := r.reg.StringValue(path, name)
+	if err == nil {
+		key := r.key(path)
+		if key.Values == nil {
+			key.Values = make(map[string]string)
+		}
+		key.Values[name] = value
+	}
+	return value, err
+}
+
+// capturedDevNode holds the answers the configuration manager gave about one device instance
+type capturedDevNode struct {
+	Located bool `json:"located,omitempty"`
+	Present bool `json:"present,omitempty"`
+	// PresentErr is the error of the presence query, when the configuration manager failed
+	PresentErr string     `json:"present_error,omitempty"`
+	Arrival    time.Time  `json:"arrival,omitempty"`
+	Topology   *Topology  `json:"topology,omitempty"`
+	Power      *PowerInfo `json:"power,omitempty"`
+}
+
+// capturedDevNodes answers device node queries from the answers recorded by
+// recordingDevNodes, keyed by instance ID
+type capturedDevNodes map[string]*capturedDevNode
+
+func (c capturedDevNodes) node(instanceID string) *capturedDevNode {
+	if node, ok := c[instanceID]; ok {
+		return node
+	}
+	return &capturedDevNode{}
+}
+
+func (c capturedDevNodes) Located(instanceID string) bool { return c.node(instanceID).Located }
+
+func (c capturedDevNodes) Present(instanceID string) (bool, error) {
+	node := c.node(instanceID)
+	if node.PresentErr != "" {
+		return false, fmt.Errorf("captured: %s", node.PresentErr)
+	}
+	return node.Present, nil
+}
+
+func (c capturedDevNodes) Arrival(instanceID string) time.Time  { return c.node(instanceID).Arrival }
+func (c capturedDevNodes) Topology(instanceID string) *Topology { return c.node(instanceID).Topology }
+func (c capturedDevNodes) Power(instanceID string) *PowerInfo   { return c.node(instanceID).Power }
+
+// recordingDevNodes queries the configuration manager through nodes and records the answers
+type recordingDevNodes struct {
+	nodes    devNodeSource
+	captured capturedDevNodes
+}
+
+// node returns the recorded answers about the instance, adding them if needed
+func (r recordingDevNodes) node(instanceID string) *capturedDevNode {
+	node, ok := r.captured[instanceID]
+	if !ok {
+		node = &capturedDevNode{}
+		r.captured[instanceID] = node
+	}
+	return node
+}
+
+func (r recordingDevNodes) Located(instanceID string) bool {
+	located := r.nodes.Located(instanceID)
+	r.node(instanceID).Located = located
+	return located
+}
+
+func (r recordingDevNodes) Present(instanceID string) (bool, error) {
+	present, err := r.nodes.Present(instanceID)
+	node := r.node(instanceID)
+	node.Present = present
+	if err != nil {
+		node.PresentErr = err.Error()
+	}
+	return present, err
+}
+
+func (r recordingDevNodes) Arrival(instanceID string) time.Time {
+	arrival := r.nodes.Arrival(instanceID)
+	r.node(instanceID).Arrival = arrival
+	return arrival
+}
+
+func (r recordingDevNodes) Topology(instanceID string) *Topology {
+	topology := r.nodes.Topology(instanceID)
+	r.node(instanceID).Topology = topology
+	return topology
+}
+
+func (r recordingDevNodes) Power(instanceID string) *PowerInfo {
+	power := r.nodes.Power(instanceID)
+	r.node(instanceID).Power = power
+	return power
+}
+
+// captureRegistry records the registry keys and device nodes read by a scan with every
+// option that widens it, without opening any port
+func captureRegistry(ctx context.Context, scan registryScan, archive *captureArchive) error {
+	reg := recordingRegistry{reg: scan.reg, captured: make(capturedRegistry)}
+	nodes := recordingDevNodes{nodes: scan.nodes, captured: make(capturedDevNodes)}
+	recording := registryScan{reg: reg, nodes: nodes}
+	if _, err := recording.list(ctx, Filter{}, options{alternateControlSets: true, includeNonUSB: true, extendedInfo: true}); err != nil {
+		return err
+	}
+
+	if err := archive.addJSON(registryCaptureName, reg.captured); err != nil {
+		return err
+	}
+	return archive.addJSON(devNodesCaptureName, nodes.captured)
+}
+
+// replayRegistry returns a scan reading the registry keys and device nodes of a capture
+func replayRegistry(archive *zip.ReadCloser) (registryScan, error) {
+	reg := make(capturedRegistry)
+	nodes := make(capturedDevNodes)
+	for name, v := range map[string]any{registryCaptureName: &reg, devNodesCaptureName: &nodes} {
+		data, err := fs.ReadFile(archive, name)
+		if err != nil {
+			return registryScan{}, fmt.Errorf("%w: %v", ErrInvalidCapture, err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			return registryScan{}, fmt.Errorf("%w: %s: %v", ErrInvalidCapture, name, err)
+		}
+	}
+	return registryScan{reg: reg, nodes: nodes}, nil
+}
@@ -0,0 +1,102 @@
+package serialfinder
+
+import (
+	"context"
+	"io/fs"
+	"os"
+)
+
+// recordingSysfs reads the file system of the running system and records everything it
+// reads, so a capturedSysfs over the archive answers the same
+type recordingSysfs struct {
+	archive *captureArchive
+}
+
+// record adds the file or directory at name, resolved, with its metadata
+func (r recordingSysfs) record(name string, info fs.FileInfo, data []byte) {
+	mode := info.Mode() &^ fs.ModeType
+	if info.IsDir() {
+		mode |= fs.ModeDir
+	}
+	r.archive.add(fsName(name), capturedFile{data: data, mode: mode, modTime: info.ModTime()})
+}
+
+func (r recordingSysfs) ReadFile(name string) ([]byte, error) {
+	resolved, err := r.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(resolved); err == nil {
+		// sysfs reports a size that has nothing to do with the contents, so they are copied
+		r.record(resolved, info, data)
+	}
+	return data, nil
+}
+
+func (r recordingSysfs) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := r.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(resolved)
+}
+
+func (r recordingSysfs) Stat(name string) (fs.FileInfo, error) {
+	resolved, err := r.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(resolved)
+}
+
+// EvalSymlinks resolves name like the capturedSysfs replaying it, recording the links it
+// follows and the files and directories it passes through
+func (r recordingSysfs) EvalSymlinks(name string) (string, error) {
+	readLink := func(name string) (string, error) {
+		target, err := os.Readlink(name)
+		if err == nil {
+			r.archive.add(fsName(name), capturedFile{data: []byte(target), mode: fs.ModeSymlink | 0o777})
+		}
+		return target, err
+	}
+	exists := func(name string) error {
+		info, err := os.Lstat(name)
+		if err == nil {
+			r.record(name, info, nil)
+		}
+		return err
+	}
+	return evalSymlinks(name, readLink, exists)
+}
+
+// captureSysfs records the inputs of every Linux scan: the /dev/serial/by-id and by-path
+// links, the USB ttys and the other UARTs, with their nodes in /dev and the files read for
+// WithExtendedInfo
+func captureSysfs(ctx context.Context, archive *captureArchive) error {
+	rec := recordingSysfs{archive: archive}
+	if _, _, _, err := listByIDLinks(ctx, rec, Filter{}, nil); err != nil {
+		return err
+	}
+	devices, nodes, err := listUSBTTYs(ctx, rec, Filter{}, true, nil)
+	if err != nil {
+		return err
+	}
+	if devices, nodes, err = addByPathLinks(ctx, rec, Filter{}, devices, nodes, nil); err != nil {
+		return err
+	}
+	extra, extraNodes, err := listNonUSBTTYs(ctx, rec, Filter{}, true)
+	if err != nil {
+		return err
+	}
+	readSysfsExtendedInfo(rec, append(devices, extra...), append(nodes, extraNodes...))
+	return nil
+}
+
+// replaySysfs reproduces the scan of the default Linux backend from a captured tree
+func replaySysfs(ctx context.Context, sys sysfsFS, f Filter, o options) ([]SerialDeviceInfo, error) {
+	return sysfsScan{sys: sys, byID: true}.list(ctx, f, o)
+}
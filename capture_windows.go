@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import "context"
+
+// captureInputs records the registry keys and device node states the registry backend reads
+func captureInputs(ctx context.Context, archive *captureArchive) error {
+	reg := &hostRegistry{}
+	defer reg.Close()
+	return captureRegistry(ctx, registryScan{reg: reg, nodes: hostDevNodes{}}, archive)
+}
@@ -0,0 +1,186 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import (
+	"encoding/binary"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modcfgmgr32               = windows.NewLazySystemDLL("cfgmgr32.dll")
+	procCMLocateDevNodeW      = modcfgmgr32.NewProc("CM_Locate_DevNodeW")
+	procCMGetDevNodePropertyW = modcfgmgr32.NewProc("CM_Get_DevNode_PropertyW")
+
+	devpkeyDeviceLastArrivalDate = windows.DEVPROPKEY{
+		FmtID: windows.DEVPROPGUID{Data1: 0x83da6326, Data2: 0x97a6, Data3: 0x4088, Data4: [8]byte{0x94, 0x53, 0xa1, 0x92, 0x3f, 0x57, 0x3b, 0x29}},
+		PID:   102,
+	}
+	devpkeyDeviceLocationPaths = windows.DEVPROPKEY{
+		FmtID: windows.DEVPROPGUID{Data1: 0xa45c254e, Data2: 0xdf1c, Data3: 0x4efd, Data4: [8]byte{0x80, 0x20, 0x67, 0xd1, 0x46, 0xa8, 0x50, 0xe0}},
+		PID:   37,
+	}
+	devpkeyDevicePowerData = windows.DEVPROPKEY{
+		FmtID: windows.DEVPROPGUID{Data1: 0xa45c254e, Data2: 0xdf1c, Data3: 0x4efd, Data4: [8]byte{0x80, 0x20, 0x67, 0xd1, 0x46, 0xa8, 0x50, 0xe0}},
+		PID:   32,
+	}
+)
+
+// locateDevNodeWindows returns the device node of a device instance ID such as
+// USB\VID_0403&PID_6001\A50285BI. Only devices currently present are found.
+func locateDevNodeWindows(instanceID string) (windows.DEVINST, error) {
+	id, err := windows.UTF16PtrFromString(instanceID)
+	if err != nil {
+		return 0, err
+	}
+	var devInst windows.DEVINST
+	ret, _, _ := procCMLocateDevNodeW.Call(uintptr(unsafe.Pointer(&devInst)), uintptr(unsafe.Pointer(id)), 0)
+	if windows.CONFIGRET(ret) != windows.CR_SUCCESS {
+		return 0, windows.CONFIGRET(ret)
+	}
+	return devInst, nil
+}
+
+// devNodePresentWindows reports whether the device instance is present and started without
+// a problem, without opening the device. An error means the configuration manager could
+// not answer, as opposed to the device being absent.
+func devNodePresentWindows(instanceID string) (bool, error) {
+	devInst, err := locateDevNodeWindows(instanceID)
+	if err == windows.CR_NO_SUCH_DEVNODE {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var status, problem uint32
+	if err := windows.CM_Get_DevNode_Status(&status, &problem, devInst, 0); err != nil {
+		return false, err
+	}
+	return status&windows.DN_STARTED != 0 && status&windows.DN_HAS_PROBLEM == 0, nil
+}
+
+// devNodePropertyWindows reads the raw value of a device node property along with its type
+func devNodePropertyWindows(devInst windows.DEVINST, key *windows.DEVPROPKEY) (windows.DEVPROPTYPE, []byte, error) {
+	var propType windows.DEVPROPTYPE
+	size := uint32(256)
+	for {
+		buf := make([]byte, size)
+		ret, _, _ := procCMGetDevNodePropertyW.Call(
+			uintptr(devInst),
+			uintptr(unsafe.Pointer(key)),
+			uintptr(unsafe.Pointer(&propType)),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)),
+			0,
+		)
+		switch windows.CONFIGRET(ret) {
+		case windows.CR_SUCCESS:
+			return propType, buf[:size], nil
+		case windows.CR_BUFFER_SMALL:
+			// size now holds the required length
+			continue
+		default:
+			return 0, nil, windows.CONFIGRET(ret)
+		}
+	}
+}
+
+// devNodeFiletimeWindows reads a FILETIME property of a device node
+func devNodeFiletimeWindows(devInst windows.DEVINST, key *windows.DEVPROPKEY) (time.Time, error) {
+	propType, buf, err := devNodePropertyWindows(devInst, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if propType != windows.DEVPROP_TYPE_FILETIME || len(buf) < 8 {
+		return time.Time{}, windows.ERROR_INVALID_DATA
+	}
+	ft := windows.Filetime{
+		LowDateTime:  binary.LittleEndian.Uint32(buf[0:4]),
+		HighDateTime: binary.LittleEndian.Uint32(buf[4:8]),
+	}
+	return time.Unix(0, ft.Nanoseconds()), nil
+}
+
+// devNodeStringListWindows reads a string list property of a device node
+func devNodeStringListWindows(devInst windows.DEVINST, key *windows.DEVPROPKEY) ([]string, error) {
+	propType, buf, err := devNodePropertyWindows(devInst, key)
+	if err != nil {
+		return nil, err
+	}
+	if propType != windows.DEVPROP_TYPE_STRING_LIST {
+		return nil, windows.ERROR_INVALID_DATA
+	}
+
+	// The list is a sequence of NUL-terminated UTF-16 strings ending with an empty string
+	chars := make([]uint16, len(buf)/2)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(buf[2*i:])
+	}
+	var list []string
+	for start := 0; start < len(chars); {
+		end := start
+		for end < len(chars) && chars[end] != 0 {
+			end++
+		}
+		if end == start {
+			break
+		}
+		list = append(list, windows.UTF16ToString(chars[start:end]))
+		start = end + 1
+	}
+	return list, nil
+}
+
+// topologyWindows derives the hub chain of a device instance from its location path, such
+// as PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)#USB(4)
+func topologyWindows(instanceID string) *Topology {
+	devInst, err := locateDevNodeWindows(instanceID)
+	if err != nil {
+		return nil
+	}
+	paths, err := devNodeStringListWindows(devInst, &devpkeyDeviceLocationPaths)
+	if err != nil || len(paths) == 0 {
+		return nil
+	}
+	return parseLocationPathTopology(paths[0])
+}
+
+// powerWindows reports the power state of a device instance from its CM_POWER_DATA, whose
+// second field is the most recent device power state (1 = D0 ... 4 = D3)
+func powerWindows(instanceID string) *PowerInfo {
+	devInst, err := locateDevNodeWindows(instanceID)
+	if err != nil {
+		return nil
+	}
+	propType, buf, err := devNodePropertyWindows(devInst, &devpkeyDevicePowerData)
+	if err != nil || propType != windows.DEVPROP_TYPE_BINARY || len(buf) < 8 {
+		return nil
+	}
+
+	switch state := binary.LittleEndian.Uint32(buf[4:8]); {
+	case state == 1:
+		return &PowerInfo{RuntimeStatus: "active"}
+	case state > 1 && state <= 4:
+		return &PowerInfo{RuntimeStatus: "suspended"}
+	default:
+		return nil
+	}
+}
+
+// arrivalTimeWindows returns when the device instance was last connected, or the zero time
+// if Windows does not record it
+func arrivalTimeWindows(instanceID string) time.Time {
+	devInst, err := locateDevNodeWindows(instanceID)
+	if err != nil {
+		return time.Time{}
+	}
+	arrival, err := devNodeFiletimeWindows(devInst, &devpkeyDeviceLastArrivalDate)
+	if err != nil {
+		return time.Time{}
+	}
+	return arrival
+}
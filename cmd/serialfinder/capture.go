@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["capture"] = command{summary: "record the raw scan inputs to an archive for list -replay", run: runCapture}
+}
+
+// runCapture writes the capture archive of this machine, to attach to a bug report
+func runCapture(args []string, stdout io.Writer) error {
+	fs := newFlagSet("capture")
+	out := fs.String("out", "", "write the archive to this file instead of standard output")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var archive bytes.Buffer
+	if err := serialfinder.Capture(context.Background(), &archive); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := stdout.Write(archive.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, archive.Bytes(), 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saved the capture to %s\n", *out)
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// columns extract the fields that -csv and -tsv can export, by column name
+var columns = map[string]func(serialfinder.SerialDeviceInfo) string{
+	"port":           func(d serialfinder.SerialDeviceInfo) string { return d.Port },
+	"dialin_port":    func(d serialfinder.SerialDeviceInfo) string { return d.DialinPort },
+	"vid":            func(d serialfinder.SerialDeviceInfo) string { return d.Vid },
+	"pid":            func(d serialfinder.SerialDeviceInfo) string { return d.Pid },
+	"serial":         func(d serialfinder.SerialDeviceInfo) string { return d.SerialNumber },
+	"transport":      func(d serialfinder.SerialDeviceInfo) string { return d.Transport.String() },
+	"manufacturer":   func(d serialfinder.SerialDeviceInfo) string { return d.Manufacturer },
+	"product":        func(d serialfinder.SerialDeviceInfo) string { return d.Product },
+	"description":    describe,
+	"by_path":        func(d serialfinder.SerialDeviceInfo) string { return d.ByPath },
+	"location":       func(d serialfinder.SerialDeviceInfo) string { return d.Location },
+	"interface":      func(d serialfinder.SerialDeviceInfo) string { return d.Interface },
+	"interface_name": func(d serialfinder.SerialDeviceInfo) string { return d.InterfaceName },
+	"driver":         func(d serialfinder.SerialDeviceInfo) string { return d.Driver },
+	"in_use":         func(d serialfinder.SerialDeviceInfo) string { return strconv.FormatBool(d.InUse) },
+	"openable":       func(d serialfinder.SerialDeviceInfo) string { return strconv.FormatBool(d.Openable) },
+	"remote_host":    func(d serialfinder.SerialDeviceInfo) string { return d.RemoteHost },
+	"mode":           func(d serialfinder.SerialDeviceInfo) string { return string(d.Mode) },
+	"id":             func(d serialfinder.SerialDeviceInfo) string { return string(d.StableID()) },
+	"siblings":       func(d serialfinder.SerialDeviceInfo) string { return strings.Join(d.Siblings, " ") },
+	"topology": func(d serialfinder.SerialDeviceInfo) string {
+		if d.Topology == nil {
+			return ""
+		}
+		return d.Topology.String()
+	},
+	"attributes": func(d serialfinder.SerialDeviceInfo) string {
+		var pairs []string
+		for _, name := range sortedKeys(d.Attributes) {
+			pairs = append(pairs, name+"="+d.Attributes[name])
+		}
+		return strings.Join(pairs, " ")
+	},
+	"connected_at": func(d serialfinder.SerialDeviceInfo) string {
+		if d.ConnectedAt.IsZero() {
+			return ""
+		}
+		return d.ConnectedAt.Format(time.RFC3339)
+	},
+}
+
+// sortedKeys returns the names of a map of attributes in order
+func sortedKeys(attributes map[string]string) []string {
+	names := make([]string, 0, len(attributes))
+	for name := range attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// defaultColumns are exported when -columns is not given
+const defaultColumns = "port,vid,pid,serial,transport,description"
+
+// parseColumns splits a comma-separated list of column names
+func parseColumns(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("unknown column %q, use one of %s", name, strings.Join(columnNames(), ", "))
+		}
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no columns selected")
+	}
+	return names, nil
+}
+
+// columnNames returns the names of every column in order
+func columnNames() []string {
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
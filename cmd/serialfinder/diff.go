@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["diff"] = command{summary: "compare two snapshots and show the devices that changed", run: runDiff}
+}
+
+// fieldChange is the old and new value of one attribute, as JSON
+type fieldChange struct {
+	Old json.RawMessage `json:"old,omitempty"`
+	New json.RawMessage `json:"new,omitempty"`
+}
+
+// changedDevice is a device present in both snapshots with different attributes
+type changedDevice struct {
+	Device  serialfinder.SerialDeviceInfo `json:"device"`
+	Changes map[string]fieldChange        `json:"changes"`
+}
+
+// snapshotDiff is the document printed by diff -json
+type snapshotDiff struct {
+	Added   []serialfinder.SerialDeviceInfo `json:"added"`
+	Removed []serialfinder.SerialDeviceInfo `json:"removed"`
+	Changed []changedDevice                 `json:"changed"`
+}
+
+// runDiff compares two snapshots written by snapshot or list -json. Devices are matched by
+// their StableID, so a device that moved to another port or connector is reported as
+// changed rather than removed and added.
+func runDiff(args []string, stdout io.Writer) error {
+	fs := newFlagSet("diff")
+	asJSON := fs.Bool("json", false, "print the differences as a JSON object")
+	paths, err := parseFlagsArgs(fs, args, "before.json", "after.json")
+	if err != nil {
+		return err
+	}
+
+	before, err := readSnapshot(paths[0])
+	if err != nil {
+		return err
+	}
+	after, err := readSnapshot(paths[1])
+	if err != nil {
+		return err
+	}
+
+	added, removed, changed := serialfinder.Diff(before, after)
+	diff := snapshotDiff{
+		Added:   orEmpty(added),
+		Removed: orEmpty(removed),
+		Changed: []changedDevice{},
+	}
+	previous := serialfinder.NewDeviceSet(before...)
+	for _, device := range changed {
+		changes, err := fieldChanges(previous[device.StableID()], device)
+		if err != nil {
+			return err
+		}
+		// Timestamps decoded in different zones are not equal structs yet read the same
+		if len(changes) > 0 {
+			diff.Changed = append(diff.Changed, changedDevice{Device: device, Changes: changes})
+		}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	if len(diff.Added)+len(diff.Removed)+len(diff.Changed) == 0 {
+		fmt.Fprintln(stdout, "no changes")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, device := range diff.Added {
+		fmt.Fprintf(tw, "+\t%s\t%s\t%s\n", device.Port, ids(device), orDash(describe(device)))
+	}
+	for _, device := range diff.Removed {
+		fmt.Fprintf(tw, "-\t%s\t%s\t%s\n", device.Port, ids(device), orDash(describe(device)))
+	}
+	for _, c := range diff.Changed {
+		names := make([]string, 0, len(c.Changes))
+		for name := range c.Changes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		details := make([]string, len(names))
+		for i, name := range names {
+			details[i] = fmt.Sprintf("%s: %s -> %s", name, orDash(string(c.Changes[name].Old)), orDash(string(c.Changes[name].New)))
+		}
+		fmt.Fprintf(tw, "~\t%s\t%s\t%s\n", c.Device.Port, ids(c.Device), strings.Join(details, ", "))
+	}
+	return tw.Flush()
+}
+
+// readSnapshot reads the devices of a snapshot
+func readSnapshot(path string) ([]serialfinder.SerialDeviceInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	snapshot, err := serialfinder.LoadSnapshot(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return snapshot.Devices, nil
+}
+
+// fieldChanges compares the JSON encodings of two devices and returns the attributes that
+// differ, by their JSON name
+func fieldChanges(old, new serialfinder.SerialDeviceInfo) (map[string]fieldChange, error) {
+	before, err := jsonFields(old)
+	if err != nil {
+		return nil, err
+	}
+	after, err := jsonFields(new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]fieldChange)
+	for name, value := range after {
+		if !bytes.Equal(before[name], value) {
+			changes[name] = fieldChange{Old: before[name], New: value}
+		}
+	}
+	for name, value := range before {
+		if _, ok := after[name]; !ok {
+			changes[name] = fieldChange{Old: value}
+		}
+	}
+	return changes, nil
+}
+
+// jsonFields returns the JSON encoding of each attribute of the device
+func jsonFields(device serialfinder.SerialDeviceInfo) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(device)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+// orEmpty keeps empty lists as [] in JSON
+func orEmpty(devices []serialfinder.SerialDeviceInfo) []serialfinder.SerialDeviceInfo {
+	if devices == nil {
+		return []serialfinder.SerialDeviceInfo{}
+	}
+	return devices
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["doctor"] = command{summary: "diagnose why devices are missing or cannot be opened", run: runDoctor}
+}
+
+// severityLabels are printed in front of each finding
+var severityLabels = map[serialfinder.Severity]string{
+	serialfinder.SeverityOK:      "ok",
+	serialfinder.SeverityWarning: "warn",
+	serialfinder.SeverityError:   "FAIL",
+}
+
+// errChecksFailed makes doctor exit with a non-zero status after printing its report
+var errChecksFailed = errors.New("some checks failed")
+
+// runDoctor prints the findings of serialfinder.Diagnose with a fix for each problem found
+func runDoctor(args []string, stdout io.Writer) error {
+	fs := newFlagSet("doctor")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	report, err := serialfinder.Diagnose(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, finding := range report.Findings {
+			fmt.Fprintf(stdout, "[%s] %s: %s\n", severityLabels[finding.Severity], finding.Subject, finding.Message)
+			if finding.Severity != serialfinder.SeverityOK && finding.Remediation != "" {
+				fmt.Fprintf(stdout, "       fix: %s\n", finding.Remediation)
+			}
+		}
+	}
+
+	if report.Severity() == serialfinder.SeverityError {
+		return errChecksFailed
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// filterFlags select the devices a command works on
+type filterFlags struct {
+	vid, pid    string
+	serial      string
+	serialRegex string
+	portRegex   string
+	transport   string
+	query       string
+}
+
+// register adds the filter flags to fs
+func (ff *filterFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&ff.vid, "vid", "", "only devices with this vendor ID, e.g. 0403, or vendor:product like 0403:6001")
+	fs.StringVar(&ff.pid, "pid", "", "only devices with this product ID, e.g. 6001, a pattern like 60?? or a range like 6001-6015")
+	fs.StringVar(&ff.serial, "serial", "", "only the device with this serial number")
+	fs.StringVar(&ff.serialRegex, "serial-regex", "", "only devices whose serial number matches the regular expression, e.g. '^A5'")
+	fs.StringVar(&ff.portRegex, "port-regex", "", "only devices whose port matches the regular expression")
+	fs.StringVar(&ff.transport, "transport", "", "only devices on this bus: usb, pci, platform, bluetooth, virtual or network")
+	fs.StringVar(&ff.query, "query", "", "only devices matching the query, e.g. 'vid=0403 serial~^A5 transport=usb'; the other filter flags override its terms")
+}
+
+// filter builds the library filter from the flags
+func (ff *filterFlags) filter() (serialfinder.Filter, error) {
+	var f serialfinder.Filter
+	if ff.query != "" {
+		var err error
+		if f, err = serialfinder.ParseQuery(ff.query); err != nil {
+			return f, err
+		}
+	}
+	if ff.vid != "" {
+		f.Vid = ff.vid
+	}
+	if ff.pid != "" {
+		f.Pid = ff.pid
+	}
+	if ff.serial != "" {
+		f.SerialNumber = ff.serial
+	}
+
+	if ff.transport != "" {
+		transport, err := serialfinder.ParseTransport(ff.transport)
+		if err != nil {
+			return f, err
+		}
+		f.Transport = transport
+	}
+	if ff.serialRegex != "" {
+		re, err := regexp.Compile(ff.serialRegex)
+		if err != nil {
+			return f, fmt.Errorf("invalid -serial-regex: %v", err)
+		}
+		f.SerialRegexp = re
+	}
+	if ff.portRegex != "" {
+		re, err := regexp.Compile(ff.portRegex)
+		if err != nil {
+			return f, fmt.Errorf("invalid -port-regex: %v", err)
+		}
+		f.PortRegexp = re
+	}
+	return f, nil
+}
+
+// needsNonUSB reports whether the filter can only match ports that are not USB devices, which
+// the library leaves out unless asked
+func needsNonUSB(f serialfinder.Filter) bool {
+	return f.Transport != serialfinder.TransportUnknown && f.Transport != serialfinder.TransportUSB
+}
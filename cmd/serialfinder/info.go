@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["info"] = command{summary: "print everything known about one port or alias", run: runInfo}
+}
+
+// infoColumns are the fields printed by info, in order; empty ones are left out
+var infoColumns = []string{
+	"port", "dialin_port", "id", "vid", "pid", "serial", "manufacturer", "product", "description",
+	"transport", "by_path", "location", "topology", "interface", "interface_name", "driver", "siblings", "remote_host", "mode", "connected_at",
+}
+
+// runInfo prints the metadata of the device behind a port name, or of the device an alias
+// of the alias file refers to
+func runInfo(args []string, stdout io.Writer) error {
+	fs := newFlagSet("info")
+	inUse := fs.Bool("in-use", false, "check whether another process holds the port open")
+	var output outputFlags
+	output.register(fs)
+	names, err := parseFlagsArgs(fs, args, "port|alias")
+	if err != nil {
+		return err
+	}
+	if err := output.check(); err != nil {
+		return err
+	}
+
+	finder := serialfinder.NewFinder(
+		serialfinder.WithIncludeNonUSB(true),
+		serialfinder.WithInUseCheck(*inUse),
+		serialfinder.WithExtendedInfo(true),
+	)
+	device, err := finder.LookupPort(context.Background(), names[0])
+	if errors.Is(err, serialfinder.ErrPortNotFound) {
+		// Not a port; try it as an alias, unless there is no alias file to look in
+		if aliased, aliasErr := finder.FindByAlias(context.Background(), names[0]); !errors.Is(aliasErr, os.ErrNotExist) && !errors.Is(aliasErr, serialfinder.ErrUnknownAlias) {
+			device, err = aliased, aliasErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if !output.table() {
+		return output.write(stdout, []serialfinder.SerialDeviceInfo{device})
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	for _, name := range infoColumns {
+		if value := columns[name](device); value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", strings.ReplaceAll(name, "_", " "), value)
+		}
+	}
+	if p := device.Power; p != nil {
+		fmt.Fprintf(tw, "power:\t%d mA, %s\n", p.MaxPowerMA, orDash(p.RuntimeStatus))
+	}
+	if *inUse {
+		fmt.Fprintf(tw, "in use:\t%s\n", yesNo(device.InUse))
+	}
+	if known, ok := serialfinder.LookupKnownDevice(device); ok {
+		for _, name := range sortedKeys(known.Attributes) {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, known.Attributes[name])
+		}
+	}
+	// The raw backend properties come last, under the names the platform gives them
+	for _, name := range sortedKeys(device.Attributes) {
+		fmt.Fprintf(tw, "%s:\t%s\n", name, device.Attributes[name])
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["list"] = command{summary: "list the serial devices", run: runList}
+}
+
+// runList prints the devices found on this machine as a table
+func runList(args []string, stdout io.Writer) error {
+	fs := newFlagSet("list")
+	nonUSB := fs.Bool("all", false, "also list built-in, Bluetooth and virtual ports")
+	inUse := fs.Bool("in-use", false, "check whether another process holds each port open")
+	verifyOpen := fs.Bool("verify-open", false, "open each port briefly to check that it can be opened; may reset boards")
+	bootloaders := fs.Bool("bootloaders", false, "also list boards in a bootloader without a serial port, such as a Pico in BOOTSEL mode")
+	replay := fs.String("replay", "", "list the devices recorded in this archive by the capture command instead of this machine")
+	var filters filterFlags
+	filters.register(fs)
+	var output outputFlags
+	output.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := output.check(); err != nil {
+		return err
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	opts := []serialfinder.Option{
+		serialfinder.WithIncludeNonUSB(*nonUSB || needsNonUSB(filter)),
+		serialfinder.WithInUseCheck(*inUse),
+		serialfinder.WithBootloaders(*bootloaders),
+		serialfinder.WithVerifyOpen(*verifyOpen),
+		serialfinder.WithSortByPort(true),
+		serialfinder.WithExtendedInfo(true),
+	}
+	if *replay != "" {
+		opts = append(opts, serialfinder.WithReplay(*replay))
+	}
+	finder := serialfinder.NewFinder(opts...)
+	devices, err := finder.List(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	if !output.table() {
+		return output.write(stdout, devices)
+	}
+	if len(devices) == 0 {
+		fmt.Fprintln(os.Stderr, "no serial devices found")
+		return nil
+	}
+
+	printTable(stdout, devices, *inUse, *verifyOpen)
+	return nil
+}
+
+// printTable writes one aligned row per device, with the IN USE and OPENABLE columns when
+// they were checked
+func printTable(w io.Writer, devices []serialfinder.SerialDeviceInfo, inUse, openable bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "PORT\tVID:PID\tSERIAL\tTRANSPORT\tDESCRIPTION"
+	if inUse {
+		header += "\tIN USE"
+	}
+	if openable {
+		header += "\tOPENABLE"
+	}
+	fmt.Fprintln(tw, header)
+
+	for _, device := range devices {
+		row := fmt.Sprintf("%s\t%s\t%s\t%s\t%s", orDash(device.Port), ids(device), orDash(device.SerialNumber), device.Transport, orDash(describe(device)))
+		if inUse {
+			row += "\t" + yesNo(device.InUse)
+		}
+		if openable {
+			row += "\t" + yesNo(device.Openable)
+		}
+		fmt.Fprintln(tw, row)
+	}
+	tw.Flush()
+}
+
+// describe returns the best available label for the device: the name registered for it,
+// the product name it reports, its description or the name of its IDs in the usb.ids
+// database
+func describe(device serialfinder.SerialDeviceInfo) string {
+	if known, ok := serialfinder.LookupKnownDevice(device); ok {
+		return known.Name
+	}
+	switch {
+	case device.Product != "":
+		if device.Manufacturer != "" {
+			return device.Manufacturer + " " + device.Product
+		}
+		return device.Product
+	case device.Description != "":
+		return device.Description
+	}
+	return serialfinder.Resolve(device)
+}
+
+// ids formats the VID:PID column
+func ids(device serialfinder.SerialDeviceInfo) string {
+	if device.Vid == "" && device.Pid == "" {
+		return "-"
+	}
+	return device.Vid + ":" + device.Pid
+}
+
+// orDash returns s, or "-" for an empty column
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// yesNo formats a boolean column
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,142 @@
+// Command serialfinder prints the serial devices the serialfinder package discovers on this
+// machine.
+//
+// Usage:
+//
+//	serialfinder <command> [flags]
+//
+// Run serialfinder help for the list of commands.
+//
+// Devices listed in known-devices.json in the serialfinder directory of the user
+// configuration directory (~/.config/serialfinder on Linux) are labeled with their
+// registered names; see serialfinder.LoadKnownDevices for the format.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// command is one subcommand of the CLI
+type command struct {
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+// commands holds the subcommands by name. Each command file adds its own from init.
+var commands = map[string]command{}
+
+// errUsage is returned by commands whose arguments are wrong; the flag package has already
+// printed the details
+var errUsage = errors.New("usage")
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "serialfinder: unknown command %q\n\n", name)
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+
+	if err := loadKnownDevices(); err != nil {
+		fmt.Fprintf(os.Stderr, "serialfinder: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := cmd.run(os.Args[2:], os.Stdout); err != nil {
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "serialfinder %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+// loadKnownDevices registers the devices of the user's known-devices.json, if there is one
+func loadKnownDevices() error {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return nil
+	}
+	err = serialfinder.LoadKnownDevices(filepath.Join(dir, "serialfinder", "known-devices.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// usage prints the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: serialfinder <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run serialfinder <command> -h for the flags of a command.")
+}
+
+// newFlagSet returns a flag set for the named command that reports errors instead of exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("serialfinder "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parses the arguments of a command, turning flag errors into errUsage
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	_, err := parseFlagsArgs(fs, args)
+	return err
+}
+
+// parseFlagsArgs parses the flags of a command followed by exactly one argument per name
+// and returns the arguments
+func parseFlagsArgs(fs *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	if len(names) > 0 {
+		fs.Usage = func() {
+			fmt.Fprintf(fs.Output(), "Usage: %s [flags] %s\n", fs.Name(), strings.Join(names, " "))
+			fs.PrintDefaults()
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+	switch {
+	case fs.NArg() > len(names):
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(len(names)))
+	case fs.NArg() < len(names):
+		fmt.Fprintf(fs.Output(), "missing argument %s\n", names[fs.NArg()])
+	default:
+		return fs.Args(), nil
+	}
+	fs.Usage()
+	return nil, errUsage
+}
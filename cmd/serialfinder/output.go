@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// outputFlags selects how commands print devices
+type outputFlags struct {
+	json      bool
+	jsonLines bool
+	format    string
+	tmpl      *template.Template
+	csv       bool
+	tsv       bool
+	columns   string
+	names     []string // parsed columns
+}
+
+// templateFuncs are available to -format templates besides the built-in ones
+var templateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"join":    strings.Join,
+	"resolve": serialfinder.Resolve,
+}
+
+// register adds the output flags to fs
+func (o *outputFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.json, "json", false, "print the devices as a JSON array")
+	fs.BoolVar(&o.jsonLines, "json-lines", false, "print one JSON object per device and line")
+	fs.StringVar(&o.format, "format", "", "print each device with a Go template, e.g. '{{.Port}} {{.SerialNumber}}'")
+	fs.BoolVar(&o.csv, "csv", false, "print the devices as comma-separated values with a header")
+	fs.BoolVar(&o.tsv, "tsv", false, "print the devices as tab-separated values with a header")
+	fs.StringVar(&o.columns, "columns", defaultColumns, "comma-separated columns for -csv and -tsv: "+strings.Join(columnNames(), ", "))
+}
+
+// check reports conflicting output flags and parses the template
+func (o *outputFlags) check() error {
+	selected := 0
+	for _, set := range []bool{o.json, o.jsonLines, o.format != "", o.csv, o.tsv} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return fmt.Errorf("only one of -json, -json-lines, -format, -csv and -tsv can be used")
+	}
+
+	if o.csv || o.tsv {
+		names, err := parseColumns(o.columns)
+		if err != nil {
+			return err
+		}
+		o.names = names
+	}
+
+	if o.format != "" {
+		tmpl, err := template.New("format").Funcs(templateFuncs).Parse(o.format)
+		if err != nil {
+			return fmt.Errorf("invalid -format: %v", err)
+		}
+		o.tmpl = tmpl
+	}
+	return nil
+}
+
+// table reports whether the devices are printed as a table, the default
+func (o *outputFlags) table() bool {
+	return !o.json && !o.jsonLines && o.tmpl == nil && !o.csv && !o.tsv
+}
+
+// write prints the devices in the format selected by the flags; the table is printed by
+// the commands themselves since its columns vary
+func (o *outputFlags) write(w io.Writer, devices []serialfinder.SerialDeviceInfo) error {
+	switch {
+	case o.json:
+		// An empty inventory is still a valid array for jq
+		if devices == nil {
+			devices = []serialfinder.SerialDeviceInfo{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(devices)
+	case o.jsonLines:
+		enc := json.NewEncoder(w)
+		for _, device := range devices {
+			if err := enc.Encode(device); err != nil {
+				return err
+			}
+		}
+	case o.csv || o.tsv:
+		cw := csv.NewWriter(w)
+		if o.tsv {
+			cw.Comma = '\t'
+		}
+		if err := cw.Write(o.names); err != nil {
+			return err
+		}
+		row := make([]string, len(o.names))
+		for _, device := range devices {
+			for i, name := range o.names {
+				row[i] = columns[name](device)
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case o.tmpl != nil:
+		for _, device := range devices {
+			if err := o.tmpl.Execute(w, device); err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/hs0zip/serialfinder"
+	"github.com/hs0zip/serialfinder/grpcfinder"
+	"github.com/hs0zip/serialfinder/serialfinderpb"
+)
+
+func init() {
+	commands["serve"] = command{summary: "serve the device inventory and hotplug events over HTTP", run: runServe}
+}
+
+// runServe serves GET /devices and GET /events, and the gRPC service when -grpc is set,
+// until interrupted
+func runServe(args []string, stdout io.Writer) error {
+	fs := newFlagSet("serve")
+	listen := fs.String("listen", ":8080", "address to listen on")
+	grpcListen := fs.String("grpc", "", "also serve the gRPC service on this address, e.g. :9090")
+	nonUSB := fs.Bool("all", false, "also report built-in, Bluetooth and virtual ports")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	finder := serialfinder.NewFinder(serialfinder.WithIncludeNonUSB(*nonUSB), serialfinder.WithExtendedInfo(true))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /devices", devicesHandler(finder))
+	mux.HandleFunc("GET /events", eventsHandler(finder))
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	var grpcServer *grpc.Server
+	var grpcErr chan error
+	if *grpcListen != "" {
+		listener, err := net.Listen("tcp", *grpcListen)
+		if err != nil {
+			return err
+		}
+		grpcServer = grpc.NewServer()
+		serialfinderpb.RegisterSerialFinderServer(grpcServer, grpcfinder.NewServer(finder))
+		log.Printf("serving gRPC on %s", *grpcListen)
+		grpcErr = make(chan error, 1)
+		go func() {
+			grpcErr <- grpcServer.Serve(listener)
+		}()
+	}
+
+	// Both servers stop when interrupted or when the gRPC server fails, whose error is
+	// then returned
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	stopped := make(chan error, 1)
+	go func() {
+		var err error
+		select {
+		case <-ctx.Done():
+		case err = <-grpcErr:
+			err = fmt.Errorf("serving gRPC: %w", err)
+		}
+		if grpcServer != nil {
+			// Watch streams only end with their clients, so they are not waited for
+			grpcServer.Stop()
+		}
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdown)
+		stopped <- err
+	}()
+
+	log.Printf("serving devices on %s", *listen)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return <-stopped
+}
+
+// devicesHandler answers with the devices as a JSON array. The vid, pid, serial and
+// transport query parameters filter them like the flags of list.
+func devicesHandler(finder *serialfinder.Finder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		filter := serialfinder.Filter{Vid: query.Get("vid"), Pid: query.Get("pid"), SerialNumber: query.Get("serial")}
+		if name := query.Get("transport"); name != "" {
+			transport, err := serialfinder.ParseTransport(name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			filter.Transport = transport
+		}
+
+		devices, err := finder.List(r.Context(), filter)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, serialfinder.ErrInvalidFilter) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if devices == nil {
+			devices = []serialfinder.SerialDeviceInfo{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(devices)
+	}
+}
+
+// eventJSON is the data of one server-sent event
+type eventJSON struct {
+	Type   string                         `json:"type"`
+	Device *serialfinder.SerialDeviceInfo `json:"device,omitempty"`
+}
+
+// eventsHandler streams hotplug events as server-sent events, starting with an added event
+// for every device present. The event name is the event type: added, removed or resync.
+func eventsHandler(finder *serialfinder.Finder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Listeners must not block the shared watch, so slow clients are disconnected. The
+		// devices present are replayed from within Subscribe, before anything reads the
+		// channel, so they are queued apart and written first, whatever their number.
+		var mu sync.Mutex
+		replaying := true
+		var replay []serialfinder.Event
+		events := make(chan serialfinder.Event, 64)
+		overflow := make(chan struct{})
+		unsubscribe := finder.Subscribe(func(event serialfinder.Event) {
+			mu.Lock()
+			if replaying {
+				replay = append(replay, event)
+				mu.Unlock()
+				return
+			}
+			mu.Unlock()
+			select {
+			case events <- event:
+			default:
+				select {
+				case <-overflow:
+				default:
+					close(overflow)
+				}
+			}
+		})
+		defer unsubscribe()
+
+		mu.Lock()
+		replaying = false
+		pending := replay
+		replay = nil
+		mu.Unlock()
+		for _, event := range pending {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(30 * time.Second)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-overflow:
+				return
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case event := <-events:
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one server-sent event
+func writeEvent(w io.Writer, event serialfinder.Event) error {
+	data := eventJSON{Type: event.Type.String()}
+	if event.Type != serialfinder.EventResync {
+		data.Device = &event.Device
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", data.Type, payload)
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// staticBackend always lists the same devices
+type staticBackend []serialfinder.SerialDeviceInfo
+
+func (b staticBackend) List(ctx context.Context, f serialfinder.Filter) ([]serialfinder.SerialDeviceInfo, error) {
+	return append([]serialfinder.SerialDeviceInfo(nil), b...), nil
+}
+
+func TestDevicesStatus(t *testing.T) {
+	finder := serialfinder.NewFinder(serialfinder.WithCustomBackend(staticBackend{
+		{Port: "/dev/ttyUSB0", Vid: "0403", Pid: "6001", SerialNumber: "A000"},
+	}))
+	server := httptest.NewServer(devicesHandler(finder))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		query  string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"?vid=0403&pid=6001", http.StatusOK},
+		{"?vid=zz", http.StatusBadRequest},
+		{"?pid=123456", http.StatusBadRequest},
+		{"?transport=carrier-pigeon", http.StatusBadRequest},
+	} {
+		resp, err := http.Get(server.URL + tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("GET /devices%s answered %d, want %d", tc.query, resp.StatusCode, tc.status)
+		}
+	}
+}
+
+func TestEventsReplaysManyDevices(t *testing.T) {
+	// More devices than the events buffered for a client
+	var devices staticBackend
+	for i := 0; i < 200; i++ {
+		devices = append(devices, serialfinder.SerialDeviceInfo{
+			Port: fmt.Sprintf("/dev/ttyUSB%d", i), Vid: "0403", Pid: "6001", SerialNumber: fmt.Sprintf("A%03d", i),
+		})
+	}
+	finder := serialfinder.NewFinder(serialfinder.WithCustomBackend(devices), serialfinder.WithPollInterval(time.Hour))
+	// Start the shared watch, so the client is replayed the devices present
+	added := make(chan struct{}, len(devices))
+	defer finder.Subscribe(func(serialfinder.Event) { added <- struct{}{} })()
+	for range devices {
+		<-added
+	}
+
+	server := httptest.NewServer(eventsHandler(finder))
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for n := 0; n < len(devices); {
+		if !scanner.Scan() {
+			t.Fatalf("stream ended after %d of %d devices: %v", n, len(devices), scanner.Err())
+		}
+		if scanner.Text() == "event: added" {
+			n++
+		}
+	}
+}
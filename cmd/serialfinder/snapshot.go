@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["snapshot"] = command{summary: "save the serial devices to a JSON file for diff", run: runSnapshot}
+}
+
+// runSnapshot writes the devices with SaveSnapshot
+func runSnapshot(args []string, stdout io.Writer) error {
+	fs := newFlagSet("snapshot")
+	out := fs.String("out", "", "write the snapshot to this file instead of standard output")
+	nonUSB := fs.Bool("all", false, "also save built-in, Bluetooth and virtual ports")
+	var filters filterFlags
+	filters.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	finder := serialfinder.NewFinder(serialfinder.WithIncludeNonUSB(*nonUSB || needsNonUSB(filter)), serialfinder.WithExtendedInfo(true))
+	devices, err := finder.List(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return serialfinder.SaveSnapshot(stdout, devices)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := serialfinder.SaveSnapshot(file, devices); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "saved %d devices to %s\n", len(devices), *out)
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["udev-rule"] = command{summary: "print or install a udev rule giving a device a stable name", run: runUdevRule}
+}
+
+// runUdevRule prints a udev rule matching the one device selected by the filter flags, and
+// installs it with -install
+func runUdevRule(args []string, stdout io.Writer) error {
+	fs := newFlagSet("udev-rule")
+	symlink := fs.String("symlink", "", "name of the link to create in /dev (required), e.g. my-probe")
+	mode := fs.String("mode", "", "permissions of the device node, e.g. 0660")
+	group := fs.String("group", "", "group owning the device node, e.g. dialout")
+	install := fs.Bool("install", false, "write the rule to -rules-dir and reload udev")
+	rulesDir := fs.String("rules-dir", "/etc/udev/rules.d", "directory the rule is installed into")
+	var filters filterFlags
+	filters.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkUdevRuleFlags(*symlink, *mode, *group); err != nil {
+		return err
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	devices, err := serialfinder.NewFinder().List(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	if len(devices) != 1 {
+		return fmt.Errorf("the flags must select exactly one device, found %d%s", len(devices), portList(devices))
+	}
+
+	rule, err := udevRule(devices[0], *symlink, *mode, *group)
+	if err != nil {
+		return err
+	}
+
+	if !*install {
+		fmt.Fprint(stdout, rule)
+		return nil
+	}
+
+	path := filepath.Join(*rulesDir, "99-serialfinder-"+*symlink+".rules")
+	if err := os.WriteFile(path, []byte(rule), 0o644); err != nil {
+		return err
+	}
+	for _, args := range [][]string{{"control", "--reload-rules"}, {"trigger", "--subsystem-match=tty"}} {
+		if out, err := exec.Command("udevadm", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("rule written to %s, but udevadm %s failed: %v: %s", path, args[0], err, out)
+		}
+	}
+	fmt.Fprintf(stdout, "installed %s; the device is available as /dev/%s\n", path, *symlink)
+	return nil
+}
+
+var (
+	// udevSymlinkRE matches the names -symlink accepts, which stay in /dev
+	udevSymlinkRE = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+	// udevModeRE matches octal permissions such as 660 or 0660
+	udevModeRE = regexp.MustCompile(`^0?[0-7]{3}$`)
+)
+
+// checkUdevRuleFlags rejects the values of -symlink, -mode and -group that would break out of
+// the rule or out of /dev
+func checkUdevRuleFlags(symlink, mode, group string) error {
+	if !udevSymlinkRE.MatchString(symlink) || strings.Contains(symlink, "..") || symlink == "." {
+		return fmt.Errorf("-symlink must be a plain name of letters, digits, '.', '_' and '-', such as my-probe")
+	}
+	if mode != "" && !udevModeRE.MatchString(mode) {
+		return fmt.Errorf("-mode must be octal permissions such as 0660, not %q", mode)
+	}
+	if strings.ContainsAny(group, "\",\r\n") {
+		return fmt.Errorf("-group must be a group name such as dialout, not %q", group)
+	}
+	return nil
+}
+
+// udevRule returns the rule linking /dev/<symlink> to the device. Devices are matched by
+// VID, PID and serial number, or by their position on the USB bus when they have no serial
+// number, and by interface when the device exposes several ports.
+func udevRule(device serialfinder.SerialDeviceInfo, symlink, mode, group string) (string, error) {
+	if device.Transport != serialfinder.TransportUSB || device.Vid == "" || device.Pid == "" {
+		return "", fmt.Errorf("%s is not a USB device; udev rules can only be generated for USB devices", device.Port)
+	}
+
+	matches := []string{
+		`SUBSYSTEM=="tty"`,
+		fmt.Sprintf(`ATTRS{idVendor}=="%s"`, strings.ToLower(device.Vid)),
+		fmt.Sprintf(`ATTRS{idProduct}=="%s"`, strings.ToLower(device.Pid)),
+	}
+	switch {
+	case device.SerialNumber != "":
+		if strings.ContainsAny(device.SerialNumber, "\"\\\n") {
+			return "", fmt.Errorf("the serial number of %s cannot be written in a udev rule", device.Port)
+		}
+		matches = append(matches, fmt.Sprintf(`ATTRS{serial}=="%s"`, device.SerialNumber))
+	case device.Location != "":
+		// Without a serial number the device is only recognizable by the port it is plugged into
+		matches = append(matches, fmt.Sprintf(`KERNELS=="%s"`, device.Location))
+	default:
+		return "", fmt.Errorf("%s has neither a serial number nor a bus location to match on", device.Port)
+	}
+	if len(device.Siblings) > 0 && device.Interface != "" {
+		matches = append(matches, fmt.Sprintf(`ENV{ID_USB_INTERFACE_NUM}=="%s"`, strings.ToLower(device.Interface)))
+	}
+
+	actions := []string{fmt.Sprintf(`SYMLINK+="%s"`, symlink)}
+	if mode != "" {
+		actions = append(actions, fmt.Sprintf(`MODE="%s"`, mode))
+	}
+	if group != "" {
+		actions = append(actions, fmt.Sprintf(`GROUP="%s"`, group))
+	}
+
+	var rule strings.Builder
+	fmt.Fprintf(&rule, "# %s, generated by serialfinder from %s\n", orDash(describe(device)), device.Port)
+	fmt.Fprintf(&rule, "%s, %s\n", strings.Join(matches, ", "), strings.Join(actions, ", "))
+	return rule.String(), nil
+}
+
+// portList formats the ports of the devices for an error message
+func portList(devices []serialfinder.SerialDeviceInfo) string {
+	if len(devices) == 0 {
+		return ""
+	}
+	ports := make([]string, len(devices))
+	for i, device := range devices {
+		ports[i] = device.Port
+	}
+	return ": " + strings.Join(ports, ", ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func TestCheckUdevRuleFlags(t *testing.T) {
+	for _, tc := range []struct {
+		symlink, mode, group string
+		ok                   bool
+	}{
+		{"my-probe", "", "", true},
+		{"ttyUSB.debug_1", "0660", "dialout", true},
+		{"probe", "660", "plugdev", true},
+		{"", "", "", false},
+		{"../shadow", "", "", false},
+		{"serial/probe", "", "", false},
+		{"..", "", "", false},
+		{"a..b", "", "", false},
+		{".", "", "", false},
+		{`probe", RUN+="/bin/sh`, "", "", false},
+		{"my probe", "", "", false},
+		{"probe\n", "", "", false},
+		{"probe", "0666, RUN", "", false},
+		{"probe", "rw", "", false},
+		{"probe", "0680", "", false},
+		{"probe", "00660", "", false},
+		{"probe", "", `dialout", RUN+="x`, false},
+		{"probe", "", "dialout,root", false},
+		{"probe", "", "dialout\nSUBSYSTEM", false},
+	} {
+		err := checkUdevRuleFlags(tc.symlink, tc.mode, tc.group)
+		if (err == nil) != tc.ok {
+			t.Errorf("checkUdevRuleFlags(%q, %q, %q) = %v, want ok %v", tc.symlink, tc.mode, tc.group, err, tc.ok)
+		}
+	}
+}
+
+func TestUdevRule(t *testing.T) {
+	device := serialfinder.SerialDeviceInfo{
+		Port: "/dev/ttyUSB0", Vid: "0403", Pid: "6001", SerialNumber: "A50285BI", Transport: serialfinder.TransportUSB,
+	}
+	rule, err := udevRule(device, "my-probe", "0660", "dialout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `SUBSYSTEM=="tty", ATTRS{idVendor}=="0403", ATTRS{idProduct}=="6001", ATTRS{serial}=="A50285BI", SYMLINK+="my-probe", MODE="0660", GROUP="dialout"` + "\n"
+	if !strings.HasSuffix(rule, want) {
+		t.Errorf("rule is\n%s\nwant it to end with\n%s", rule, want)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["validate"] = command{summary: "check the attached devices against devices.yaml", run: runValidate}
+}
+
+// errValidationFailed makes validate exit with a non-zero status after printing its result
+var errValidationFailed = errors.New("devices do not match the devices file")
+
+// runValidate compares the attached devices with a devices file
+func runValidate(args []string, stdout io.Writer) error {
+	fs := newFlagSet("validate")
+	path := fs.String("file", "", "devices file to check against instead of devices.yaml in the configuration directory")
+	nonUSB := fs.Bool("all", false, "also check built-in, Bluetooth and virtual ports")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	finder := serialfinder.NewFinder(serialfinder.WithIncludeNonUSB(*nonUSB))
+	validation, err := finder.ValidateDevices(context.Background(), *path)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(validation); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(stdout, validation)
+	}
+
+	if !validation.OK() {
+		return errValidationFailed
+	}
+	return nil
+}
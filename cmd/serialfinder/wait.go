@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+func init() {
+	commands["wait"] = command{summary: "wait for a device to appear and print its port", run: runWait}
+}
+
+// runWait blocks until a device matching the filter flags is present and prints its port
+func runWait(args []string, stdout io.Writer) error {
+	fs := newFlagSet("wait")
+	timeout := fs.Duration("timeout", 0, "give up after this long, e.g. 30s; 0 waits forever")
+	nonUSB := fs.Bool("all", false, "also consider built-in, Bluetooth and virtual ports")
+	var filters filterFlags
+	filters.register(fs)
+	var output outputFlags
+	output.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := output.check(); err != nil {
+		return err
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	finder := serialfinder.NewFinder(serialfinder.WithIncludeNonUSB(*nonUSB || needsNonUSB(filter)))
+	device, err := finder.WaitForDevice(ctx, filter)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("no matching device appeared within %v", *timeout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !output.table() {
+		return output.write(stdout, []serialfinder.SerialDeviceInfo{device})
+	}
+	fmt.Fprintln(stdout, device.Port)
+	return nil
+}
@@ -0,0 +1,72 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultCommandTimeout bounds the tools backends run unless WithCommandTimeout says otherwise
+const defaultCommandTimeout = 30 * time.Second
+
+// CommandTimeoutError reports a tool a backend runs, such as ioreg, system_profiler, lsof
+// or PowerShell, that did not finish in time and was killed. A misbehaving kernel extension
+// can make ioreg hang indefinitely.
+type CommandTimeoutError struct {
+	// Command is the name of the tool
+	Command string
+	Timeout time.Duration
+}
+
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("serialfinder: %s did not finish within %v and was killed", e.Command, e.Timeout)
+}
+
+// WithCommandTimeout sets how long the tools a backend runs (ioreg, system_profiler and
+// lsof on macOS, PowerShell for the WMI backend on Windows) may take before they are
+// killed and the scan fails with a *CommandTimeoutError, 30 seconds by default. A negative
+// timeout lets them run until the context is canceled.
+func WithCommandTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.commandTimeout = timeout
+	}
+}
+
+// toolTimeout returns the timeout of the tools a backend runs
+func (o options) toolTimeout() time.Duration {
+	if o.commandTimeout == 0 {
+		return defaultCommandTimeout
+	}
+	return o.commandTimeout
+}
+
+// runCommand runs cmd in a span named after the tool. The process is killed once the
+// timeout expires, and a *CommandTimeoutError returned; a timeout of zero or less means none.
+func runCommand(ctx context.Context, cmd *exec.Cmd, timeout time.Duration) error {
+	name := filepath.Base(cmd.Path)
+	_, span := startSpan(ctx, "serialfinder.exec", attribute.String("process.executable.name", name))
+
+	err := cmd.Start()
+	if err == nil {
+		var timedOut atomic.Bool
+		if timeout > 0 {
+			timer := time.AfterFunc(timeout, func() {
+				timedOut.Store(true)
+				cmd.Process.Kill()
+			})
+			defer timer.Stop()
+		}
+		err = cmd.Wait()
+		if timedOut.Load() {
+			err = &CommandTimeoutError{Command: name, Timeout: timeout}
+		}
+	}
+
+	endSpan(span, err)
+	return err
+}
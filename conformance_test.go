@@ -0,0 +1,301 @@
+package serialfinder_test
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hs0zip/serialfinder"
+	"github.com/hs0zip/serialfinder/serialfindertest"
+)
+
+// The platform backends are run against the shared conformance suite on fakes of their
+// inputs, so every platform is checked on every platform
+
+func TestConformanceSysfs(t *testing.T) {
+	serialfindertest.Run(t, installSysfs)
+}
+
+func TestConformanceIoregText(t *testing.T) {
+	serialfindertest.Run(t, func(t *testing.T, sys serialfindertest.System) serialfindertest.Lister {
+		return installIoreg(t, sys, ioregText)
+	})
+}
+
+func TestConformanceIoregXML(t *testing.T) {
+	serialfindertest.Run(t, func(t *testing.T, sys serialfindertest.System) serialfindertest.Lister {
+		return installIoreg(t, sys, ioregXML)
+	})
+}
+
+func TestConformanceRegistry(t *testing.T) {
+	serialfindertest.Run(t, installRegistry)
+}
+
+// lister lists the devices of a backend as a Finder hands the filter to it, renaming the
+// ports the backend reports to the ports of the system description
+func lister(backend serialfinder.Backend, ports map[string]string) serialfindertest.Lister {
+	return func(ctx context.Context, filter serialfinder.Filter) ([]serialfinder.SerialDeviceInfo, error) {
+		filter, err := filter.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		devices, err := backend.List(ctx, filter)
+		for i, device := range devices {
+			if port, ok := ports[device.Port]; ok {
+				devices[i].Port = port
+			}
+		}
+		return devices, err
+	}
+}
+
+// linkFS adds the ReadLink method of fs.ReadLinkFS to a MapFS whose symbolic links hold their
+// target as data
+type linkFS struct {
+	fstest.MapFS
+}
+
+func (l linkFS) ReadLink(name string) (string, error) {
+	file, ok := l.MapFS[name]
+	if !ok || file.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(file.Data), nil
+}
+
+// deniedFS fails with fs.ErrPermission to open the named file or directory, or anything
+// below it, as when the current user may not read it
+type deniedFS struct {
+	fsys   linkFS
+	denied string
+}
+
+func (d deniedFS) Open(name string) (fs.File, error) {
+	if name == d.denied || strings.HasPrefix(name, d.denied+"/") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.fsys.Open(name)
+}
+
+func (d deniedFS) ReadLink(name string) (string, error) {
+	return d.fsys.ReadLink(name)
+}
+
+// installSysfs lays out a sysfs tree with a usb-serial tty for each device, without
+// /dev/serial/by-id, as on systems without udev
+func installSysfs(t *testing.T, sys serialfindertest.System) serialfindertest.Lister {
+	fsys, ports := sysfsTree(sys.Devices)
+	if sys.PermissionDenied {
+		return lister(serialfinder.SysfsBackend(deniedFS{linkFS{fsys}, "sys/class/tty"}), ports)
+	}
+	return lister(serialfinder.SysfsBackend(linkFS{fsys}), ports)
+}
+
+// sysfsTree lays out the tree of installSysfs, mapping the ports of the tree, /dev/ttyUSBn for
+// the nth device, to the ports of the devices
+func sysfsTree(devices []serialfinder.SerialDeviceInfo) (fstest.MapFS, map[string]string) {
+	fsys := fstest.MapFS{"sys/class/tty": &fstest.MapFile{Mode: fs.ModeDir | 0o755}}
+	ports := make(map[string]string)
+	for i, device := range devices {
+		tty := fmt.Sprintf("ttyUSB%d", i)
+		usbDir := fmt.Sprintf("sys/devices/pci0000:00/0000:00:14.0/usb1/1-%d", i+1)
+		ttyDir := fmt.Sprintf("%s/1-%d:1.0/%s", usbDir, i+1, tty)
+		fsys[usbDir+"/idVendor"] = &fstest.MapFile{Data: []byte(strings.ToLower(device.Vid) + "\n")}
+		fsys[usbDir+"/idProduct"] = &fstest.MapFile{Data: []byte(strings.ToLower(device.Pid) + "\n")}
+		if device.SerialNumber != "" {
+			fsys[usbDir+"/serial"] = &fstest.MapFile{Data: []byte(device.SerialNumber + "\n")}
+		}
+		fsys[ttyDir] = &fstest.MapFile{Mode: fs.ModeDir | 0o755}
+		fsys[path.Join("sys/class/tty", tty, "device")] = &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte("/" + ttyDir)}
+		fsys[path.Join("dev", tty)] = &fstest.MapFile{Mode: fs.ModeDevice | fs.ModeCharDevice | 0o660}
+		ports[path.Join("/dev", tty)] = device.Port
+	}
+	return fsys, ports
+}
+
+// TestConformanceSysfsFindFirstInUse checks that a scan stopped at the first match still
+// reports whether the device is in use
+func TestConformanceSysfsFindFirstInUse(t *testing.T) {
+	fsys, _ := sysfsTree([]serialfinder.SerialDeviceInfo{
+		{Vid: "0403", Pid: "6001", SerialNumber: "A1"},
+		{Vid: "0403", Pid: "6001", SerialNumber: "A2"},
+	})
+	for _, inUse := range []bool{false, true} {
+		var held []string
+		if inUse {
+			held = []string{"/dev/ttyUSB0"}
+		}
+		f := serialfinder.SysfsFinder(linkFS{fsys}, held, serialfinder.WithInUseCheck(true))
+		device, err := f.FindFirst(context.Background(), serialfinder.Filter{Vid: "0403"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if device.Port != "/dev/ttyUSB0" || device.InUse != inUse {
+			t.Errorf("FindFirst found %s in use %v, want /dev/ttyUSB0 in use %v", device.Port, device.InUse, inUse)
+		}
+	}
+}
+
+// ioreg formats
+const (
+	ioregText = iota
+	ioregXML
+)
+
+// installIoreg writes the ioreg output listing a USB device with one serial client for each
+// device
+func installIoreg(t *testing.T, sys serialfindertest.System, format int) serialfindertest.Lister {
+	ports := make(map[string]string)
+	var b strings.Builder
+	if format == ioregText {
+		b.WriteString("+-o Root  <class IORegistryEntry, id 0x100000100>\n")
+	} else {
+		b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n<plist version=\"1.0\">\n<array>\n")
+	}
+	for i, device := range sys.Devices {
+		vid, _ := strconv.ParseInt(device.Vid, 16, 64)
+		pid, _ := strconv.ParseInt(device.Pid, 16, 64)
+		callout := fmt.Sprintf("/dev/cu.usbserial-%d", i)
+		ports[callout] = device.Port
+
+		if format == ioregText {
+			fmt.Fprintf(&b, "  +-o Device@%d  <class IOUSBHostDevice, id 0x%x>\n", i+1, 0x1000+i)
+			fmt.Fprintf(&b, "    {\n      \"idVendor\" = %d\n      \"idProduct\" = %d\n", vid, pid)
+			if device.SerialNumber != "" {
+				fmt.Fprintf(&b, "      \"USB Serial Number\" = %q\n", device.SerialNumber)
+			}
+			fmt.Fprintf(&b, "      \"locationID\" = %d\n    }\n", (i+1)<<24)
+			fmt.Fprintf(&b, "    +-o AppleUSBFTDI  <class AppleUSBFTDI, id 0x%x>\n", 0x2000+i)
+			fmt.Fprintf(&b, "      +-o IOSerialBSDClient  <class IOSerialBSDClient, id 0x%x>\n", 0x3000+i)
+			fmt.Fprintf(&b, "          {\n            \"IOCalloutDevice\" = %q\n          }\n", callout)
+			continue
+		}
+		fmt.Fprintf(&b, "<dict>\n<key>idVendor</key><integer>%d</integer>\n<key>idProduct</key><integer>%d</integer>\n", vid, pid)
+		if device.SerialNumber != "" {
+			fmt.Fprintf(&b, "<key>USB Serial Number</key><string>%s</string>\n", device.SerialNumber)
+		}
+		fmt.Fprintf(&b, "<key>IORegistryEntryChildren</key><array><dict>\n<key>IOObjectClass</key><string>AppleUSBFTDI</string>\n")
+		fmt.Fprintf(&b, "<key>IORegistryEntryChildren</key><array><dict><key>IOCalloutDevice</key><string>%s</string></dict></array>\n", callout)
+		b.WriteString("</dict></array>\n</dict>\n")
+	}
+	if format == ioregXML {
+		b.WriteString("</array>\n</plist>\n")
+	}
+
+	fsys := fstest.MapFS{serialfinder.IoregCaptureName: &fstest.MapFile{Data: []byte(b.String())}}
+	if sys.PermissionDenied {
+		return lister(serialfinder.IoregBackend(deniedFS{linkFS{fsys}, serialfinder.IoregCaptureName}), ports)
+	}
+	return lister(serialfinder.IoregBackend(fsys), ports)
+}
+
+// fakeRegistry is a registry of keys with string values
+type fakeRegistry struct {
+	subKeys map[string][]string
+	values  map[string]map[string]string
+	// denied makes every key unreadable
+	denied bool
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{subKeys: make(map[string][]string), values: make(map[string]map[string]string)}
+}
+
+// set creates the key with its parents and sets one of its values
+func (r *fakeRegistry) set(key, name, value string) {
+	r.addKey(key)
+	if r.values[key] == nil {
+		r.values[key] = make(map[string]string)
+	}
+	r.values[key][name] = value
+}
+
+// addKey creates the key and its missing parents
+func (r *fakeRegistry) addKey(key string) {
+	if _, ok := r.subKeys[key]; ok {
+		return
+	}
+	r.subKeys[key] = nil
+	if i := strings.LastIndex(key, `\`); i >= 0 {
+		r.addKey(key[:i])
+		r.subKeys[key[:i]] = append(r.subKeys[key[:i]], key[i+1:])
+	}
+}
+
+func (r *fakeRegistry) open(key string) error {
+	if r.denied {
+		return &fs.PathError{Op: "open", Path: key, Err: fs.ErrPermission}
+	}
+	if _, ok := r.subKeys[key]; !ok {
+		return &fs.PathError{Op: "open", Path: key, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+func (r *fakeRegistry) SubKeyNames(key string) ([]string, error) {
+	if err := r.open(key); err != nil {
+		return nil, err
+	}
+	return r.subKeys[key], nil
+}
+
+func (r *fakeRegistry) ValueNames(key string) ([]string, error) {
+	if err := r.open(key); err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(r.values[key]))
+	for name := range r.values[key] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r *fakeRegistry) StringValue(key, name string) (string, error) {
+	if err := r.open(key); err != nil {
+		return "", err
+	}
+	value, ok := r.values[key][name]
+	if !ok {
+		return "", &fs.PathError{Op: "read", Path: key + `\` + name, Err: fs.ErrNotExist}
+	}
+	return value, nil
+}
+
+// presentNodes reports every device instance as present and started
+type presentNodes struct{}
+
+func (presentNodes) Located(string) bool                    { return true }
+func (presentNodes) Present(string) (bool, error)           { return true, nil }
+func (presentNodes) Arrival(string) time.Time               { return time.Time{} }
+func (presentNodes) Topology(string) *serialfinder.Topology { return nil }
+func (presentNodes) Power(string) *serialfinder.PowerInfo   { return nil }
+
+// installRegistry enumerates each device below Enum\USB with a COM port. Devices without a
+// serial number get an instance ID generated by Windows.
+func installRegistry(t *testing.T, sys serialfindertest.System) serialfindertest.Lister {
+	reg := newFakeRegistry()
+	reg.addKey(`SYSTEM\CurrentControlSet\Enum\USB`)
+	ports := make(map[string]string)
+	for i, device := range sys.Devices {
+		instance := device.SerialNumber
+		if instance == "" {
+			instance = fmt.Sprintf("6&2a1b3c4d&0&%d", i+1)
+		}
+		port := fmt.Sprintf("COM%d", i+3)
+		key := fmt.Sprintf(`SYSTEM\CurrentControlSet\Enum\USB\VID_%s&PID_%s\%s`, device.Vid, device.Pid, instance)
+		reg.set(key, "Service", "usbser")
+		reg.set(key+`\Device Parameters`, "PortName", port)
+		ports[port] = device.Port
+	}
+	reg.denied = sys.PermissionDenied
+	return lister(serialfinder.RegistryBackend(reg, presentNodes{}), ports)
+}
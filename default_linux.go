@@ -0,0 +1,9 @@
+//go:build linux && !android
+// +build linux,!android
+
+package serialfinder
+
+// defaultBackend returns the backend used when no other backend is selected
+func defaultBackend() backend {
+	return byIDBackend{}
+}
@@ -0,0 +1,180 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Severity ranks the findings of Diagnose
+type Severity int
+
+const (
+	// SeverityOK is a check that passed
+	SeverityOK Severity = iota
+	// SeverityWarning is a problem that may explain missing or misbehaving devices
+	SeverityWarning
+	// SeverityError is a problem that prevents finding or opening devices
+	SeverityError
+)
+
+var severityNames = map[Severity]string{
+	SeverityOK:      "ok",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns the lower-case name of the severity
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name
+func (s *Severity) UnmarshalText(text []byte) error {
+	for severity, name := range severityNames {
+		if name == string(text) {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("serialfinder: unknown severity %q", text)
+}
+
+// FindingCode identifies the kind of a finding, so programs can react to specific problems
+type FindingCode string
+
+// The findings Diagnose reports. Codes are stable; messages are meant for people and may change.
+const (
+	// FindingScan is the outcome of the scan itself
+	FindingScan FindingCode = "scan"
+	// FindingNoDevices means the scan succeeded but found no device
+	FindingNoDevices FindingCode = "no-devices"
+	// FindingPortAccess is whether the current user can open a port
+	FindingPortAccess FindingCode = "port-access"
+	// FindingNotInGroup means a port can only be opened by a group the user is not in,
+	// such as dialout
+	FindingNotInGroup FindingCode = "not-in-group"
+	// FindingPortInUse means another process holds a port open
+	FindingPortInUse FindingCode = "port-in-use"
+	// FindingByIDDir is whether /dev/serial/by-id exists on Linux
+	FindingByIDDir FindingCode = "by-id-dir"
+	// FindingWSL reports that the program runs under WSL
+	FindingWSL FindingCode = "wsl"
+	// FindingDriverMissing means a USB serial interface has no driver bound
+	FindingDriverMissing FindingCode = "driver-missing"
+	// FindingGhostPorts means Windows keeps COM numbers reserved for detached devices
+	FindingGhostPorts FindingCode = "ghost-ports"
+	// FindingTool is whether a tool a backend runs is in PATH on macOS
+	FindingTool FindingCode = "tool"
+)
+
+// Finding is one result of Diagnose
+type Finding struct {
+	Code     FindingCode `json:"code"`
+	Severity Severity    `json:"severity"`
+	// Subject is what the finding is about: a port, a device, a directory or a tool
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+	// Remediation tells how to fix the problem; empty for findings without one
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Report is the result of Diagnose: the devices found and the findings about them and the
+// system
+type Report struct {
+	Devices  []SerialDeviceInfo `json:"devices"`
+	Findings []Finding          `json:"findings"`
+}
+
+// Severity returns the highest severity of the findings
+func (r *Report) Severity() Severity {
+	worst := SeverityOK
+	for _, finding := range r.Findings {
+		if finding.Severity > worst {
+			worst = finding.Severity
+		}
+	}
+	return worst
+}
+
+// Diagnose looks for the reasons devices may be missing or impossible to open: a failed
+// scan, ports the user has no permission for or another process holds, and platform
+// problems such as a missing /dev/serial/by-id, unbound drivers or COM ports reserved by
+// detached devices. The options configure the scan; the in-use check is always enabled.
+// It only returns an error if the context is canceled.
+func Diagnose(ctx context.Context, opts ...Option) (*Report, error) {
+	opts = append(opts, WithInUseCheck(true))
+	devices, err := NewFinder(opts...).List(ctx, Filter{})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+
+	report := &Report{Devices: devices}
+	switch {
+	case err != nil:
+		report.add(Finding{Code: FindingScan, Severity: SeverityError, Subject: "scan", Message: err.Error(), Remediation: scanRemediation(err)})
+	case len(devices) == 0:
+		report.add(Finding{Code: FindingNoDevices, Severity: SeverityWarning, Subject: "scan", Message: "no USB serial devices found", Remediation: "plug the device in and check the cable carries data, not only power"})
+	default:
+		report.add(Finding{Code: FindingScan, Subject: "scan", Message: fmt.Sprintf("%d USB serial device(s) found", len(devices))})
+	}
+
+	for _, device := range devices {
+		if device.Port != "" {
+			report.add(portFindings(device)...)
+		}
+	}
+	report.add(platformFindings(devices)...)
+	return report, nil
+}
+
+// add appends findings to the report
+func (r *Report) add(findings ...Finding) {
+	r.Findings = append(r.Findings, findings...)
+}
+
+// scanRemediation suggests what to do about a failed scan
+func scanRemediation(err error) string {
+	switch {
+	case errors.Is(err, ErrNoDevicesInWSL):
+		return "share the device from Windows: usbipd bind --busid <busid>, then usbipd attach --wsl --busid <busid>"
+	case errors.Is(err, ErrUnsupportedPlatform):
+		return "serialfinder cannot scan for devices on this operating system"
+	}
+	return ""
+}
+
+// portFindings checks that the port of a device can be opened
+func portFindings(device SerialDeviceInfo) []Finding {
+	port := device.Port
+	var findings []Finding
+
+	access, err := CheckAccess(port)
+	switch {
+	case errors.Is(err, errors.ErrUnsupported):
+		// Access is governed by groups on Linux only
+	case err != nil:
+		findings = append(findings, Finding{Code: FindingPortAccess, Severity: SeverityError, Subject: port, Message: err.Error(), Remediation: "the device node is missing; unplug and replug the device"})
+	case !access.CanOpen():
+		code := FindingPortAccess
+		if !access.InGroup && access.Group != "" && access.Group != "root" {
+			code = FindingNotInGroup
+		}
+		findings = append(findings, Finding{Code: code, Severity: SeverityError, Subject: port, Message: "no permission to open the port", Remediation: access.Hint()})
+	default:
+		findings = append(findings, Finding{Code: FindingPortAccess, Subject: port, Message: "can be opened"})
+	}
+
+	if device.InUse {
+		findings = append(findings, Finding{Code: FindingPortInUse, Severity: SeverityWarning, Subject: port, Message: "held open by another process", Remediation: "close the other program (serial monitor, ModemManager, brltty, ...) before opening the port"})
+	}
+	return findings
+}
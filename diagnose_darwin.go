@@ -0,0 +1,27 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import "os/exec"
+
+// platformFindings verifies the tools the macOS backends run are available
+func platformFindings(devices []SerialDeviceInfo) []Finding {
+	tools := []struct {
+		name, use string
+	}{
+		{"ioreg", "lists the USB serial devices"},
+		{"system_profiler", "is the fallback when ioreg output cannot be used"},
+		{"lsof", "tells which ports are in use"},
+	}
+
+	var findings []Finding
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool.name); err != nil {
+			findings = append(findings, Finding{Code: FindingTool, Severity: SeverityError, Subject: tool.name, Message: "not found in PATH; it " + tool.use, Remediation: "add /usr/sbin and /usr/bin to PATH"})
+		} else {
+			findings = append(findings, Finding{Code: FindingTool, Subject: tool.name, Message: "available"})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,79 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// serialDrivers are the kernel modules of common USB serial chips, by VID
+var serialDrivers = map[string]string{
+	"0403": "ftdi_sio",
+	"10c4": "cp210x",
+	"1a86": "ch341",
+	"067b": "pl2303",
+}
+
+// platformFindings looks for the udev links, USB devices without a bound driver and WSL setup
+func platformFindings(devices []SerialDeviceInfo) []Finding {
+	var findings []Finding
+
+	if _, err := os.Stat(serialByIDDir); err != nil {
+		finding := Finding{Code: FindingByIDDir, Severity: SeverityWarning, Subject: serialByIDDir, Message: "missing"}
+		if len(devices) > 0 {
+			finding.Remediation = "udev is not running or has no rules for serial devices; ports are found through sysfs instead, but have no stable names"
+		} else {
+			finding.Remediation = "udev creates it when the first USB serial device is attached"
+		}
+		findings = append(findings, finding)
+	} else {
+		findings = append(findings, Finding{Code: FindingByIDDir, Subject: serialByIDDir, Message: "present"})
+	}
+
+	if IsWSL() {
+		findings = append(findings, Finding{Code: FindingWSL, Subject: "WSL", Message: "running under WSL; USB devices must be attached with usbipd"})
+	}
+
+	return append(findings, unboundInterfaceFindings()...)
+}
+
+// unboundInterfaceFindings reports the USB interfaces of serial class (CDC, vendor specific)
+// that no driver is bound to, which usually means a missing kernel module
+func unboundInterfaceFindings() []Finding {
+	var findings []Finding
+	interfaces, _ := filepath.Glob("/sys/bus/usb/devices/*:*")
+	for _, iface := range interfaces {
+		class := readSysfsString(hostSysfs{}, iface, "bInterfaceClass")
+		// 02 communications, 0a CDC data, ff vendor specific (FTDI, CP210x, CH340, ...)
+		if class != "02" && class != "0a" && class != "ff" {
+			continue
+		}
+		if _, err := os.Lstat(filepath.Join(iface, "driver")); err == nil {
+			continue
+		}
+
+		usbDir := filepath.Dir(iface)
+		vid := readSysfsString(hostSysfs{}, usbDir, "idVendor")
+		pid := readSysfsString(hostSysfs{}, usbDir, "idProduct")
+		driver, known := serialDrivers[vid]
+		if !known && class == "ff" {
+			// Many vendor-specific interfaces are not serial ports at all
+			continue
+		}
+		if !known {
+			driver = "cdc_acm"
+		}
+		findings = append(findings, Finding{
+			Code:        FindingDriverMissing,
+			Severity:    SeverityWarning,
+			Subject:     fmt.Sprintf("%s:%s", strings.ToUpper(vid), strings.ToUpper(pid)),
+			Message:     fmt.Sprintf("interface %s has no driver bound", filepath.Base(iface)),
+			Remediation: fmt.Sprintf("load the driver with sudo modprobe %s; on WSL the kernel may lack it", driver),
+		})
+	}
+	return findings
+}
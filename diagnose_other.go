@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+// platformFindings has nothing to check on platforms without a backend
+func platformFindings(devices []SerialDeviceInfo) []Finding {
+	return nil
+}
@@ -0,0 +1,54 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// platformFindings reports COM ports that the registry still records for devices that are
+// no longer attached ("ghost" ports), which keep their COM number reserved
+func platformFindings(devices []SerialDeviceInfo) []Finding {
+	present := make(map[string]bool)
+	for _, device := range devices {
+		present[strings.ToUpper(device.Port)] = true
+	}
+
+	reg := &hostRegistry{}
+	defer reg.Close()
+	usbKey := `SYSTEM\CurrentControlSet\Enum\USB`
+	ids, err := reg.SubKeyNames(usbKey)
+	if err != nil {
+		return []Finding{{Code: FindingGhostPorts, Severity: SeverityWarning, Subject: "registry", Message: fmt.Sprintf("cannot read Enum\\USB: %v", err)}}
+	}
+
+	ghosts := make(map[string]bool)
+	for _, id := range ids {
+		instances, _ := reg.SubKeyNames(usbKey + `\` + id)
+		for _, instance := range instances {
+			port, err := reg.StringValue(usbKey+`\`+id+`\`+instance+`\Device Parameters`, "PortName")
+			if err == nil && strings.HasPrefix(strings.ToUpper(port), "COM") && !present[strings.ToUpper(port)] {
+				ghosts[port] = true
+			}
+		}
+	}
+
+	if len(ghosts) == 0 {
+		return []Finding{{Code: FindingGhostPorts, Subject: "registry", Message: "no COM ports reserved by detached devices"}}
+	}
+	ports := make([]string, 0, len(ghosts))
+	for port := range ghosts {
+		ports = append(ports, port)
+	}
+	sort.Strings(ports)
+	return []Finding{{
+		Code:        FindingGhostPorts,
+		Severity:    SeverityWarning,
+		Subject:     "registry",
+		Message:     fmt.Sprintf("COM ports reserved by detached devices: %s", strings.Join(ports, ", ")),
+		Remediation: "remove them in Device Manager (View > Show hidden devices) or with pnputil /remove-device to free their COM numbers",
+	}}
+}
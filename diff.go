@@ -0,0 +1,21 @@
+package serialfinder
+
+import "reflect"
+
+// Diff compares two snapshots of devices by StableID. It returns the devices of new that
+// are not in old, the devices of old that are not in new, and the devices of new whose
+// attributes differ from their entry in old, such as a port that became in use. Each list
+// is ordered by ID.
+func Diff(old, new []SerialDeviceInfo) (added, removed, changed []SerialDeviceInfo) {
+	before, after := NewDeviceSet(old...), NewDeviceSet(new...)
+
+	added = after.Difference(before).Devices()
+	removed = before.Difference(after).Devices()
+	for _, id := range after.IDs() {
+		previous, ok := before[id]
+		if ok && !reflect.DeepEqual(previous, after[id]) {
+			changed = append(changed, after[id])
+		}
+	}
+	return added, removed, changed
+}
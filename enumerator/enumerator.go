@@ -0,0 +1,66 @@
+// Package enumerator mirrors the API of go.bug.st/serial/enumerator on top of
+// serialfinder, so projects using that package can switch to the serialfinder backends by
+// changing the import path:
+//
+//	import "github.com/hs0zip/serialfinder/enumerator"
+//
+//	ports, err := enumerator.GetDetailedPortsList()
+package enumerator
+
+import (
+	"context"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// PortDetails has the fields of go.bug.st/serial/enumerator.PortDetails
+type PortDetails struct {
+	Name         string
+	IsUSB        bool
+	VID          string
+	PID          string
+	SerialNumber string
+
+	// Product is the product name the device reports, or a description of the port when
+	// it reports none
+	Product string
+}
+
+// GetDetailedPortsList returns every serial port of the system, USB or not, like its
+// go.bug.st counterpart. VID and PID are upper-case hex and empty for ports that are not
+// USB devices.
+func GetDetailedPortsList() ([]*PortDetails, error) {
+	return GetDetailedPortsListContext(context.Background())
+}
+
+// GetDetailedPortsListContext is GetDetailedPortsList with a context, and with options
+// for the underlying Finder
+func GetDetailedPortsListContext(ctx context.Context, opts ...serialfinder.Option) ([]*PortDetails, error) {
+	opts = append([]serialfinder.Option{serialfinder.WithIncludeNonUSB(true), serialfinder.WithExtendedInfo(true)}, opts...)
+	devices, err := serialfinder.NewFinder(opts...).List(ctx, serialfinder.Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]*PortDetails, 0, len(devices))
+	for _, device := range devices {
+		ports = append(ports, FromDevice(device))
+	}
+	return ports, nil
+}
+
+// FromDevice converts a device found by serialfinder
+func FromDevice(device serialfinder.SerialDeviceInfo) *PortDetails {
+	product := device.Product
+	if product == "" {
+		product = device.Description
+	}
+	return &PortDetails{
+		Name:         device.Port,
+		IsUSB:        device.Transport == serialfinder.TransportUSB,
+		VID:          device.Vid,
+		PID:          device.Pid,
+		SerialNumber: device.SerialNumber,
+		Product:      product,
+	}
+}
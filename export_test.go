@@ -0,0 +1,61 @@
+package serialfinder
+
+import (
+	"context"
+	"io/fs"
+)
+
+// RegistryTree and DevNodeSource let tests outside the package fake the Windows registry and
+// configuration manager
+type (
+	RegistryTree  = registryTree
+	DevNodeSource = devNodeSource
+)
+
+// backendFunc adapts an internal scan to Backend for the tests of package serialfinder_test
+type backendFunc func(ctx context.Context, f Filter) ([]SerialDeviceInfo, error)
+
+func (b backendFunc) List(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+	return b(ctx, f)
+}
+
+// SysfsBackend scans a captured sysfs tree as the Linux backends scan the running system
+func SysfsBackend(fsys fs.FS) Backend {
+	return backendFunc(func(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+		return replaySysfs(ctx, capturedSysfs{fsys: fsys}, f, options{})
+	})
+}
+
+// SysfsFinder returns a Finder with the options given that scans a captured sysfs tree as
+// the Linux backends scan the running system, where the nodes inUse are held open
+func SysfsFinder(fsys fs.FS, inUse []string, opts ...Option) *Finder {
+	f := NewFinder(opts...)
+	f.backend = sysfsScan{sys: capturedSysfs{fsys: fsys}, byID: true, inUse: func(ctx context.Context, nodes []string) map[string]bool {
+		held := make(map[string]bool)
+		for _, node := range inUse {
+			held[node] = true
+		}
+		return held
+	}}
+	return f
+}
+
+// IoregBackend parses the ioreg output stored in fsys as the macOS backend parses the output
+// of the tool
+func IoregBackend(fsys fs.FS) Backend {
+	return backendFunc(func(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+		return replayIoreg(ctx, fsys, f, options{})
+	})
+}
+
+// RegistryBackend walks a registry as the Windows backend walks the registry of the machine,
+// with the options given
+func RegistryBackend(reg RegistryTree, nodes DevNodeSource, opts ...Option) Backend {
+	o := newOptions(opts)
+	return backendFunc(func(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+		return registryScan{reg: reg, nodes: nodes}.list(ctx, f, o)
+	})
+}
+
+// IoregCaptureName is the name IoregBackend reads the output from
+const IoregCaptureName = ioregCaptureName
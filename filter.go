@@ -0,0 +1,183 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidFilter is returned when the VID or PID of a filter is not a 16-bit hex number
+var ErrInvalidFilter = errors.New("serialfinder: invalid filter")
+
+// Filter selects devices by their attributes. Empty fields match any device.
+type Filter struct {
+	// Vid and Pid are hex numbers, with or without a 0x prefix, in any case and with or
+	// without leading zeros: "0403", "0x403" and "403" are the same. Vid also accepts both
+	// IDs as "0403:6001" when Pid is empty.
+	//
+	// Each can also match a family of IDs: "?" stands for any hex digit, so "60??" matches
+	// the FTDI 60xx parts, and "6001-6015" matches the IDs in that range, bounds included.
+	Vid string
+	Pid string
+	// Transport selects devices on one bus; TransportUnknown matches any bus
+	Transport TransportType
+	// SerialNumber selects the device with exactly this serial number
+	SerialNumber string
+	// SerialRegexp and PortRegexp select devices whose serial number or port matches the
+	// expression somewhere; anchor it with ^ and $ to match the whole value
+	SerialRegexp *regexp.Regexp
+	PortRegexp   *regexp.Regexp
+}
+
+// Match reports whether the device satisfies the filter
+func (f Filter) Match(device SerialDeviceInfo) bool {
+	if f.Transport != TransportUnknown && f.Transport != device.Transport {
+		return false
+	}
+	if f.SerialNumber != "" && f.SerialNumber != device.SerialNumber {
+		return false
+	}
+	if f.SerialRegexp != nil && !f.SerialRegexp.MatchString(device.SerialNumber) {
+		return false
+	}
+	if f.PortRegexp != nil && !f.PortRegexp.MatchString(device.Port) {
+		return false
+	}
+	return f.matchIDs(device.Vid, device.Pid)
+}
+
+// Normalize returns the filter with its VID and PID as four upper-case hex digits, like the
+// backends report them, or as patterns and ranges of such, splitting a Vid of the form
+// "0403:6001". It returns an error wrapping ErrInvalidFilter if they are neither 16-bit hex
+// numbers nor valid patterns or ranges. List normalizes filters
+// itself; Normalize lets programs validate user input up front.
+func (f Filter) Normalize() (Filter, error) {
+	if vid, pid, ok := strings.Cut(f.Vid, ":"); ok {
+		if f.Pid != "" {
+			return f, fmt.Errorf("%w: vid %q has a product ID and pid is set too", ErrInvalidFilter, f.Vid)
+		}
+		f.Vid, f.Pid = vid, pid
+	}
+
+	var err error
+	if f.Vid, err = normalizeUSBID("vid", f.Vid); err != nil {
+		return f, err
+	}
+	if f.Pid, err = normalizeUSBID("pid", f.Pid); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// normalizeUSBID formats a VID or PID as four upper-case hex digits, a pattern of four
+// digits or ?, or a range of two IDs. Empty IDs stay empty.
+func normalizeUSBID(name, id string) (string, error) {
+	if lo, hi, ok := strings.Cut(id, "-"); ok {
+		first, err := normalizeHexID(name, id, lo, false)
+		if err != nil {
+			return "", err
+		}
+		last, err := normalizeHexID(name, id, hi, false)
+		if err != nil {
+			return "", err
+		}
+		if first == "" || last == "" || first > last {
+			return "", fmt.Errorf("%w: %s range %q is empty or open", ErrInvalidFilter, name, id)
+		}
+		return first + "-" + last, nil
+	}
+	return normalizeHexID(name, id, id, true)
+}
+
+// normalizeHexID formats one ID, or a pattern if wildcards are allowed, as four upper-case
+// characters. id is the whole value, for error messages.
+func normalizeHexID(name, id, digits string, wildcards bool) (string, error) {
+	digits = strings.TrimSpace(digits)
+	if digits == "" {
+		return "", nil
+	}
+	if len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		digits = digits[2:]
+	}
+	if len(digits) > 4 {
+		digits = strings.TrimLeft(digits, "0")
+	}
+	if digits == "" || len(digits) > 4 {
+		return "", fmt.Errorf("%w: %s %q is not a 16-bit hex number", ErrInvalidFilter, name, id)
+	}
+	for _, c := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) && !(wildcards && c == '?') {
+			return "", fmt.Errorf("%w: %s %q is not a 16-bit hex number", ErrInvalidFilter, name, id)
+		}
+	}
+	return strings.ToUpper(strings.Repeat("0", 4-len(digits)) + digits), nil
+}
+
+// matchIDs compares the VID and PID case-insensitively, letting backends skip work for
+// devices that cannot match before reading the rest of their attributes
+func (f Filter) matchIDs(vid, pid string) bool {
+	return matchUSBID(f.Vid, vid) && matchUSBID(f.Pid, pid)
+}
+
+// matchUSBID compares an ID with a filter value: an ID, a pattern with ? for any digit or
+// a range. Patterns and ranges are compared with the four-digit form Normalize gives them.
+func matchUSBID(want, id string) bool {
+	switch {
+	case want == "":
+		return true
+	case strings.EqualFold(want, id):
+		return true
+	case id == "":
+		return false
+	}
+
+	if lo, hi, ok := strings.Cut(want, "-"); ok {
+		n, err := strconv.ParseUint(id, 16, 16)
+		if err != nil {
+			return false
+		}
+		first, err1 := strconv.ParseUint(lo, 16, 16)
+		last, err2 := strconv.ParseUint(hi, 16, 16)
+		return err1 == nil && err2 == nil && first <= n && n <= last
+	}
+
+	if !strings.Contains(want, "?") || len(want) != len(id) {
+		return false
+	}
+	for i := 0; i < len(want); i++ {
+		if want[i] != '?' && !strings.EqualFold(want[i:i+1], id[i:i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matcher selects devices by rules that do not fit a Filter, such as the chip families of
+// the profiles package
+type Matcher interface {
+	Match(device SerialDeviceInfo) bool
+}
+
+// FindByProfile returns the devices the matcher selects. See Finder.FindByProfile.
+func FindByProfile(ctx context.Context, profile Matcher, opts ...Option) ([]SerialDeviceInfo, error) {
+	return NewFinder(opts...).FindByProfile(ctx, profile)
+}
+
+// FindByProfile returns the devices the matcher selects, e.g. profiles.FTDI. The extended
+// attributes are collected for the matcher, as with WithExtendedInfo.
+func (f *Finder) FindByProfile(ctx context.Context, profile Matcher) ([]SerialDeviceInfo, error) {
+	devices, err := f.listExtended(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched []SerialDeviceInfo
+	for _, device := range devices {
+		if profile.Match(device) {
+			matched = append(matched, device)
+		}
+	}
+	return matched, nil
+}
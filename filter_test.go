@@ -0,0 +1,98 @@
+package serialfinder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFilterNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		vid, pid         string
+		wantVid, wantPid string
+	}{
+		{"", "", "", ""},
+		{"0403", "6001", "0403", "6001"},
+		{"403", "6001", "0403", "6001"},
+		{"0x403", "0X6001", "0403", "6001"},
+		{"1a86", "7523", "1A86", "7523"},
+		{" 0403 ", "", "0403", ""},
+		// Five digits are accepted when the extra ones are leading zeros
+		{"00403", "006001", "0403", "6001"},
+		{"0403:6001", "", "0403", "6001"},
+		{"0403:", "", "0403", ""},
+		{":6001", "", "", "6001"},
+		{"0403", "60??", "0403", "60??"},
+		{"0403", "60?a", "0403", "60?A"},
+		{"", "6001-6015", "", "6001-6015"},
+		{"", "1-ff", "", "0001-00FF"},
+		{"", "6001-6001", "", "6001-6001"},
+	} {
+		f, err := Filter{Vid: tc.vid, Pid: tc.pid}.Normalize()
+		if err != nil {
+			t.Errorf("Normalize vid %q pid %q: %v", tc.vid, tc.pid, err)
+			continue
+		}
+		if f.Vid != tc.wantVid || f.Pid != tc.wantPid {
+			t.Errorf("Normalize vid %q pid %q = %q %q, want %q %q", tc.vid, tc.pid, f.Vid, f.Pid, tc.wantVid, tc.wantPid)
+		}
+	}
+
+	for _, tc := range []struct{ vid, pid string }{
+		// Both IDs in vid and a separate pid
+		{"0403:6001", "6001"},
+		{"0403:6001", "6015"},
+		{"10403", ""},
+		{"", "123456"},
+		{"0x", ""},
+		{"xyz", ""},
+		{"04 03", ""},
+		{"0403:60x1", ""},
+		// Empty, reversed and open ranges
+		{"", "6015-6001"},
+		{"", "6001-"},
+		{"", "-6015"},
+		{"", "-"},
+		// Ranges do not take patterns
+		{"", "60??-6015"},
+		{"", "6001-6015-6020"},
+	} {
+		if _, err := (Filter{Vid: tc.vid, Pid: tc.pid}).Normalize(); !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Normalize vid %q pid %q = %v, want an error wrapping ErrInvalidFilter", tc.vid, tc.pid, err)
+		}
+	}
+}
+
+func TestMatchUSBID(t *testing.T) {
+	for _, tc := range []struct {
+		want, id string
+		match    bool
+	}{
+		{"", "", true},
+		{"", "0403", true},
+		{"0403", "0403", true},
+		{"0403", "", false},
+		{"0403", "0404", false},
+		// IDs are compared case-insensitively, whichever side is upper case
+		{"1A86", "1a86", true},
+		{"1a86", "1A86", true},
+		{"60??", "6001", true},
+		{"60??", "60fF", true},
+		{"60?A", "601a", true},
+		{"60??", "6101", false},
+		{"60??", "600", false},
+		{"????", "ABCD", true},
+		{"6001-6015", "6001", true},
+		{"6001-6015", "6010", true},
+		{"6001-6015", "6015", true},
+		{"6001-6015", "600a", true},
+		{"6001-6015", "6016", false},
+		{"6001-6015", "6000", false},
+		{"6001-6015", "zz", false},
+		{"6001-6001", "6001", true},
+		{"0001-00FF", "00ff", true},
+	} {
+		if got := matchUSBID(tc.want, tc.id); got != tc.match {
+			t.Errorf("matchUSBID(%q, %q) = %v, want %v", tc.want, tc.id, got, tc.match)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// DeviceFinder lists and watches serial devices. Finder implements it for the local
+// machine and grpcfinder.Client for the machine running a gRPC server.
+type DeviceFinder interface {
+	List(ctx context.Context, filter Filter) ([]SerialDeviceInfo, error)
+	Watch(ctx context.Context, filter Filter) (<-chan Event, error)
+}
+
+var _ DeviceFinder = (*Finder)(nil)
+
+// Finder discovers serial devices with a fixed set of options. A Finder is safe for
+// concurrent use, and so are separate Finders: scans keep their state to themselves, and
+// the package-wide settings changed by RegisterBackend, RegisterKnownDevice and
+// UseUSBIDsFile are guarded by locks.
+type Finder struct {
+	opts    options
+	backend backend
+	// err is returned by every scan when the Finder could not be set up
+	err error
+	// subs holds the listeners of Subscribe
+	subs subscriptions
+	// scans shares a scan between concurrent calls to List with the same filter
+	scans scanGroup
+}
+
+// NewFinder returns a Finder configured with the given options
+func NewFinder(opts ...Option) *Finder {
+	f := &Finder{opts: newOptions(opts)}
+	for i, exclude := range f.opts.excludes {
+		normalized, err := exclude.Normalize()
+		if err == nil && normalized == (Filter{}) {
+			err = fmt.Errorf("%w: exclusion matches every device", ErrInvalidFilter)
+		}
+		if err != nil {
+			f.err = err
+			return f
+		}
+		f.opts.excludes[i] = normalized
+	}
+	if f.opts.replay != "" {
+		// The devices of the capture are not attached to this machine
+		f.opts.verifyOpen = false
+		f.opts.bootloaders = false
+		f.backend = replayBackend{path: f.opts.replay}
+		return f
+	}
+	if f.opts.customBackend != nil {
+		f.backend = customBackend{f.opts.customBackend}
+		return f
+	}
+	if f.opts.backend == BackendDefault {
+		f.backend = defaultBackend()
+		return f
+	}
+
+	backendsMu.RLock()
+	newBackend, ok := platformBackends[f.opts.backend]
+	backendsMu.RUnlock()
+	if ok {
+		f.backend = newBackend()
+	} else {
+		f.err = fmt.Errorf("%w: %q", ErrUnknownBackend, f.opts.backend)
+	}
+	return f
+}
+
+// List returns the devices matching the filter. It returns ctx.Err() if the context is
+// canceled before the scan completes, and an error wrapping ErrInvalidFilter if the VID or
+// PID of the filter is malformed. Concurrent calls with the same filter share one scan, so
+// a burst of requests does not start a subprocess or registry walk each.
+func (f *Finder) List(ctx context.Context, filter Filter) ([]SerialDeviceInfo, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	backendName := string(f.opts.backend)
+	if backendName == "" {
+		backendName = "default"
+	}
+	ctx, span := startScanSpan(ctx, f.opts, "serialfinder.List", attribute.String("serialfinder.backend", backendName))
+	devices, err := f.scans.do(ctx, scanKey(filter), func(ctx context.Context) ([]SerialDeviceInfo, error) {
+		return f.list(ctx, filter, f.opts)
+	})
+	span.SetAttributes(attribute.Int("serialfinder.devices", len(devices)))
+	endSpan(span, err)
+	return devices, err
+}
+
+// FindFirst returns the first device matching the filter. See Finder.FindFirst.
+func FindFirst(ctx context.Context, filter Filter, opts ...Option) (SerialDeviceInfo, error) {
+	return NewFinder(opts...).FindFirst(ctx, filter)
+}
+
+// FindFirst returns the first device matching the filter, for the common case of a program
+// looking for its one device. The backends that resolve devices one by one, sysfs on Linux
+// and the registry on Windows, stop at the first match instead of walking every port, so
+// the device may have fewer Siblings than List reports. It returns ErrDeviceNotFound when
+// no device matches.
+func (f *Finder) FindFirst(ctx context.Context, filter Filter) (SerialDeviceInfo, error) {
+	filter, err := filter.Normalize()
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+
+	ctx, span := startScanSpan(ctx, f.opts, "serialfinder.FindFirst")
+	o := f.opts
+	o.stopAt = func(device SerialDeviceInfo) bool {
+		return filter.Match(device) && !f.opts.excluded(device)
+	}
+	devices, err := f.list(ctx, filter, o)
+	if err == nil && len(devices) == 0 {
+		err = ErrDeviceNotFound
+	}
+	endSpan(span, err)
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+	return devices[0], nil
+}
+
+// list runs the scan of List with the options o, which FindFirst narrows
+func (f *Finder) list(ctx context.Context, filter Filter, o options) ([]SerialDeviceInfo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	devices, err := f.listWithRetry(ctx, filter, o)
+	if err != nil {
+		return nil, err
+	}
+	if o.bootloaders && !o.stopped(devices) {
+		boot, err := listBootloaders(ctx, o)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, boot...)
+	}
+	if o.preferByPath {
+		for i := range devices {
+			if devices[i].ByPath != "" {
+				devices[i].Port = devices[i].ByPath
+			}
+		}
+	}
+	assignSiblings(devices)
+	labelKnownDevices(devices)
+	if o.verifyOpen {
+		for i := range devices {
+			if devices[i].Port != "" && filter.Match(devices[i]) && !o.excluded(devices[i]) {
+				devices[i].Openable = portOpenable(devices[i].Port)
+			}
+		}
+	}
+
+	var matched []SerialDeviceInfo
+	for _, device := range devices {
+		if filter.Match(device) && !o.excluded(device) {
+			matched = append(matched, device)
+		}
+	}
+	o.caseIDs(matched)
+	if o.sortByPort {
+		SortByPort(matched)
+	}
+	return matched, nil
+}
+
+// listExtended lists every device with the extended attributes, for the lookups that
+// compare names, whatever WithExtendedInfo says
+func (f *Finder) listExtended(ctx context.Context) ([]SerialDeviceInfo, error) {
+	o := f.opts
+	o.extendedInfo = true
+	return f.list(ctx, Filter{}, o)
+}
+
+// assignSiblings sets Siblings of each device to the ports of the other devices sharing its Location
+func assignSiblings(devices []SerialDeviceInfo) {
+	ports := make(map[string][]string)
+	for _, device := range devices {
+		if device.Location != "" && device.Port != "" {
+			ports[device.Location] = append(ports[device.Location], device.Port)
+		}
+	}
+	for i := range devices {
+		devices[i].Siblings = nil
+		for _, port := range ports[devices[i].Location] {
+			if port != devices[i].Port {
+				devices[i].Siblings = append(devices[i].Siblings, port)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestFinderConcurrentUse shares one Finder between goroutines that list, watch, monitor
+// and subscribe while the devices change. It finds data races when run with -race.
+func TestFinderConcurrentUse(t *testing.T) {
+	backend := &fakeBackend{}
+	devices := func(n int) []SerialDeviceInfo {
+		var devices []SerialDeviceInfo
+		for i := 0; i < n; i++ {
+			devices = append(devices, SerialDeviceInfo{
+				Port:         fmt.Sprintf("/dev/ttyUSB%d", i),
+				Vid:          "0403",
+				Pid:          "6001",
+				SerialNumber: fmt.Sprintf("A%d", i),
+				Attributes:   map[string]string{"bcdDevice": "0600"},
+			})
+		}
+		return devices
+	}
+	backend.set(devices(3)...)
+	f := NewFinder(
+		WithCustomBackend(backend),
+		WithSortByPort(true),
+		WithExclude("2341", ""),
+		WithPollInterval(time.Millisecond),
+		// Poll instead of listening to the devices of the machine running the test
+		func(o *options) { o.changeSource = func(context.Context) <-chan struct{} { return nil } },
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	filter := Filter{Vid: "0403"}
+	var wg sync.WaitGroup
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := fn(); err != nil && ctx.Err() == nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 4; i++ {
+		run(func() error {
+			found, err := f.List(ctx, filter)
+			// Callers own the devices they get
+			for j := range found {
+				found[j].Attributes["bcdDevice"] = "changed"
+			}
+			return err
+		})
+		run(func() error {
+			_, err := f.FindFirst(ctx, filter)
+			if errors.Is(err, ErrDeviceNotFound) {
+				return nil
+			}
+			return err
+		})
+		run(func() error {
+			watchCtx, stop := context.WithTimeout(ctx, 20*time.Millisecond)
+			defer stop()
+			events, err := f.Watch(watchCtx, filter)
+			if err != nil {
+				if watchCtx.Err() != nil {
+					// Stopped before the first scan ended
+					return nil
+				}
+				return err
+			}
+			for range events {
+			}
+			return nil
+		})
+		run(func() error {
+			m, err := f.Monitor(ctx, filter)
+			if err != nil {
+				return err
+			}
+			for j := 0; j < 10; j++ {
+				for _, device := range m.Current() {
+					m.Lookup(device.StableID())
+				}
+				time.Sleep(time.Millisecond)
+			}
+			m.Close()
+			return nil
+		})
+		run(func() error {
+			unsubscribe := f.Subscribe(func(Event) {})
+			time.Sleep(5 * time.Millisecond)
+			unsubscribe()
+			return nil
+		})
+	}
+	// Devices come and go meanwhile
+	run(func() error {
+		for n := 0; n < 5; n++ {
+			backend.set(devices(n)...)
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	})
+	wg.Wait()
+}
+
+// flakyBackend fails its first scans with a transient error
+type flakyBackend struct {
+	fakeBackend
+	failures int
+}
+
+func (b *flakyBackend) List(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+	b.mu.Lock()
+	if b.failures > 0 {
+		b.failures--
+		b.mu.Unlock()
+		return nil, &transientError{errors.New("device busy")}
+	}
+	b.mu.Unlock()
+	return b.fakeBackend.List(ctx, f)
+}
+
+func TestIsPresentScansAsList(t *testing.T) {
+	RegisterKnownDevice("1209", "C0DE", "Bench probe", nil)
+	const byPath = "/dev/serial/by-path/pci-0000:00:14.0-usb-0:2:1.0-port0"
+	backend := &flakyBackend{failures: 1}
+	backend.set(SerialDeviceInfo{
+		Port:         "/dev/serial/by-id/usb-Bench_Probe_P1-if00-port0",
+		ByPath:       byPath,
+		Vid:          "1209",
+		Pid:          "C0DE",
+		SerialNumber: "P1",
+	})
+	f := NewFinder(WithCustomBackend(backend), WithRetry(1, time.Millisecond), WithPreferByPath(true), WithLowercaseIDs(true))
+
+	present, device, err := f.IsPresent(context.Background(), byPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !present || device.Port != byPath || device.Description != "Bench probe" || device.Vid != "1209" || device.Pid != "c0de" {
+		t.Errorf("IsPresent(%s) = %v, %+v", byPath, present, device)
+	}
+}
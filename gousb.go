@@ -0,0 +1,118 @@
+//go:build gousb && cgo
+// +build gousb,cgo
+
+package serialfinder
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/gousb"
+)
+
+func init() {
+	platformBackends[BackendLibUSB] = func() backend {
+		return libusbBackend{base: defaultBackend()}
+	}
+}
+
+// libusbBackend cross-references the ports found by another backend with the descriptors
+// libusb reads from the devices, filling in what sysfs and the registry do not expose.
+//
+// Building it requires libusb-1.0 and `go get github.com/google/gousb`.
+type libusbBackend struct {
+	base backend
+}
+
+// list runs the base backend and enriches every device it finds
+func (b libusbBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	// The hub chain tells apart devices with the same VID and PID
+	o.extendedInfo = true
+	devices, err := b.base.list(ctx, f, o)
+	if err != nil || len(devices) == 0 {
+		return devices, err
+	}
+
+	usb := gousb.NewContext()
+	defer usb.Close()
+
+	// Only devices that a port was found for are opened. Errors are ignored because devices
+	// that cannot be opened (e.g. for lack of permission) are still returned by OpenDevices.
+	handles, _ := usb.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		for _, device := range devices {
+			if matchLibUSBDevice(device, desc) {
+				return true
+			}
+		}
+		return false
+	})
+	defer func() {
+		for _, h := range handles {
+			h.Close()
+		}
+	}()
+
+	for i := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, h := range handles {
+			if matchLibUSBDevice(devices[i], h.Desc) {
+				enrichFromLibUSB(&devices[i], h)
+				break
+			}
+		}
+	}
+	return devices, nil
+}
+
+// matchLibUSBDevice reports whether the descriptor belongs to the device. The hub chain is
+// compared when known; otherwise VID and PID have to be unique to match.
+func matchLibUSBDevice(device SerialDeviceInfo, desc *gousb.DeviceDesc) bool {
+	if !strings.EqualFold(device.Vid, desc.Vendor.String()) || !strings.EqualFold(device.Pid, desc.Product.String()) {
+		return false
+	}
+	if device.Topology == nil {
+		return true
+	}
+	if len(device.Topology.Ports) != len(desc.Path) {
+		return false
+	}
+	for i, port := range device.Topology.Ports {
+		if desc.Path[i] != port {
+			return false
+		}
+	}
+	return true
+}
+
+// enrichFromLibUSB fills the string descriptors and the power budget of the active
+// configuration, keeping what the base backend already reported
+func enrichFromLibUSB(device *SerialDeviceInfo, h *gousb.Device) {
+	if device.Manufacturer == "" {
+		if s, err := h.Manufacturer(); err == nil {
+			device.Manufacturer = s
+		}
+	}
+	if device.Product == "" {
+		if s, err := h.Product(); err == nil {
+			device.Product = s
+		}
+	}
+	if device.SerialNumber == "" {
+		if s, err := h.SerialNumber(); err == nil {
+			device.SerialNumber = s
+		}
+	}
+
+	if n, err := h.ActiveConfigNum(); err == nil {
+		if cfg, ok := h.Desc.Configs[n]; ok {
+			if device.Power == nil {
+				device.Power = &PowerInfo{}
+			}
+			if device.Power.MaxPowerMA == 0 {
+				device.Power.MaxPowerMA = int(cfg.MaxPower)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package serialfinder
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DeviceGroup is one physical device and the serial ports it exposes, such as the four
+// channels of an FT4232H
+type DeviceGroup struct {
+	// Location is the Location shared by the ports; empty for a port whose backend does not
+	// report one, which then forms a group of its own
+	Location string `json:"location,omitempty"`
+	// Ports are the ports of the device, ordered by interface number and then by port
+	Ports []SerialDeviceInfo `json:"ports"`
+}
+
+// GroupByPhysicalDevice clusters the ports that belong to the same physical device, those
+// with the same Location (its place on the bus), so user interfaces can show a multi-port
+// adapter as one node with its ports below it. Groups are in the order of their first port
+// in devices.
+func GroupByPhysicalDevice(devices []SerialDeviceInfo) []DeviceGroup {
+	var groups []DeviceGroup
+	index := make(map[string]int)
+	for _, device := range devices {
+		if device.Location == "" {
+			groups = append(groups, DeviceGroup{Ports: []SerialDeviceInfo{device}})
+			continue
+		}
+		if i, ok := index[device.Location]; ok {
+			groups[i].Ports = append(groups[i].Ports, device)
+			continue
+		}
+		index[device.Location] = len(groups)
+		groups = append(groups, DeviceGroup{Location: device.Location, Ports: []SerialDeviceInfo{device}})
+	}
+
+	for _, group := range groups {
+		sort.SliceStable(group.Ports, func(i, j int) bool {
+			a, b := group.Ports[i], group.Ports[j]
+			if a.Interface != b.Interface {
+				return a.Interface < b.Interface
+			}
+			return ComparePorts(a.Port, b.Port) < 0
+		})
+	}
+	return groups
+}
+
+// String labels the group for display, e.g. "FTDI Quad RS232-HS (4 ports)": the name
+// registered with RegisterKnownDevice, the names the device reports, its description or
+// the usb.ids names of its IDs, in that order
+func (g DeviceGroup) String() string {
+	if len(g.Ports) == 0 {
+		return ""
+	}
+	first := g.Ports[0]
+	var name string
+	if known, ok := LookupKnownDevice(first); ok {
+		name = known.Name
+	}
+	switch {
+	case name != "":
+	case first.Product != "" && first.Manufacturer != "":
+		name = first.Manufacturer + " " + first.Product
+	case first.Product != "":
+		name = first.Product
+	case first.Description != "":
+		name = first.Description
+	default:
+		name = Resolve(first)
+	}
+	if name == "" {
+		name = first.Port
+	}
+	if len(g.Ports) == 1 {
+		return fmt.Sprintf("%s (1 port)", name)
+	}
+	return fmt.Sprintf("%s (%d ports)", name, len(g.Ports))
+}
@@ -0,0 +1,84 @@
+package grpcfinder
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+
+	"github.com/hs0zip/serialfinder"
+	pb "github.com/hs0zip/serialfinder/serialfinderpb"
+)
+
+// Client lists and watches the devices of the machine running a SerialFinder server. It
+// implements serialfinder.DeviceFinder and serialfinder.Backend, and is safe for concurrent use.
+type Client struct {
+	client pb.SerialFinderClient
+}
+
+var (
+	_ serialfinder.DeviceFinder = (*Client)(nil)
+	_ serialfinder.Backend      = (*Client)(nil)
+)
+
+// NewClient returns a client calling the server over conn
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{client: pb.NewSerialFinderClient(conn)}
+}
+
+// List returns the devices of the server matching the filter
+func (c *Client) List(ctx context.Context, filter serialfinder.Filter) ([]serialfinder.SerialDeviceInfo, error) {
+	resp, err := c.client.List(ctx, &pb.ListRequest{Filter: FilterToProto(filter)})
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []serialfinder.SerialDeviceInfo
+	for _, msg := range resp.GetDevices() {
+		devices = append(devices, DeviceFromProto(msg))
+	}
+	return devices, nil
+}
+
+// Watch reports the devices of the server matching the filter as they are attached and
+// detached, like Finder.Watch. It returns an error if the server cannot start the watch.
+// The channel is closed when the context is canceled or the connection to the server is
+// lost; watch again to resume.
+func (c *Client) Watch(ctx context.Context, filter serialfinder.Filter) (<-chan serialfinder.Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := c.client.Watch(ctx, &pb.WatchRequest{Filter: FilterToProto(filter)})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	// The server sends the header once its first scan succeeded; without it, the stream
+	// ends with the error of the server
+	header, err := stream.Header()
+	if err == nil && len(header.Get(watchStartedKey)) == 0 {
+		if _, err = stream.Recv(); err == nil {
+			err = errors.New("grpcfinder: watch started without a header")
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	events := make(chan serialfinder.Event)
+	go func() {
+		defer cancel()
+		defer close(events)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			select {
+			case events <- EventFromProto(msg):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,165 @@
+package grpcfinder
+
+import (
+	"fmt"
+	"regexp"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hs0zip/serialfinder"
+	pb "github.com/hs0zip/serialfinder/serialfinderpb"
+)
+
+// The values of pb.Transport are those of serialfinder.TransportType, so transports are
+// converted by value
+
+// DeviceToProto converts a device to its protobuf message
+func DeviceToProto(device serialfinder.SerialDeviceInfo) *pb.Device {
+	msg := &pb.Device{
+		SerialNumber:  device.SerialNumber,
+		Vid:           device.Vid,
+		Pid:           device.Pid,
+		Port:          device.Port,
+		DialinPort:    device.DialinPort,
+		Transport:     pb.Transport(device.Transport),
+		InUse:         device.InUse,
+		Location:      device.Location,
+		Siblings:      device.Siblings,
+		Interface:     device.Interface,
+		Manufacturer:  device.Manufacturer,
+		Product:       device.Product,
+		Description:   device.Description,
+		Remote:        device.Remote,
+		RemoteHost:    device.RemoteHost,
+		Mode:          string(device.Mode),
+		Openable:      device.Openable,
+		Driver:        device.Driver,
+		ByPath:        device.ByPath,
+		InterfaceName: device.InterfaceName,
+		Attributes:    device.Attributes,
+	}
+	if !device.ConnectedAt.IsZero() {
+		msg.ConnectedAt = timestamppb.New(device.ConnectedAt)
+	}
+	if t := device.Topology; t != nil {
+		msg.Topology = &pb.Topology{Bus: uint32(t.Bus)}
+		for _, port := range t.Ports {
+			msg.Topology.Ports = append(msg.Topology.Ports, uint32(port))
+		}
+	}
+	if p := device.Power; p != nil {
+		msg.Power = &pb.PowerInfo{MaxPowerMa: uint32(p.MaxPowerMA), RuntimeStatus: p.RuntimeStatus, Autosuspend: p.Autosuspend}
+	}
+	return msg
+}
+
+// DeviceFromProto converts a protobuf message back to a device
+func DeviceFromProto(msg *pb.Device) serialfinder.SerialDeviceInfo {
+	device := serialfinder.SerialDeviceInfo{
+		SerialNumber:  msg.GetSerialNumber(),
+		Vid:           msg.GetVid(),
+		Pid:           msg.GetPid(),
+		Port:          msg.GetPort(),
+		DialinPort:    msg.GetDialinPort(),
+		Transport:     serialfinder.TransportType(msg.GetTransport()),
+		InUse:         msg.GetInUse(),
+		Location:      msg.GetLocation(),
+		Siblings:      msg.GetSiblings(),
+		Interface:     msg.GetInterface(),
+		Manufacturer:  msg.GetManufacturer(),
+		Product:       msg.GetProduct(),
+		Description:   msg.GetDescription(),
+		Remote:        msg.GetRemote(),
+		RemoteHost:    msg.GetRemoteHost(),
+		Mode:          serialfinder.Mode(msg.GetMode()),
+		Openable:      msg.GetOpenable(),
+		Driver:        msg.GetDriver(),
+		ByPath:        msg.GetByPath(),
+		InterfaceName: msg.GetInterfaceName(),
+		Attributes:    msg.GetAttributes(),
+	}
+	if msg.GetConnectedAt() != nil {
+		device.ConnectedAt = msg.GetConnectedAt().AsTime()
+	}
+	if t := msg.GetTopology(); t != nil {
+		device.Topology = &serialfinder.Topology{Bus: int(t.GetBus())}
+		for _, port := range t.GetPorts() {
+			device.Topology.Ports = append(device.Topology.Ports, int(port))
+		}
+	}
+	if p := msg.GetPower(); p != nil {
+		device.Power = &serialfinder.PowerInfo{MaxPowerMA: int(p.GetMaxPowerMa()), RuntimeStatus: p.GetRuntimeStatus(), Autosuspend: p.GetAutosuspend()}
+	}
+	return device
+}
+
+// FilterToProto converts a filter to its protobuf message. Regular expressions are sent as
+// their source text.
+func FilterToProto(filter serialfinder.Filter) *pb.Filter {
+	msg := &pb.Filter{
+		Vid:          filter.Vid,
+		Pid:          filter.Pid,
+		Transport:    pb.Transport(filter.Transport),
+		SerialNumber: filter.SerialNumber,
+	}
+	if filter.SerialRegexp != nil {
+		msg.SerialRegexp = filter.SerialRegexp.String()
+	}
+	if filter.PortRegexp != nil {
+		msg.PortRegexp = filter.PortRegexp.String()
+	}
+	return msg
+}
+
+// FilterFromProto converts a protobuf message back to a filter. It fails if a regular
+// expression does not compile.
+func FilterFromProto(msg *pb.Filter) (serialfinder.Filter, error) {
+	filter := serialfinder.Filter{
+		Vid:          msg.GetVid(),
+		Pid:          msg.GetPid(),
+		Transport:    serialfinder.TransportType(msg.GetTransport()),
+		SerialNumber: msg.GetSerialNumber(),
+	}
+	var err error
+	if expr := msg.GetSerialRegexp(); expr != "" {
+		if filter.SerialRegexp, err = regexp.Compile(expr); err != nil {
+			return serialfinder.Filter{}, fmt.Errorf("serial regexp: %w", err)
+		}
+	}
+	if expr := msg.GetPortRegexp(); expr != "" {
+		if filter.PortRegexp, err = regexp.Compile(expr); err != nil {
+			return serialfinder.Filter{}, fmt.Errorf("port regexp: %w", err)
+		}
+	}
+	return filter, nil
+}
+
+// eventTypes maps the event types of both packages
+var eventTypes = map[serialfinder.EventType]pb.Event_Type{
+	serialfinder.EventAdded:   pb.Event_TYPE_ADDED,
+	serialfinder.EventRemoved: pb.Event_TYPE_REMOVED,
+	serialfinder.EventResync:  pb.Event_TYPE_RESYNC,
+}
+
+// EventToProto converts an event to its protobuf message. Resync events carry no device.
+func EventToProto(event serialfinder.Event) *pb.Event {
+	msg := &pb.Event{Type: eventTypes[event.Type]}
+	if event.Type != serialfinder.EventResync {
+		msg.Device = DeviceToProto(event.Device)
+	}
+	return msg
+}
+
+// EventFromProto converts a protobuf message back to an event
+func EventFromProto(msg *pb.Event) serialfinder.Event {
+	event := serialfinder.Event{Device: DeviceFromProto(msg.GetDevice())}
+	for t, pbType := range eventTypes {
+		if pbType == msg.GetType() {
+			event.Type = t
+		}
+	}
+	if event.Type == serialfinder.EventResync {
+		event.Device = serialfinder.SerialDeviceInfo{}
+	}
+	return event
+}
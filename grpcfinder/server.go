@@ -0,0 +1,96 @@
+// Package grpcfinder serves the devices of a machine over gRPC and lists and watches them
+// from another, so a central controller can enumerate the devices of many agents.
+//
+// On the agent, register a server and serve it:
+//
+//	server := grpc.NewServer()
+//	serialfinderpb.RegisterSerialFinderServer(server, grpcfinder.NewServer(serialfinder.NewFinder()))
+//	server.Serve(listener)
+//
+// On the controller, a Client is used like a Finder:
+//
+//	conn, err := grpc.NewClient("agent:9090", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	devices, err := grpcfinder.NewClient(conn).List(ctx, serialfinder.Filter{})
+//
+// The command line tool serves the same service with serialfinder serve -grpc.
+package grpcfinder
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hs0zip/serialfinder"
+	pb "github.com/hs0zip/serialfinder/serialfinderpb"
+)
+
+// watchStartedKey is the header the server sends once a watch started. Failed watches end
+// with a status and no header.
+const watchStartedKey = "serialfinder-watch-started"
+
+// server implements the SerialFinder service on top of a DeviceFinder
+type server struct {
+	pb.UnimplementedSerialFinderServer
+	finder serialfinder.DeviceFinder
+}
+
+// NewServer returns the SerialFinder service answering with the devices of finder
+func NewServer(finder serialfinder.DeviceFinder) pb.SerialFinderServer {
+	return &server{finder: finder}
+}
+
+// List answers with the devices matching the filter of the request
+func (s *server) List(ctx context.Context, req *pb.ListRequest) (*pb.ListResponse, error) {
+	filter, err := FilterFromProto(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	devices, err := s.finder.List(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	resp := &pb.ListResponse{}
+	for _, device := range devices {
+		resp.Devices = append(resp.Devices, DeviceToProto(device))
+	}
+	return resp, nil
+}
+
+// Watch streams the events of a watch until the client goes away. The header is sent once
+// the first scan succeeded, so the client can tell a failed start from a quiet watch.
+func (s *server) Watch(req *pb.WatchRequest, stream pb.SerialFinder_WatchServer) error {
+	filter, err := FilterFromProto(req.GetFilter())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	events, err := s.finder.Watch(stream.Context(), filter)
+	if err != nil {
+		return toStatus(err)
+	}
+	if err := stream.SendHeader(metadata.Pairs(watchStartedKey, "1")); err != nil {
+		return err
+	}
+
+	for event := range events {
+		if err := stream.Send(EventToProto(event)); err != nil {
+			return err
+		}
+	}
+	return toStatus(stream.Context().Err())
+}
+
+// toStatus keeps the code of context errors so clients see them as such, and reports
+// invalid filters as invalid arguments
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, serialfinder.ErrInvalidFilter) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return status.FromContextError(err).Err()
+}
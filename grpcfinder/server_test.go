@@ -0,0 +1,39 @@
+package grpcfinder
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hs0zip/serialfinder"
+	pb "github.com/hs0zip/serialfinder/serialfinderpb"
+)
+
+func TestToStatus(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{nil, codes.OK},
+		{fmt.Errorf("%w: bad pattern", serialfinder.ErrInvalidFilter), codes.InvalidArgument},
+		{context.Canceled, codes.Canceled},
+		{fmt.Errorf("scanning: %w", context.DeadlineExceeded), codes.DeadlineExceeded},
+		{serialfinder.ErrDeviceNotFound, codes.Unknown},
+	} {
+		if code := status.Code(toStatus(tc.err)); code != tc.code {
+			t.Errorf("toStatus(%v) has code %v, want %v", tc.err, code, tc.code)
+		}
+	}
+}
+
+func TestListInvalidExclusion(t *testing.T) {
+	// An exclusion matching every device makes every scan of the finder fail
+	s := NewServer(serialfinder.NewFinder(serialfinder.WithExcludeFilter(serialfinder.Filter{})))
+	_, err := s.List(context.Background(), &pb.ListRequest{})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("List failed with %v, want code InvalidArgument", err)
+	}
+}
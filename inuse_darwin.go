@@ -0,0 +1,56 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// portsInUse returns which of the ports are held open by a process, as reported by lsof
+func portsInUse(ctx context.Context, ports []string, timeout time.Duration) map[string]bool {
+	inUse := make(map[string]bool)
+
+	// -F n prints one "n<path>" line per open file; lsof exits with 1 when nothing is open,
+	// so the exit status is ignored and only the output is used
+	args := append([]string{"-F", "n", "--"}, ports...)
+	cmd := exec.CommandContext(ctx, "lsof", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	_ = runCommand(ctx, cmd, timeout)
+
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "n") {
+			inUse[line[1:]] = true
+		}
+	}
+	return inUse
+}
+
+// markInUse sets InUse on each device whose callout or dial-in node is held open
+func markInUse(ctx context.Context, devices []SerialDeviceInfo, o options) {
+	if len(devices) == 0 {
+		return
+	}
+
+	var ports []string
+	for _, device := range devices {
+		ports = append(ports, device.Port)
+		if device.DialinPort != "" && device.DialinPort != device.Port {
+			ports = append(ports, device.DialinPort)
+		}
+	}
+
+	// A process may hold either of the two nodes of a port
+	inUse := portsInUse(ctx, ports, o.toolTimeout())
+	for i := range devices {
+		devices[i].InUse = inUse[devices[i].Port] || (devices[i].DialinPort != "" && inUse[devices[i].DialinPort])
+	}
+}
@@ -0,0 +1,58 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// portsInUse returns which of the device nodes are held open by a process or locked
+// through a lock file. Processes that cannot be inspected are skipped.
+func portsInUse(ctx context.Context, nodes []string) map[string]bool {
+	inUse := make(map[string]bool)
+	wanted := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		wanted[node] = true
+	}
+	if len(wanted) == 0 {
+		return inUse
+	}
+
+	// Lock files hold the PID of the owner, which must still be running
+	for node := range wanted {
+		if uucpLocked(lockDirs, node) {
+			inUse[node] = true
+		}
+	}
+
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return inUse
+	}
+	for _, proc := range procs {
+		if ctx.Err() != nil {
+			return inUse
+		}
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+
+		fdDir := filepath.Join("/proc", proc.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err == nil && wanted[target] {
+				inUse[target] = true
+			}
+		}
+	}
+
+	return inUse
+}
@@ -0,0 +1,310 @@
+package serialfinder
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ParseIoregOutput extracts the USB serial devices from a saved ioreg dump, such as one
+// attached to a bug report. It accepts the text printed by `ioreg -r -t -c IOSerialBSDClient -l`
+// and the XML printed by `ioreg -a -r -c IOUSBHostDevice -l`. It works on every platform;
+// Port is the callout (/dev/cu.*) node.
+func ParseIoregOutput(data []byte) ([]SerialDeviceInfo, error) {
+	return parseIoregOutput(data, Filter{}, options{})
+}
+
+// parseIoregOutput extracts the devices matching the IDs of the filter from ioreg output in
+// either format. Only the text format lists the ports without USB device, with
+// WithIncludeNonUSB.
+func parseIoregOutput(data []byte, f Filter, o options) ([]SerialDeviceInfo, error) {
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<plist")) {
+		return parseIoregXML(data, f, o.preferDialin)
+	}
+	devices, _, _, err := parseIoregText(data, f, o)
+	return devices, err
+}
+
+// ioregNode is an object of the I/O Registry printed by ioreg, with the properties the text
+// parser needs
+type ioregNode struct {
+	depth int
+	// class is the class of the object, e.g. AppleUSBFTDI for the driver of a serial client
+	class string
+	// usb marks IOUSBHostDevice and IOUSBDevice objects, which carry the IDs of the device
+	usb          bool
+	vid, pid     string
+	serial       string
+	serialString string // kUSBSerialNumberString, used when "USB Serial Number" is missing
+	iface        string
+	location     string
+	topology     *Topology
+	callout      string
+	dialin       string
+	manufacturer string
+	product      string
+	// attributes are the other scalar properties of a USB device, for Attributes
+	attributes map[string]string
+}
+
+// ioregMappedKeys are the properties of a USB device read into fields, left out of
+// Attributes
+var ioregMappedKeys = map[string]bool{
+	"idVendor": true, "idProduct": true, "locationID": true, "USB Serial Number": true,
+	"kUSBSerialNumberString": true, "USB Vendor Name": true, "USB Product Name": true,
+}
+
+// parseIoregText extracts the devices matching the IDs of the filter from the text printed by
+// `ioreg -r -t -c IOSerialBSDClient -l`, along with the Bluetooth, built-in and virtual
+// ports with WithIncludeNonUSB. It also counts the serial clients and those printed
+// below their path from the root, so callers can tell output printed without -t, which
+// shows each client alone and never the USB device above it.
+//
+// Each object starts with a "+-o" line whose column gives its depth in the tree. With -t,
+// every client comes after the objects from the root down to it, printed again for each
+// client, so the parser keeps the chain of ancestors of the current object and attributes
+// each serial client to the nearest USB device above it, such as one of the adapters behind
+// a hub rather than the hub.
+//
+// Large IORegistry trees produce megabytes of text, so the output is converted to a string
+// once and walked line by line with substrings, which allocates nothing per line.
+func parseIoregText(data []byte, f Filter, o options) (devices []SerialDeviceInfo, clients, parsed int, err error) {
+	text := string(data)
+	var stack []*ioregNode
+
+	// pop removes the objects at depth or deeper, adding the serial clients among them
+	pop := func(depth int) {
+		for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+			node := stack[len(stack)-1]
+			if node.callout != "" || node.dialin != "" {
+				clients++
+				if len(stack) > 1 {
+					parsed++
+				}
+				device, ok := ioregClientDevice(stack, o.preferDialin)
+				if ok && (device.Transport == TransportUSB || o.includeNonUSB) && f.matchIDs(device.Vid, device.Pid) {
+					devices = append(devices, device)
+				}
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	for len(text) > 0 {
+		var line string
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			line, text = text[:i], text[i+1:]
+		} else {
+			line, text = text, ""
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		if i := strings.Index(line, "+-o "); i >= 0 {
+			depth := i / 2
+			pop(depth)
+			node := &ioregNode{depth: depth}
+			if j := strings.Index(line, "<class "); j >= 0 {
+				class := line[j+len("<class "):]
+				if k := strings.IndexAny(class, ", >"); k >= 0 {
+					class = class[:k]
+				}
+				node.class = class
+				// IOUSBHostDevice, or IOUSBDevice before OS X 10.11; not IOUSBHostInterface
+				node.usb = class == "IOUSBHostDevice" || class == "IOUSBDevice"
+			}
+			stack = append(stack, node)
+			continue
+		}
+		if len(stack) == 0 {
+			continue
+		}
+
+		key, value, ok := splitIoregProperty(strings.TrimSpace(line))
+		if !ok {
+			continue
+		}
+		node := stack[len(stack)-1]
+		switch key {
+		case "idVendor":
+			if hexVal, err := parseHexValue(value); err == nil {
+				node.vid = fmt.Sprintf("%04X", hexVal)
+			}
+		case "idProduct":
+			if hexVal, err := parseHexValue(value); err == nil {
+				node.pid = fmt.Sprintf("%04X", hexVal)
+			}
+		case "bInterfaceNumber":
+			if number, err := parseHexValue(value); err == nil {
+				node.iface = fmt.Sprintf("%02X", number)
+			}
+		case "locationID":
+			if locationID, err := parseHexValue(value); err == nil {
+				node.location = fmt.Sprintf("0x%08x", locationID)
+				node.topology = parseLocationIDTopology(locationID)
+			}
+		case "USB Serial Number":
+			node.serial = parseStringValue(value)
+		case "kUSBSerialNumberString":
+			node.serialString = parseStringValue(value)
+		case "IOCalloutDevice":
+			node.callout = parseStringValue(value)
+		case "IODialinDevice":
+			node.dialin = parseStringValue(value)
+		case "USB Vendor Name":
+			node.manufacturer = parseStringValue(value)
+		case "USB Product Name":
+			node.product = parseStringValue(value)
+		default:
+			// Dictionaries, arrays and data are not scalars
+			if node.usb && !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "(") && !strings.HasPrefix(value, "<") {
+				if node.attributes == nil {
+					node.attributes = make(map[string]string)
+				}
+				node.attributes[key] = parseStringValue(value)
+			}
+		}
+	}
+	pop(0)
+
+	return devices, clients, parsed, nil
+}
+
+// ioregClientDevice describes the serial client at the top of the stack from its nodes and
+// its nearest USB device ancestor. Clients of the Bluetooth stack or without USB device
+// above them are described from the classes of their ancestors, see ioregNonUSBTransport.
+// It returns false for clients printed without their path and for USB devices without VID
+// and PID.
+func ioregClientDevice(stack []*ioregNode, preferDialin bool) (device SerialDeviceInfo, ok bool) {
+	if len(stack) < 2 {
+		return SerialDeviceInfo{}, false
+	}
+	client := stack[len(stack)-1]
+	device = SerialDeviceInfo{
+		Port:       client.callout,
+		DialinPort: client.dialin,
+		Transport:  TransportUSB,
+	}
+	if device.Port == "" || (preferDialin && device.DialinPort != "") {
+		device.Port = device.DialinPort
+	}
+	// The client is published by the driver of the port, its parent
+	device.Driver = stack[len(stack)-2].class
+
+	// Bluetooth controllers may sit on USB, but their ports belong to the remote devices
+	if !strings.HasPrefix(device.Driver, "IOBluetooth") {
+		for i := len(stack) - 1; i >= 0; i-- {
+			node := stack[i]
+			// The interface is the closest one, below the USB device
+			if device.Interface == "" && node.iface != "" {
+				device.Interface = node.iface
+			}
+			if !node.usb {
+				continue
+			}
+			if node.vid == "" || node.pid == "" {
+				return SerialDeviceInfo{}, false
+			}
+			device.Vid, device.Pid = node.vid, node.pid
+			device.SerialNumber = node.serial
+			if device.SerialNumber == "" {
+				device.SerialNumber = node.serialString
+			}
+			device.Location, device.Topology = node.location, node.topology
+			device.Manufacturer, device.Product = node.manufacturer, node.product
+			device.Attributes = maps.Clone(node.attributes)
+			return device, true
+		}
+	}
+
+	device.Interface = ""
+	device.Transport = ioregNonUSBTransport(stack)
+	name := path.Base(device.Port)
+	device.Description = strings.TrimPrefix(strings.TrimPrefix(name, "cu."), "tty.")
+	return device, true
+}
+
+// ioregPlatformClasses are the classes of the nubs through which built-in hardware is
+// attached: the devices of Apple silicon, the platform and ACPI devices of Intel Macs, and
+// PCI devices
+var ioregPlatformClasses = map[string]bool{
+	"AppleARMIODevice": true, "IOPlatformDevice": true, "IOACPIPlatformDevice": true, "IOPCIDevice": true,
+}
+
+// ioregNonUSBTransport tells the transport of a serial client without USB device from its
+// provider, the driver of the port: the Bluetooth stack publishes its ports from
+// IOBluetoothSerialClient objects, built-in UARTs are below a nub of the platform, and
+// the other ports are virtual
+func ioregNonUSBTransport(stack []*ioregNode) TransportType {
+	if strings.HasPrefix(stack[len(stack)-2].class, "IOBluetooth") {
+		return TransportBluetooth
+	}
+	for _, node := range stack[:len(stack)-1] {
+		if ioregPlatformClasses[node.class] {
+			return TransportPlatform
+		}
+	}
+	return TransportVirtual
+}
+
+// splitIoregProperty splits a property line such as `| |   "idVendor" = 1027` into its key
+// and value: the first quoted name followed by an equals sign, and the rest of the line
+func splitIoregProperty(line string) (key, value string, ok bool) {
+	for start := strings.IndexByte(line, '"'); start >= 0; {
+		rest := line[start+1:]
+		end := strings.IndexByte(rest, '"')
+		if end < 0 {
+			return "", "", false
+		}
+		if end > 0 {
+			after := strings.TrimLeft(rest[end+1:], " \t")
+			if strings.HasPrefix(after, "=") {
+				return rest[:end], strings.TrimSpace(after[1:]), true
+			}
+		}
+		// Like a search for the pattern, retry from the next quote
+		start += 1 + end
+	}
+	return "", "", false
+}
+
+// parseHexValue converts ioreg number values (like 0x1234 or 1234) to int64
+func parseHexValue(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	// Remove trailing comma if present (sometimes happens in ioreg output)
+	value = strings.TrimSuffix(value, ",")
+
+	// Check if it's already a decimal number
+	decVal, errDec := strconv.ParseInt(value, 10, 64)
+	if errDec == nil {
+		return decVal, nil
+	}
+
+	// Try parsing as hex (ioreg usually uses 0x prefix, but let's be flexible)
+	if strings.HasPrefix(value, "0x") {
+		return strconv.ParseInt(value[2:], 16, 64)
+	}
+	// Fallback attempt if no prefix but maybe hex? Unlikely needed for VID/PID.
+	hexVal, errHex := strconv.ParseInt(value, 16, 64)
+	if errHex == nil {
+		return hexVal, nil
+	}
+
+	// Return the original decimal error if hex also failed
+	return 0, errDec
+}
+
+// parseStringValue extracts string values like "My String" -> My String
+func parseStringValue(value string) string {
+	value = strings.TrimSpace(value)
+	// Remove trailing comma if present
+	value = strings.TrimSuffix(value, ",")
+	// Remove surrounding quotes
+	if strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		return value[1 : len(value)-1]
+	}
+	return value // Return as-is if not quoted
+}
@@ -0,0 +1,202 @@
+package serialfinder
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// testdata/ioreg.txt is the output of `ioreg -r -t -c IOSerialBSDClient -l` on a machine with
+// two FTDI adapters behind a hub, an Arduino Uno, the console UART and the incoming
+// Bluetooth port
+
+// ioregProperty is a property line split into its key and value
+type ioregProperty struct {
+	key, value string
+}
+
+// regexpIoregProperties splits the property lines of ioreg text as parseIoregText did before
+// it cut lines and properties by hand, with a bufio.Scanner and a regular expression
+func regexpIoregProperties(t testing.TB, data []byte) []ioregProperty {
+	reKeyValue := regexp.MustCompile(`"([^"]+)"\s*=\s*(.*)`)
+	var properties []ioregProperty
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if match := reKeyValue.FindStringSubmatch(strings.TrimSpace(scanner.Text())); len(match) == 3 {
+			properties = append(properties, ioregProperty{match[1], strings.TrimSpace(match[2])})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return properties
+}
+
+// ioregProperties splits the property lines of ioreg text as parseIoregText does
+func ioregProperties(data []byte) []ioregProperty {
+	var properties []ioregProperty
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if key, value, ok := splitIoregProperty(strings.TrimSpace(line)); ok {
+			properties = append(properties, ioregProperty{key, value})
+		}
+	}
+	return properties
+}
+
+func readIoregFixture(t testing.TB) []byte {
+	data, err := os.ReadFile("testdata/ioreg.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseIoregTextMatchesRegexp(t *testing.T) {
+	data := readIoregFixture(t)
+	// Line endings of captures saved on Windows
+	crlf := bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+
+	for _, input := range [][]byte{data, crlf} {
+		want := regexpIoregProperties(t, input)
+		got := ioregProperties(input)
+		if len(got) != len(want) {
+			t.Fatalf("split %d properties, the regular expression %d", len(got), len(want))
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("property %d is %+v, the regular expression gives %+v", i, got[i], want[i])
+			}
+		}
+	}
+
+	for _, line := range []string{
+		`"" = 1`,
+		`"" "idVendor" = 1027`,
+		`"IOCFPlugInTypes" = {"9dc7b780-9ec0-11d4-a54f-000a27052861"="IOUSBLib.bundle"}`,
+		`"unterminated = 1`,
+		`"USB Product Name"="FT232R"`,
+		`"key"	=	"tab"`,
+		`no property`,
+	} {
+		want := regexpIoregProperties(t, []byte(line))
+		got := ioregProperties([]byte(line))
+		if len(got) != len(want) || (len(want) == 1 && got[0] != want[0]) {
+			t.Errorf("%q splits into %+v, the regular expression into %+v", line, got, want)
+		}
+	}
+}
+
+func TestParseIoregTextFixture(t *testing.T) {
+	devices, clients, parsed, err := parseIoregText(readIoregFixture(t), Filter{}, options{includeNonUSB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clients != 5 || parsed != 5 {
+		t.Errorf("found %d clients and %d below their path, want 5 and 5", clients, parsed)
+	}
+
+	type usbPort struct {
+		port, dialin, vid, pid, serial, location, iface, driver string
+	}
+	want := []usbPort{
+		// Each adapter behind the hub gets its own IDs, not those of the hub
+		{"/dev/cu.usbserial-A10K4QJ7", "/dev/tty.usbserial-A10K4QJ7", "0403", "6001", "A10K4QJ7", "0x01110000", "00", "AppleUSBFTDI"},
+		{"/dev/cu.usbserial-A10K4QJ8", "/dev/tty.usbserial-A10K4QJ8", "0403", "6001", "A10K4QJ8", "0x01120000", "00", "AppleUSBFTDI"},
+		{"/dev/cu.usbmodem21101", "/dev/tty.usbmodem21101", "2341", "0043", "85736323838351F0E1E0", "0x02100000", "01", "AppleUSBACMData"},
+	}
+	var got []usbPort
+	for _, d := range devices {
+		if d.Transport == TransportUSB {
+			got = append(got, usbPort{d.Port, d.DialinPort, d.Vid, d.Pid, d.SerialNumber, d.Location, d.Interface, d.Driver})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("found USB ports %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("USB port %d is %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// The ports without USB device take their transport from their provider
+	type nonUSBPort struct {
+		port, description, driver string
+		transport                 TransportType
+	}
+	wantNonUSB := []nonUSBPort{
+		{"/dev/cu.debug-console", "debug-console", "AppleSamsungSerial", TransportPlatform},
+		{"/dev/cu.Bluetooth-Incoming-Port", "Bluetooth-Incoming-Port", "IOBluetoothSerialClient", TransportBluetooth},
+	}
+	var gotNonUSB []nonUSBPort
+	for _, d := range devices {
+		if d.Transport != TransportUSB {
+			if d.Vid != "" || d.Pid != "" || d.Interface != "" {
+				t.Errorf("port %s without USB device has IDs %s:%s interface %q", d.Port, d.Vid, d.Pid, d.Interface)
+			}
+			gotNonUSB = append(gotNonUSB, nonUSBPort{d.Port, d.Description, d.Driver, d.Transport})
+		}
+	}
+	if len(gotNonUSB) != len(wantNonUSB) {
+		t.Fatalf("found non-USB ports %+v, want %+v", gotNonUSB, wantNonUSB)
+	}
+	for i := range wantNonUSB {
+		if gotNonUSB[i] != wantNonUSB[i] {
+			t.Errorf("non-USB port %d is %+v, want %+v", i, gotNonUSB[i], wantNonUSB[i])
+		}
+	}
+
+	// Only with WithIncludeNonUSB, and never for a filter on the IDs
+	for _, tc := range []struct {
+		f Filter
+		o options
+	}{
+		{Filter{}, options{}},
+		{Filter{Vid: "0403"}, options{includeNonUSB: true}},
+	} {
+		devices, _, _, err := parseIoregText(readIoregFixture(t), tc.f, tc.o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range devices {
+			if d.Transport != TransportUSB {
+				t.Errorf("filter %+v with options %+v found %s", tc.f, tc.o, d.Port)
+			}
+		}
+	}
+}
+
+func TestParseIoregTextWithoutPath(t *testing.T) {
+	// Without -t each client is printed alone
+	output := `+-o IOSerialBSDClient  <class IOSerialBSDClient, id 0x100000563, registered, matched, active, busy 0 (0 ms), retain 6>
+    {
+      "IOCalloutDevice" = "/dev/cu.usbserial-A10K4QJ7"
+      "IODialinDevice" = "/dev/tty.usbserial-A10K4QJ7"
+    }
+    
+`
+	devices, clients, parsed, err := parseIoregText([]byte(output), Filter{}, options{includeNonUSB: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clients != 1 || parsed != 0 || len(devices) != 0 {
+		t.Errorf("found %d clients, %d below their path and devices %+v, want 1, 0 and none", clients, parsed, devices)
+	}
+}
+
+// BenchmarkParseIoregText parses the fixture repeated as on a machine with a hundred times
+// as many ports, whose paths from the root ioreg prints again for each client
+func BenchmarkParseIoregText(b *testing.B) {
+	data := bytes.Repeat(readIoregFixture(b), 100)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, err := parseIoregText(data, Filter{}, options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package serialfinder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// decodePlist decodes an XML property list, as printed by `ioreg -a`, into dictionaries
+// (map[string]any), arrays ([]any), strings, integers (int64), booleans and data ([]byte)
+func decodePlist(data []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	// Property lists declare a DOCTYPE that the decoder does not need to resolve
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("error decoding plist: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			if start.Name.Local == "plist" {
+				continue
+			}
+			return decodePlistValue(decoder, start)
+		}
+	}
+}
+
+// decodePlistValue decodes the value started by start, consuming its end element
+func decodePlistValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				if t.Name.Local == "key" {
+					if err := decoder.DecodeElement(&key, &t); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				dict[key] = value
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var array []any
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch t := token.(type) {
+			case xml.StartElement:
+				value, err := decodePlistValue(decoder, t)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			case xml.EndElement:
+				return array, nil
+			}
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, err
+		}
+		return start.Name.Local == "true", nil
+	}
+
+	// Scalars carry their value as character data
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "integer":
+		return strconv.ParseInt(strings.TrimSpace(text), 0, 64)
+	case "data":
+		return []byte(strings.TrimSpace(text)), nil
+	default: // string, real, date
+		return text, nil
+	}
+}
+
+// parseIoregXML extracts the serial devices from the plist printed by
+// `ioreg -a -r -c IOUSBHostDevice -l`: an array of USB devices whose descendants, listed under
+// IORegistryEntryChildren, include the interfaces and their IOSerialBSDClient entries
+func parseIoregXML(data []byte, f Filter, preferDialin bool) ([]SerialDeviceInfo, error) {
+	root, err := decodePlist(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []SerialDeviceInfo
+	// parent carries the attributes of the closest USB device and interface above entry
+	var walk func(entry map[string]any, parent SerialDeviceInfo)
+	walk = func(entry map[string]any, parent SerialDeviceInfo) {
+		vid, hasVid := entry["idVendor"].(int64)
+		pid, hasPid := entry["idProduct"].(int64)
+		if _, isInterface := entry["bInterfaceNumber"]; !isInterface && hasVid && hasPid {
+			// A USB device replaces whatever was inherited
+			parent = SerialDeviceInfo{
+				Vid:       fmt.Sprintf("%04X", vid),
+				Pid:       fmt.Sprintf("%04X", pid),
+				Transport: TransportUSB,
+			}
+			if serial, ok := entry["USB Serial Number"].(string); ok {
+				parent.SerialNumber = serial
+			} else if serial, ok := entry["kUSBSerialNumberString"].(string); ok {
+				parent.SerialNumber = serial
+			}
+			if location, ok := entry["locationID"].(int64); ok {
+				parent.Location = fmt.Sprintf("0x%08x", location)
+				parent.Topology = parseLocationIDTopology(location)
+			}
+			if vendor, ok := entry["USB Vendor Name"].(string); ok {
+				parent.Manufacturer = vendor
+			}
+			if product, ok := entry["USB Product Name"].(string); ok {
+				parent.Product = product
+			}
+			for key, value := range entry {
+				if ioregMappedKeys[key] {
+					continue
+				}
+				switch v := value.(type) {
+				case string:
+					parent.setAttribute(key, v)
+				case int64:
+					parent.setAttribute(key, strconv.FormatInt(v, 10))
+				case bool:
+					// As the text output of ioreg prints them
+					if v {
+						parent.setAttribute(key, "Yes")
+					} else {
+						parent.setAttribute(key, "No")
+					}
+				}
+			}
+		}
+		if number, ok := entry["bInterfaceNumber"].(int64); ok {
+			parent.Interface = fmt.Sprintf("%02X", number)
+		}
+
+		callout, _ := entry["IOCalloutDevice"].(string)
+		dialin, _ := entry["IODialinDevice"].(string)
+		if (callout != "" || dialin != "") && parent.Vid != "" {
+			device := parent
+			device.Attributes = maps.Clone(parent.Attributes)
+			device.Port = callout
+			device.DialinPort = dialin
+			if device.Port == "" || (preferDialin && dialin != "") {
+				device.Port = dialin
+			}
+			if f.matchIDs(device.Vid, device.Pid) {
+				devices = append(devices, device)
+			}
+		}
+
+		// The serial clients below are published by the driver of this entry
+		parent.Driver, _ = entry["IOObjectClass"].(string)
+		children, _ := entry["IORegistryEntryChildren"].([]any)
+		for _, child := range children {
+			if childEntry, ok := child.(map[string]any); ok {
+				walk(childEntry, parent)
+			}
+		}
+	}
+
+	switch r := root.(type) {
+	case []any:
+		for _, item := range r {
+			if entry, ok := item.(map[string]any); ok {
+				walk(entry, SerialDeviceInfo{})
+			}
+		}
+	case map[string]any:
+		walk(r, SerialDeviceInfo{})
+	default:
+		return nil, fmt.Errorf("unexpected ioreg plist root %T", root)
+	}
+
+	return devices, nil
+}
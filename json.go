@@ -0,0 +1,70 @@
+package serialfinder
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// MarshalJSON encodes the device with a stable schema shared by every platform:
+//
+//	{
+//	  "serial": "A50285BI",           // omitted when the device has no serial number
+//	  "vid": "0403",                  // lower-case hex
+//	  "pid": "6001",                  // lower-case hex
+//	  "port": "/dev/ttyUSB0",
+//	  "dialin_port": "/dev/tty.x",    // macOS only, omitted when empty
+//	  "transport": "usb",             // see TransportType.String
+//	  "connected_at": "2024-05-01T10:00:00Z", // RFC 3339, omitted when unknown
+//	  "in_use": true,                 // omitted when false
+//	  "by_path": "/dev/serial/by-path/pci-0000:00:14.0-usb-0:1.4:1.0-port0", // Linux only, omitted when empty
+//	  "location": "1-1.4",            // omitted when empty
+//	  "siblings": ["/dev/ttyUSB1"],   // omitted when empty
+//	  "interface": "00",              // omitted when unknown
+//	  "interface_name": "Debug UART", // omitted when unknown
+//	  "topology": {"bus": 1, "ports": [1, 4]}, // omitted when unknown
+//	  "power": {"max_power_ma": 100, "runtime_status": "active", "autosuspend": true}, // omitted when unknown
+//	  "manufacturer": "FTDI",         // omitted when unknown
+//	  "product": "FT232R USB UART",   // omitted when unknown
+//	  "description": "pl011 uart0",   // omitted when empty
+//	  "driver": "ftdi_sio",           // omitted when unknown
+//	  "attributes": {"speed": "12"},  // raw backend properties, omitted when empty
+//	  "remote": true,                 // omitted when false
+//	  "remote_host": "10.0.0.5",      // omitted when unknown
+//	  "mode": "bootloader",           // omitted when unknown
+//	  "openable": true                // omitted when false or not checked
+//	}
+//
+// New fields may be added, but existing ones keep their name and meaning.
+func (d SerialDeviceInfo) MarshalJSON() ([]byte, error) {
+	// plain has the same fields and tags but none of the methods, avoiding recursion
+	type plain SerialDeviceInfo
+	out := struct {
+		plain
+		Vid         string     `json:"vid"`
+		Pid         string     `json:"pid"`
+		ConnectedAt *time.Time `json:"connected_at,omitempty"`
+	}{
+		plain: plain(d),
+		Vid:   strings.ToLower(d.Vid),
+		Pid:   strings.ToLower(d.Pid),
+	}
+	if !d.ConnectedAt.IsZero() {
+		out.ConnectedAt = &d.ConnectedAt
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes the schema written by MarshalJSON. VID and PID are accepted in
+// either case and stored in upper case like the backends report them.
+func (d *SerialDeviceInfo) UnmarshalJSON(data []byte) error {
+	type plain SerialDeviceInfo
+	var in plain
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	in.Vid = strings.ToUpper(in.Vid)
+	in.Pid = strings.ToUpper(in.Pid)
+	*d = SerialDeviceInfo(in)
+	return nil
+}
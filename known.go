@@ -0,0 +1,94 @@
+package serialfinder
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// KnownDevice is a device model registered with RegisterKnownDevice
+type KnownDevice struct {
+	Vid  string `json:"vid"`
+	Pid  string `json:"pid,omitempty"`
+	Name string `json:"name"`
+	// Attributes are free-form labels, e.g. {"team": "hil", "fixture": "bench-3"}
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// knownDevices holds the registered models by "VID:PID", with an empty PID for models
+// registered for a whole vendor
+var knownDevices struct {
+	mu   sync.RWMutex
+	byID map[string]KnownDevice
+}
+
+// RegisterKnownDevice labels a device model, such as in-house hardware that usb.ids does
+// not know. Devices with the VID and PID get the name as their Description, and Resolve
+// and the command line tool report it. An empty PID registers every product of the
+// vendor; a registration for the exact PID takes precedence. Registering the same IDs
+// again replaces the earlier entry.
+func RegisterKnownDevice(vid, pid, name string, attrs map[string]string) {
+	vid, pid = strings.ToUpper(vid), strings.ToUpper(pid)
+	copied := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		copied[k] = v
+	}
+
+	knownDevices.mu.Lock()
+	defer knownDevices.mu.Unlock()
+	if knownDevices.byID == nil {
+		knownDevices.byID = make(map[string]KnownDevice)
+	}
+	knownDevices.byID[vid+":"+pid] = KnownDevice{Vid: vid, Pid: pid, Name: name, Attributes: copied}
+}
+
+// LoadKnownDevices registers the devices listed in a JSON file, an array of objects with
+// the fields vid, pid, name and attributes:
+//
+//	[{"vid": "1209", "pid": "0001", "name": "Bench power meter", "attributes": {"team": "hil"}}]
+func LoadKnownDevices(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var entries []KnownDevice
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, entry := range entries {
+		if entry.Vid == "" || entry.Name == "" {
+			return fmt.Errorf("%s: entries need a vid and a name", path)
+		}
+	}
+	for _, entry := range entries {
+		RegisterKnownDevice(entry.Vid, entry.Pid, entry.Name, entry.Attributes)
+	}
+	return nil
+}
+
+// LookupKnownDevice returns the registration matching the VID and PID of the device
+func LookupKnownDevice(device SerialDeviceInfo) (KnownDevice, bool) {
+	if device.Vid == "" {
+		return KnownDevice{}, false
+	}
+	vid, pid := strings.ToUpper(device.Vid), strings.ToUpper(device.Pid)
+
+	knownDevices.mu.RLock()
+	defer knownDevices.mu.RUnlock()
+	if known, ok := knownDevices.byID[vid+":"+pid]; ok {
+		return known, true
+	}
+	known, ok := knownDevices.byID[vid+":"]
+	return known, ok
+}
+
+// labelKnownDevices sets the Description of the registered devices
+func labelKnownDevices(devices []SerialDeviceInfo) {
+	for i := range devices {
+		if known, ok := LookupKnownDevice(devices[i]); ok {
+			devices[i].Description = known.Name
+		}
+	}
+}
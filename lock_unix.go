@@ -0,0 +1,38 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package serialfinder
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockDirs are the directories where UUCP-style LCK..<name> lock files are created
+var lockDirs = []string{"/run/lock", "/var/lock", "/var/spool/uucp"}
+
+// uucpLocked reports whether a lock file in dirs holds the device node for a process that is
+// still running
+func uucpLocked(dirs []string, node string) bool {
+	for _, dir := range dirs {
+		data, err := os.ReadFile(filepath.Join(dir, "LCK.."+filepath.Base(node)))
+		if err != nil {
+			continue
+		}
+		// HDB lock files hold the PID in ASCII, padded to ten characters
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			continue
+		}
+		// Signal 0 only checks that the process exists; EPERM means another user runs it
+		if err := unix.Kill(pid, 0); err == nil || errors.Is(err, unix.EPERM) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package serialfinder
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUUCPLocked(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name, data string
+		locked     bool
+	}{
+		// HDB format, the PID padded to ten characters
+		{"ttyUSB0", fmt.Sprintf("%10d\n", os.Getpid()), true},
+		{"ttyUSB1", fmt.Sprintf("%d", os.Getpid()), true},
+		// The PID of a process that exited; PIDs above 2^22 are not given out
+		{"ttyUSB2", fmt.Sprintf("%10d\n", 1<<30), false},
+		{"ttyUSB3", "\x01\x02\x03\x04", false},
+		{"ttyUSB4", "         0\n", false},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, "LCK.."+tc.name), []byte(tc.data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if locked := uucpLocked([]string{dir}, "/dev/"+tc.name); locked != tc.locked {
+			t.Errorf("%s locked with %q: uucpLocked = %v, want %v", tc.name, tc.data, locked, tc.locked)
+		}
+	}
+	if uucpLocked([]string{dir}, "/dev/ttyUSB9") {
+		t.Error("a port without lock file is locked")
+	}
+}
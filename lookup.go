@@ -0,0 +1,89 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPortNotFound is returned by LookupPort when no device has the port
+var ErrPortNotFound = errors.New("serialfinder: port not found")
+
+// LookupPort returns the device behind a port name. See Finder.LookupPort.
+func LookupPort(ctx context.Context, port string, opts ...Option) (SerialDeviceInfo, error) {
+	return NewFinder(opts...).LookupPort(ctx, port)
+}
+
+// LookupPort returns the device whose port or dial-in port is the given name, such as
+// "/dev/ttyUSB0", "COM7" or "/dev/cu.usbmodem1101". Symbolic links such as the names in
+// /dev/serial/by-id are resolved first, and COM port names are compared regardless of case
+// and of a \\.\ prefix. Built-in and virtual ports are only found with WithIncludeNonUSB.
+// It returns an error wrapping ErrPortNotFound when no device has the port.
+//
+// On Linux the sysfs backends read the attributes of that one USB device instead of
+// scanning every port. The ioreg and registry backends have no index by port and list the
+// devices as List does.
+func (f *Finder) LookupPort(ctx context.Context, port string) (SerialDeviceInfo, error) {
+	if f.err != nil {
+		return SerialDeviceInfo{}, f.err
+	}
+	if lookuper, ok := f.backend.(portLookuper); ok {
+		device, found, err := lookuper.lookupPort(ctx, port, f.opts)
+		if err != nil {
+			return SerialDeviceInfo{}, err
+		}
+		if found {
+			if f.opts.excluded(device) {
+				return SerialDeviceInfo{}, fmt.Errorf("%w: %s", ErrPortNotFound, port)
+			}
+			devices := []SerialDeviceInfo{device}
+			labelKnownDevices(devices)
+			if f.opts.verifyOpen {
+				devices[0].Openable = portOpenable(devices[0].Port)
+			}
+			f.opts.caseIDs(devices)
+			return devices[0], nil
+		}
+	}
+
+	devices, err := f.List(ctx, Filter{})
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+
+	name := canonicalPort(port)
+	for _, device := range devices {
+		if samePort(device.Port, name) || (device.DialinPort != "" && samePort(device.DialinPort, name)) {
+			return device, nil
+		}
+	}
+	return SerialDeviceInfo{}, fmt.Errorf("%w: %s", ErrPortNotFound, port)
+}
+
+// canonicalPort resolves a device path that is a symbolic link to the node it points to
+func canonicalPort(port string) string {
+	if !filepath.IsAbs(port) {
+		return port
+	}
+	if resolved, err := filepath.EvalSymlinks(port); err == nil {
+		return resolved
+	}
+	return port
+}
+
+// samePort compares port names, ignoring the case and the device namespace prefix of
+// Windows COM ports
+func samePort(a, b string) bool {
+	if a == b {
+		return true
+	}
+	a, b = strings.TrimPrefix(a, `\\.\`), strings.TrimPrefix(b, `\\.\`)
+	return isCOMPort(a) && strings.EqualFold(a, b)
+}
+
+// isCOMPort reports whether the name looks like a Windows COM port
+func isCOMPort(name string) bool {
+	return len(name) > 3 && strings.EqualFold(name[:3], "com")
+}
@@ -0,0 +1,72 @@
+package serialfinder
+
+import (
+	"context"
+	"sync"
+)
+
+// Monitor keeps a snapshot of the devices matching a filter up to date in the background,
+// so long-running programs can read the devices present without waiting for a scan
+type Monitor struct {
+	mu      sync.RWMutex
+	devices DeviceSet
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewMonitor starts a Monitor of the devices matching the filter. See Finder.Monitor.
+func NewMonitor(ctx context.Context, filter Filter, opts ...Option) (*Monitor, error) {
+	return NewFinder(opts...).Monitor(ctx, filter)
+}
+
+// Monitor scans for the devices matching the filter and keeps the result current through
+// Watch, with the same notification sources and polling fallback, until the context is
+// canceled or Close is called. It returns an error if the first scan fails.
+func (f *Finder) Monitor(ctx context.Context, filter Filter) (*Monitor, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	m := &Monitor{cancel: cancel, done: make(chan struct{})}
+	// The snapshot is replaced by every rescan rather than patched by the events, which
+	// say nothing of devices whose port, driver or other attributes changed
+	_, events, err := f.watch(ctx, filter, false, func(devices DeviceSet) {
+		m.mu.Lock()
+		m.devices = devices
+		m.mu.Unlock()
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		defer close(m.done)
+		for range events {
+		}
+	}()
+	return m, nil
+}
+
+// Current returns the devices present, ordered by StableID. It does not scan; the result
+// reflects the last change the monitor was told about.
+func (m *Monitor) Current() []SerialDeviceInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return cloneDevices(m.devices.Devices())
+}
+
+// Lookup returns the device with the given ID if it is present
+func (m *Monitor) Lookup(id DeviceID) (SerialDeviceInfo, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	device, ok := m.devices.Get(id)
+	if !ok {
+		return SerialDeviceInfo{}, false
+	}
+	return cloneDevices([]SerialDeviceInfo{device})[0], true
+}
+
+// Close stops the monitor and waits for it to finish. Current keeps returning the last
+// snapshot afterwards.
+func (m *Monitor) Close() {
+	m.cancel()
+	<-m.done
+}
@@ -0,0 +1,83 @@
+package serialfinder
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend lists the devices last set
+type fakeBackend struct {
+	mu      sync.Mutex
+	devices []SerialDeviceInfo
+}
+
+func (b *fakeBackend) set(devices ...SerialDeviceInfo) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.devices = devices
+}
+
+func (b *fakeBackend) List(ctx context.Context, f Filter) ([]SerialDeviceInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]SerialDeviceInfo(nil), b.devices...), nil
+}
+
+// fakeChanges is a notification source that announces a change on each call of notify
+type fakeChanges chan struct{}
+
+func (c fakeChanges) source(ctx context.Context) <-chan struct{} {
+	return c
+}
+
+func (c fakeChanges) notify() {
+	c <- struct{}{}
+}
+
+// waitCurrent waits until the snapshot of the monitor satisfies ok
+func waitCurrent(t *testing.T, m *Monitor, ok func([]SerialDeviceInfo) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !ok(m.Current()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("monitor snapshot is %+v", m.Current())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMonitorRefreshesAttributes(t *testing.T) {
+	backend := &fakeBackend{}
+	device := SerialDeviceInfo{Port: "/dev/ttyUSB0", Vid: "0403", Pid: "6001", SerialNumber: "A1", Driver: "ftdi_sio"}
+	backend.set(device)
+	changes := make(fakeChanges)
+	f := NewFinder(WithCustomBackend(backend), func(o *options) { o.changeSource = changes.source })
+
+	m, err := f.Monitor(context.Background(), Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if current := m.Current(); len(current) != 1 || current[0].Port != "/dev/ttyUSB0" {
+		t.Fatalf("initial snapshot is %+v", current)
+	}
+
+	// The device is enumerated again under another port, keeping its StableID
+	moved := device
+	moved.Port = "/dev/ttyUSB1"
+	backend.set(moved)
+	changes.notify()
+	waitCurrent(t, m, func(current []SerialDeviceInfo) bool {
+		return len(current) == 1 && current[0].Port == "/dev/ttyUSB1"
+	})
+
+	// Devices are still added and removed
+	other := SerialDeviceInfo{Port: "/dev/ttyACM0", Vid: "2341", Pid: "0043", SerialNumber: "B2"}
+	backend.set(other)
+	changes.notify()
+	waitCurrent(t, m, func(current []SerialDeviceInfo) bool {
+		return len(current) == 1 && current[0].Port == "/dev/ttyACM0"
+	})
+}
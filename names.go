@@ -0,0 +1,223 @@
+package serialfinder
+
+import (
+	"bufio"
+	_ "embed"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embeddedUSBIDs is a compact usb.ids database covering common serial devices
+//
+//go:embed usb.ids
+var embeddedUSBIDs string
+
+// idDatabase maps VIDs and PIDs to the names listed in a usb.ids file
+type idDatabase struct {
+	vendors  map[string]string
+	products map[string]string // keyed by "VID:PID"
+}
+
+var (
+	embeddedDBOnce sync.Once
+	embeddedDB     *idDatabase
+)
+
+// defaultIDDatabase returns the embedded database, parsing it on first use
+func defaultIDDatabase() *idDatabase {
+	embeddedDBOnce.Do(func() {
+		// The embedded file is known to be well formed
+		embeddedDB, _ = parseUSBIDs(strings.NewReader(embeddedUSBIDs))
+	})
+	return embeddedDB
+}
+
+// usbIDsReloadInterval is how often an external usb.ids file is checked for changes
+const usbIDsReloadInterval = time.Second
+
+// externalIDs tracks the usb.ids file configured with UseUSBIDsFile
+var externalIDs struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	checked time.Time
+	db      *idDatabase
+}
+
+// UseUSBIDsFile makes name lookups consult the usb.ids file at path in addition to the
+// embedded database, with entries from the file taking precedence. The file is reloaded
+// when it changes, so internal vendor IDs can be added without rebuilding. An empty path
+// restores the embedded database.
+func UseUSBIDsFile(path string) error {
+	externalIDs.mu.Lock()
+	defer externalIDs.mu.Unlock()
+
+	if path == "" {
+		externalIDs.path = ""
+		externalIDs.db = nil
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	db, err := loadUSBIDsFile(path)
+	if err != nil {
+		return err
+	}
+
+	externalIDs.path = path
+	externalIDs.modTime = info.ModTime()
+	externalIDs.size = info.Size()
+	externalIDs.checked = time.Now()
+	externalIDs.db = db
+	return nil
+}
+
+// currentIDDatabase returns the database used for lookups, reloading the external file
+// if it changed since the last check
+func currentIDDatabase() *idDatabase {
+	externalIDs.mu.Lock()
+	defer externalIDs.mu.Unlock()
+
+	if externalIDs.path == "" {
+		return defaultIDDatabase()
+	}
+
+	if time.Since(externalIDs.checked) >= usbIDsReloadInterval {
+		externalIDs.checked = time.Now()
+		info, err := os.Stat(externalIDs.path)
+		if err == nil && (!info.ModTime().Equal(externalIDs.modTime) || info.Size() != externalIDs.size) {
+			// Keep serving the previous contents if the new file cannot be read
+			if db, err := loadUSBIDsFile(externalIDs.path); err == nil {
+				externalIDs.modTime = info.ModTime()
+				externalIDs.size = info.Size()
+				externalIDs.db = db
+			}
+		}
+	}
+	return externalIDs.db
+}
+
+// loadUSBIDsFile parses the file at path and overlays it on the embedded database
+func loadUSBIDsFile(path string) (*idDatabase, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	external, err := parseUSBIDs(f)
+	if err != nil {
+		return nil, err
+	}
+
+	base := defaultIDDatabase()
+	db := &idDatabase{
+		vendors:  make(map[string]string, len(base.vendors)+len(external.vendors)),
+		products: make(map[string]string, len(base.products)+len(external.products)),
+	}
+	for _, src := range []*idDatabase{base, external} {
+		for id, name := range src.vendors {
+			db.vendors[id] = name
+		}
+		for id, name := range src.products {
+			db.products[id] = name
+		}
+	}
+	return db, nil
+}
+
+// parseUSBIDs reads the vendor and product section of a usb.ids file. Other sections
+// (device classes, HID usages, ...) are skipped.
+func parseUSBIDs(r io.Reader) (*idDatabase, error) {
+	db := &idDatabase{
+		vendors:  make(map[string]string),
+		products: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(r)
+	currentVendor := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Interfaces (two tabs) are not needed
+		if strings.HasPrefix(line, "\t\t") {
+			continue
+		}
+
+		// Products are indented with a single tab below their vendor
+		if strings.HasPrefix(line, "\t") {
+			id, name, ok := splitIDLine(line[1:])
+			if ok && currentVendor != "" {
+				db.products[currentVendor+":"+id] = name
+			}
+			continue
+		}
+
+		// Any other unindented line either starts a vendor or a section we do not read
+		id, name, ok := splitIDLine(line)
+		if !ok {
+			currentVendor = ""
+			continue
+		}
+		currentVendor = id
+		db.vendors[id] = name
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// splitIDLine splits "0403  Future Technology Devices" into the upper-cased ID and the name
+func splitIDLine(line string) (id, name string, ok bool) {
+	if len(line) < 6 || line[4:6] != "  " || !isHex(line[:4]) {
+		return "", "", false
+	}
+	return strings.ToUpper(line[:4]), strings.TrimSpace(line[6:]), true
+}
+
+// isHex reports whether s consists only of hexadecimal digits
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// VendorName returns the name registered for the VID in the usb.ids database
+func VendorName(vid string) (string, bool) {
+	name, ok := currentIDDatabase().vendors[strings.ToUpper(vid)]
+	return name, ok
+}
+
+// ProductName returns the name registered for the VID and PID in the usb.ids database
+func ProductName(vid, pid string) (string, bool) {
+	name, ok := currentIDDatabase().products[strings.ToUpper(vid)+":"+strings.ToUpper(pid)]
+	return name, ok
+}
+
+// Resolve returns a human-readable name for the device, such as
+// "QinHeng Electronics CH340 serial converter". The name given to RegisterKnownDevice
+// comes first. Unknown IDs are left out, and an empty string is returned when neither the
+// vendor nor the product is known.
+func Resolve(device SerialDeviceInfo) string {
+	if known, ok := LookupKnownDevice(device); ok {
+		return known.Name
+	}
+	vendor, _ := VendorName(device.Vid)
+	product, _ := ProductName(device.Vid, device.Pid)
+	return strings.TrimSpace(vendor + " " + product)
+}
@@ -0,0 +1,180 @@
+package netserial
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// deviceServer is a serial device server that answered a broadcast search
+type deviceServer struct {
+	vendor   string // description of the product line, e.g. "Moxa NPort"
+	host     string
+	mac      string
+	name     string
+	ports    int // number of serial ports; 0 when the reply does not say
+	basePort int // TCP port of the first serial port in raw TCP server mode
+}
+
+// maxDeviceServerPorts bounds the ports probed on servers that do not report their count
+const maxDeviceServerPorts = 16
+
+// Moxa NPort search: an 8-byte request broadcast to UDP port 4800, answered by every NPort
+// with a reply starting with 0x81
+var (
+	moxaSearchPort    = 4800
+	moxaSearchRequest = []byte{0x01, 0x00, 0x00, 0x08, 0x00, 0x00, 0x00, 0x00}
+)
+
+// Digi ADDP (Advanced Device Discovery Protocol): a "DIGI" packet multicast to
+// 224.0.0.1:2362, answered with a list of type-length-value fields
+var (
+	addpAddr    = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 1), Port: 2362}
+	addpMagic   = []byte("DIGI")
+	addpRequest = append(append([]byte{}, addpMagic...), 0x00, 0x01, 0x00, 0x06, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff)
+)
+
+// ADDP reply fields used by the discovery
+const (
+	addpFieldMAC        = 0x01
+	addpFieldIP         = 0x02
+	addpFieldDeviceName = 0x0d
+	addpFieldPortCount  = 0x12
+)
+
+// searchDeviceServers broadcasts the Moxa and Digi search requests and collects the replies
+// until the timeout
+func searchDeviceServers(ctx context.Context, timeout time.Duration) ([]deviceServer, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	// Either request may fail on its own, e.g. without a broadcast route
+	_, moxaErr := conn.WriteToUDP(moxaSearchRequest, &net.UDPAddr{IP: net.IPv4bcast, Port: moxaSearchPort})
+	_, digiErr := conn.WriteToUDP(addpRequest, addpAddr)
+	if moxaErr != nil && digiErr != nil {
+		return nil, moxaErr
+	}
+
+	var servers []deviceServer
+	seen := make(map[string]bool)
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		server, ok := parseDeviceServerReply(buf[:n], from.IP)
+		if !ok || seen[server.host] {
+			continue
+		}
+		seen[server.host] = true
+		servers = append(servers, server)
+	}
+	return servers, ctx.Err()
+}
+
+// parseDeviceServerReply decodes a Moxa or Digi search reply received from ip
+func parseDeviceServerReply(reply []byte, ip net.IP) (deviceServer, bool) {
+	switch {
+	case len(reply) >= 8 && reply[0] == 0x81:
+		server := deviceServer{vendor: "Moxa NPort", host: ip.String(), basePort: 4001}
+		// The reply echoes the MAC address of the server in bytes 14 to 19
+		if len(reply) >= 20 {
+			server.mac = net.HardwareAddr(reply[14:20]).String()
+		}
+		return server, true
+
+	case bytes.HasPrefix(reply, addpMagic) && len(reply) >= 8:
+		server := deviceServer{vendor: "Digi", host: ip.String(), basePort: 2101}
+		// Fields follow the 8-byte header as type, length, value
+		fields := reply[8:]
+		for len(fields) >= 2 {
+			kind, length := fields[0], int(fields[1])
+			if 2+length > len(fields) {
+				break
+			}
+			value := fields[2 : 2+length]
+			switch kind {
+			case addpFieldMAC:
+				if length == 6 {
+					server.mac = net.HardwareAddr(value).String()
+				}
+			case addpFieldIP:
+				if length == 4 {
+					server.host = net.IP(value).String()
+				}
+			case addpFieldDeviceName:
+				server.name = string(bytes.TrimRight(value, "\x00"))
+			case addpFieldPortCount:
+				if length == 1 {
+					server.ports = int(value[0])
+				} else if length == 4 {
+					server.ports = int(binary.BigEndian.Uint32(value))
+				}
+			}
+			fields = fields[2+length:]
+		}
+		return server, true
+	}
+	return deviceServer{}, false
+}
+
+// serverPorts returns the serial ports of a device server that accept TCP connections in
+// raw TCP server mode, numbered from the base port of the vendor
+func serverPorts(ctx context.Context, server deviceServer, timeout time.Duration) []int {
+	count := server.ports
+	if count <= 0 || count > maxDeviceServerPorts {
+		count = maxDeviceServerPorts
+	}
+
+	open := make([]bool, count)
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dialer := net.Dialer{Timeout: timeout}
+			conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(server.host, strconv.Itoa(server.basePort+i)))
+			if err == nil {
+				conn.Close()
+				open[i] = true
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var ports []int
+	for i, ok := range open {
+		if ok {
+			ports = append(ports, server.basePort+i)
+		}
+	}
+	return ports
+}
+
+// describe labels the serial port number (1-based) of a device server
+func (s deviceServer) describe(number int) string {
+	label := s.vendor
+	if s.name != "" {
+		label += " " + s.name
+	}
+	return fmt.Sprintf("%s port %d", label, number)
+}
@@ -0,0 +1,244 @@
+package netserial
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strings"
+	"time"
+)
+
+// mdnsAddr is the IPv4 multicast group of mDNS
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// DNS record types used by service discovery
+const (
+	dnsTypeA   = 1
+	dnsTypePTR = 12
+	dnsTypeSRV = 33
+	dnsClassIN = 1
+)
+
+// mdnsService is one service instance resolved from mDNS answers
+type mdnsService struct {
+	instance string // e.g. "ttyUSB0" from "ttyUSB0._iostream._tcp.local."
+	host     string
+	port     int
+}
+
+// errMalformed is returned for DNS messages that cannot be parsed
+var errMalformed = errors.New("netserial: malformed DNS message")
+
+// browseMDNS sends one PTR query for each service type and collects the services announced
+// in the answers until the timeout. The query is sent from an ordinary UDP port, so
+// responders answer with unicast and no multicast membership is needed.
+func browseMDNS(ctx context.Context, services []string, timeout time.Duration) ([]mdnsService, error) {
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	// Unblock the read below when the context is canceled before the deadline
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := conn.WriteToUDP(buildPTRQuery(services), mdnsAddr); err != nil {
+		return nil, err
+	}
+
+	// Records arrive spread over several answers and sections, so gather all of them first
+	pointers := make(map[string]bool) // instance names
+	targets := make(map[string]mdnsService)
+	addresses := make(map[string]string)
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			break
+		}
+		records, err := parseDNSRecords(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, r := range records {
+			switch r.rtype {
+			case dnsTypePTR:
+				pointers[r.target] = true
+			case dnsTypeSRV:
+				targets[r.name] = mdnsService{host: r.target, port: r.port}
+			case dnsTypeA:
+				addresses[r.name] = r.target
+			}
+		}
+	}
+
+	var result []mdnsService
+	for instance := range pointers {
+		service, ok := targets[instance]
+		if !ok {
+			continue
+		}
+		if address, ok := addresses[service.host]; ok {
+			service.host = address
+		} else {
+			service.host = strings.TrimSuffix(service.host, ".")
+		}
+		service.instance = instanceLabel(instance)
+		result = append(result, service)
+	}
+	return result, ctx.Err()
+}
+
+// instanceLabel returns the first label of a service instance name, unescaping the dots
+// that instance names may contain
+func instanceLabel(name string) string {
+	var label strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name):
+			i++
+			label.WriteByte(name[i])
+		case name[i] == '.':
+			return label.String()
+		default:
+			label.WriteByte(name[i])
+		}
+	}
+	return label.String()
+}
+
+// buildPTRQuery encodes a DNS query with one PTR question per service type
+func buildPTRQuery(services []string) []byte {
+	msg := make([]byte, 12)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(services))) // QDCOUNT
+	for _, service := range services {
+		for _, label := range strings.Split(strings.TrimSuffix(service, ".")+".local", ".") {
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+		msg = append(msg, 0)
+		msg = binary.BigEndian.AppendUint16(msg, dnsTypePTR)
+		msg = binary.BigEndian.AppendUint16(msg, dnsClassIN)
+	}
+	return msg
+}
+
+// dnsRecord is the part of a resource record the discovery needs. target is the PTR or SRV
+// target name, or the address of an A record.
+type dnsRecord struct {
+	name   string
+	rtype  uint16
+	target string
+	port   int
+}
+
+// parseDNSRecords returns the records of every section of a DNS response
+func parseDNSRecords(msg []byte) ([]dnsRecord, error) {
+	if len(msg) < 12 || msg[2]&0x80 == 0 { // not a response
+		return nil, errMalformed
+	}
+	questions := int(binary.BigEndian.Uint16(msg[4:]))
+	count := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+
+	offset := 12
+	for i := 0; i < questions; i++ {
+		_, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		offset = next + 4
+	}
+
+	var records []dnsRecord
+	for i := 0; i < count; i++ {
+		name, next, err := readDNSName(msg, offset)
+		if err != nil {
+			return nil, err
+		}
+		if next+10 > len(msg) {
+			return nil, errMalformed
+		}
+		r := dnsRecord{name: name, rtype: binary.BigEndian.Uint16(msg[next:])}
+		length := int(binary.BigEndian.Uint16(msg[next+8:]))
+		data := next + 10
+		if data+length > len(msg) {
+			return nil, errMalformed
+		}
+
+		switch r.rtype {
+		case dnsTypePTR:
+			r.target, _, err = readDNSName(msg, data)
+		case dnsTypeSRV:
+			if length < 7 {
+				return nil, errMalformed
+			}
+			r.port = int(binary.BigEndian.Uint16(msg[data+4:]))
+			r.target, _, err = readDNSName(msg, data+6)
+		case dnsTypeA:
+			if length == 4 {
+				r.target = net.IP(msg[data : data+4]).String()
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+		offset = data + length
+	}
+	return records, nil
+}
+
+// readDNSName decodes the possibly compressed name at offset. It returns the name with a
+// trailing dot and the offset after the name.
+func readDNSName(msg []byte, offset int) (string, int, error) {
+	var name strings.Builder
+	next := -1
+	for jumps := 0; ; {
+		if offset >= len(msg) {
+			return "", 0, errMalformed
+		}
+		length := int(msg[offset])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = offset + 1
+			}
+			if name.Len() == 0 {
+				name.WriteByte('.')
+			}
+			return name.String(), next, nil
+		case length&0xc0 == 0xc0:
+			// A pointer to a name earlier in the message
+			if offset+1 >= len(msg) || jumps > 16 {
+				return "", 0, errMalformed
+			}
+			if next < 0 {
+				next = offset + 2
+			}
+			offset = int(binary.BigEndian.Uint16(msg[offset:]) & 0x3fff)
+			jumps++
+		default:
+			if offset+1+length > len(msg) {
+				return "", 0, errMalformed
+			}
+			for _, c := range msg[offset+1 : offset+1+length] {
+				if c == '.' || c == '\\' {
+					name.WriteByte('\\')
+				}
+				name.WriteByte(c)
+			}
+			name.WriteByte('.')
+			offset += 1 + length
+		}
+	}
+}
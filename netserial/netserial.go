@@ -0,0 +1,245 @@
+// Package netserial discovers serial ports shared over the network, such as ser2net
+// instances and RFC 2217 device servers. They are reported like local ports, with
+// Transport set to serialfinder.TransportNetwork and Port set to "tcp://host:port".
+//
+// Servers are found by browsing mDNS for the services ser2net and RFC 2217 servers
+// advertise, by the broadcast searches of Moxa NPort and Digi (ADDP) device servers, and by
+// probing well-known ports of the hosts given with WithHosts for a telnet server. Probed
+// ports are only reported when they speak telnet, since a silent open port cannot be told
+// apart from an unrelated service.
+package netserial
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// DefaultPorts are probed on each host given with WithHosts: the first ports of the ser2net
+// examples and the data port of Moxa and similar device servers
+var DefaultPorts = []int{2000, 2001, 2002, 2003, 4001}
+
+// DefaultServices are browsed over mDNS. ser2net advertises _iostream._tcp.
+var DefaultServices = []string{"_iostream._tcp", "_rfc2217._tcp"}
+
+// options holds the settings of a discovery
+type options struct {
+	hosts    []string
+	ports    []int
+	services []string
+	mdns     bool
+	servers  bool
+	timeout  time.Duration
+}
+
+// Option configures Discover
+type Option func(*options)
+
+// WithHosts probes the well-known ports of the given hosts
+func WithHosts(hosts ...string) Option {
+	return func(o *options) {
+		o.hosts = append(o.hosts, hosts...)
+	}
+}
+
+// WithPorts replaces the ports probed on each host, DefaultPorts by default
+func WithPorts(ports ...int) Option {
+	return func(o *options) {
+		o.ports = ports
+	}
+}
+
+// WithServices replaces the mDNS service types browsed, DefaultServices by default
+func WithServices(services ...string) Option {
+	return func(o *options) {
+		o.services = services
+	}
+}
+
+// WithMDNS enables or disables browsing mDNS, which is enabled by default
+func WithMDNS(enable bool) Option {
+	return func(o *options) {
+		o.mdns = enable
+	}
+}
+
+// WithDeviceServers enables or disables the broadcast search for Moxa NPort and Digi device
+// servers, which is enabled by default. Their serial ports are reported when they accept
+// connections in raw TCP server mode, from port 4001 on Moxa and 2101 on Digi.
+func WithDeviceServers(enable bool) Option {
+	return func(o *options) {
+		o.servers = enable
+	}
+}
+
+// WithTimeout sets how long to wait for mDNS answers and for each probed port to answer,
+// one second by default
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// Discover returns the network serial ports whose servers answer within the timeout, sorted
+// by port. Failing searches are only reported when all of them failed and no hosts were
+// given to probe.
+func Discover(ctx context.Context, opts ...Option) ([]serialfinder.SerialDeviceInfo, error) {
+	o := options{
+		ports:    DefaultPorts,
+		services: DefaultServices,
+		mdns:     true,
+		servers:  true,
+		timeout:  time.Second,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	found := make(map[string]serialfinder.SerialDeviceInfo)
+	var mu sync.Mutex
+	add := func(host string, port int, description, location string) {
+		device := serialfinder.SerialDeviceInfo{
+			Port:        "tcp://" + net.JoinHostPort(host, strconv.Itoa(port)),
+			Transport:   serialfinder.TransportNetwork,
+			Location:    location,
+			Description: description,
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		// mDNS and the device server searches describe a port better than the probe result
+		if existing, ok := found[device.Port]; !ok || existing.Description == "rfc2217" || existing.Description == "telnet" {
+			found[device.Port] = device
+		}
+	}
+
+	// The searches and the probes all wait for answers, so they run side by side
+	var wg sync.WaitGroup
+	var searchErrs []error
+	searches := 0
+	search := func(run func() error) {
+		searches++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := run(); err != nil {
+				mu.Lock()
+				searchErrs = append(searchErrs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	if o.mdns && len(o.services) > 0 {
+		search(func() error {
+			services, err := browseMDNS(ctx, o.services, o.timeout)
+			for _, service := range services {
+				add(service.host, service.port, service.instance, "")
+			}
+			return err
+		})
+	}
+	if o.servers {
+		search(func() error {
+			servers, err := searchDeviceServers(ctx, o.timeout)
+			for _, server := range servers {
+				location := server.mac
+				if location == "" {
+					location = server.host
+				}
+				for _, port := range serverPorts(ctx, server, o.timeout) {
+					add(server.host, port, server.describe(port-server.basePort+1), location)
+				}
+			}
+			return err
+		})
+	}
+
+	// Probe every host and port, a few at a time
+	limit := make(chan struct{}, 16)
+	for _, host := range o.hosts {
+		for _, port := range o.ports {
+			wg.Add(1)
+			go func(host string, port int) {
+				defer wg.Done()
+				limit <- struct{}{}
+				defer func() { <-limit }()
+				if kind, ok := probeTelnet(ctx, host, port, o.timeout); ok {
+					add(host, port, kind, "")
+				}
+			}(host, port)
+		}
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Searches fail on hosts without multicast or broadcast routes, which only matters
+	// when there was nothing else to try
+	if searches > 0 && len(searchErrs) == searches && len(o.hosts) == 0 {
+		return nil, searchErrs[0]
+	}
+
+	devices := make([]serialfinder.SerialDeviceInfo, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, device)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Port < devices[j].Port })
+	return devices, nil
+}
+
+// Telnet protocol bytes used by the probe
+const (
+	telnetIAC           = 255
+	telnetWILL          = 251
+	telnetDO            = 253
+	telnetComPortOption = 44 // RFC 2217
+)
+
+// probeTelnet connects to host:port and asks the server to enable the RFC 2217 com port
+// option. It returns "rfc2217" if the server accepts it and "telnet" if it only answers with
+// other telnet negotiation.
+func probeTelnet(ctx context.Context, host string, port int, timeout time.Duration) (string, bool) {
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return "", false
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return "", false
+	}
+	if _, err := conn.Write([]byte{telnetIAC, telnetDO, telnetComPortOption}); err != nil {
+		return "", false
+	}
+
+	// Servers send their own negotiation first, so look through everything that arrives
+	// before the deadline for the answer
+	var data []byte
+	buf := make([]byte, 256)
+	for {
+		n, err := conn.Read(buf)
+		data = append(data, buf[:n]...)
+		for i := 0; i+2 < len(data); i++ {
+			if data[i] == telnetIAC && data[i+1] == telnetWILL && data[i+2] == telnetComPortOption {
+				return "rfc2217", true
+			}
+		}
+		if err != nil || len(data) > 4096 {
+			break
+		}
+	}
+
+	for i := 0; i+1 < len(data); i++ {
+		if data[i] == telnetIAC && data[i+1] >= telnetWILL {
+			return "telnet", true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,42 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+)
+
+// appendNonUSB adds the Bluetooth, built-in and virtual serial ports to the devices of the
+// backends that only see USB devices, when WithIncludeNonUSB is set. They are the serial
+// clients ioreg prints without USB device above them, as the default backend reports them.
+// They have no VID or PID, so a filter with either never matches them.
+func appendNonUSB(ctx context.Context, f Filter, o options, devices []SerialDeviceInfo) ([]SerialDeviceInfo, error) {
+	if !o.includeNonUSB || !f.matchIDs("", "") {
+		return devices, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "ioreg", "-r", "-t", "-c", "IOSerialBSDClient", "-l")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := runCommand(ctx, cmd, o.toolTimeout()); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		// The USB devices are still reported when ioreg fails
+		return devices, nil
+	}
+
+	found, _, _, err := parseIoregText(out.Bytes(), f, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range found {
+		if device.Transport != TransportUSB {
+			devices = append(devices, device)
+		}
+	}
+	return devices, nil
+}
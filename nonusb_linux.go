@@ -0,0 +1,18 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import "context"
+
+// appendNonUSB adds the non-USB UARTs to the devices of a Linux backend when requested
+func appendNonUSB(ctx context.Context, f Filter, o options, devices []SerialDeviceInfo, nodes []string) ([]SerialDeviceInfo, []string, error) {
+	if !o.includeNonUSB {
+		return devices, nodes, nil
+	}
+	extra, extraNodes, err := listNonUSBTTYs(ctx, hostSysfs{}, f, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(devices, extra...), append(nodes, extraNodes...), nil
+}
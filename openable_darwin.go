@@ -0,0 +1,12 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import "golang.org/x/sys/unix"
+
+// The ioctls reading and setting the termios of a tty
+const (
+	getTermios = unix.TIOCGETA
+	setTermios = unix.TIOCSETA
+)
@@ -0,0 +1,12 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import "golang.org/x/sys/unix"
+
+// The ioctls reading and setting the termios of a tty
+const (
+	getTermios = unix.TCGETS
+	setTermios = unix.TCSETS
+)
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+// portOpenable reports false on platforms without a serial backend
+func portOpenable(port string) bool {
+	return false
+}
@@ -0,0 +1,47 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package serialfinder
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// portOpenable tells whether the port could be opened, disturbing it as little as possible.
+// A port locked through a UUCP lock file of a running process is not openable, and is not
+// opened at all. Otherwise the node is opened without waiting for carrier and without
+// making it the controlling terminal: ports set exclusive with TIOCEXCL fail to open, and
+// ports another process holds an flock on are reported as not openable too. HUPCL is
+// cleared and the modem lines are set back before closing, so closing does not drop DTR;
+// HUPCL stays cleared, which programs opening the port set again if they need it.
+func portOpenable(port string) bool {
+	node, err := filepath.EvalSymlinks(port)
+	if err != nil {
+		return false
+	}
+	if uucpLocked(lockDirs, node) {
+		return false
+	}
+
+	fd, err := unix.Open(node, unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return false
+	}
+	defer unix.Close(fd)
+
+	if lines, err := unix.IoctlGetInt(fd, unix.TIOCMGET); err == nil {
+		if termios, err := unix.IoctlGetTermios(fd, getTermios); err == nil && termios.Cflag&unix.HUPCL != 0 {
+			termios.Cflag &^= unix.HUPCL
+			unix.IoctlSetTermios(fd, setTermios, termios)
+		}
+		defer unix.IoctlSetPointerInt(fd, unix.TIOCMSET, lines)
+	}
+
+	if err := unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		return false
+	}
+	unix.Flock(fd, unix.LOCK_UN)
+	return true
+}
@@ -0,0 +1,11 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+// portOpenable opens the COM port briefly; COM ports are exclusive, so a port held by
+// another process cannot be opened
+func portOpenable(port string) bool {
+	active, inUse := checkCOMPortActiveWindows(port)
+	return active && !inUse
+}
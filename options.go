@@ -0,0 +1,274 @@
+package serialfinder
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures how devices are discovered
+type Option func(*options)
+
+// options holds the settings collected from the Option values passed to a scan
+type options struct {
+	preferDialin         bool
+	alternateControlSets bool
+	checkInUse           bool
+	backend              BackendName
+	includeNonUSB        bool
+	disablePortProbe     bool
+	ioregXML             bool
+	pollInterval         time.Duration
+	inotifyWatch         bool
+	bootloaders          bool
+	aliasFile            string
+	verifyOpen           bool
+	customBackend        Backend
+	replay               string
+	tracerProvider       trace.TracerProvider
+	commandTimeout       time.Duration
+	lowercaseIDs         bool
+	excludes             []Filter
+	drivers              []string
+	sortByPort           bool
+	preferByPath         bool
+	retries              int
+	retryBackoff         time.Duration
+	extendedInfo         bool
+	// changeSource replaces the notification source of the platform in Watch, so tests can
+	// announce changes
+	changeSource func(ctx context.Context) <-chan struct{}
+	// stopAt ends a scan at the first device it accepts, for backends that resolve devices
+	// one by one
+	stopAt func(SerialDeviceInfo) bool
+}
+
+// newOptions applies the given options over the defaults
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// stopped reports whether a scan ended at its last device because stopAt accepted it
+func (o options) stopped(devices []SerialDeviceInfo) bool {
+	return o.stopAt != nil && len(devices) > 0 && o.stopAt(devices[len(devices)-1])
+}
+
+// caseIDs lower-cases the VID and PID of the devices if WithLowercaseIDs is set
+func (o options) caseIDs(devices []SerialDeviceInfo) {
+	if !o.lowercaseIDs {
+		return
+	}
+	for i := range devices {
+		devices[i].Vid = strings.ToLower(devices[i].Vid)
+		devices[i].Pid = strings.ToLower(devices[i].Pid)
+	}
+}
+
+// WithLowercaseIDs reports VID and PID in lower case, such as "1a86", as Linux tools and
+// udev print them, instead of the upper case ("1A86") the backends use. JSON output is
+// always lower case.
+func WithLowercaseIDs(enable bool) Option {
+	return func(o *options) {
+		o.lowercaseIDs = enable
+	}
+}
+
+// WithExclude hides the devices with the VID and PID from every scan, such as the built-in
+// modem or debug UART of a kiosk that must not appear in a port picker. Either ID may be
+// empty, and both accept the patterns and ranges of Filter; "0403", "60??" hides the FTDI
+// 60xx parts. The option may be given several times.
+func WithExclude(vid, pid string) Option {
+	return WithExcludeFilter(Filter{Vid: vid, Pid: pid})
+}
+
+// WithExcludeFilter hides the devices matching the filter from every scan, e.g. those whose
+// port matches PortRegexp or whose serial number matches SerialRegexp. The option may be
+// given several times; a device matching any of the filters is hidden. A filter matching
+// every device makes scans fail with ErrInvalidFilter.
+func WithExcludeFilter(filter Filter) Option {
+	return func(o *options) {
+		o.excludes = append(o.excludes, filter)
+	}
+}
+
+// WithDriver only reports the ports bound to the driver, compared case-insensitively with
+// SerialDeviceInfo.Driver, such as "cdc_acm" for the class-compliant modems and boards
+// whatever their VID and PID. The option may be given several times to accept any of the
+// drivers. Devices whose driver the backend cannot tell are not reported.
+func WithDriver(name string) Option {
+	return func(o *options) {
+		o.drivers = append(o.drivers, name)
+	}
+}
+
+// WithService is WithDriver under the name Windows gives drivers, e.g. "usbser"
+func WithService(name string) Option {
+	return WithDriver(name)
+}
+
+// WithPreferByPath reports the /dev/serial/by-path link of a port as Port on Linux instead
+// of its /dev/serial/by-id link, for setups with several identical devices without a serial
+// number, whose by-id names collide. Ports without a by-path link keep their usual name. It
+// has no effect on other platforms.
+func WithPreferByPath(prefer bool) Option {
+	return func(o *options) {
+		o.preferByPath = prefer
+	}
+}
+
+// WithSortByPort orders the devices by port in natural order, so COM2 comes before COM10
+// and /dev/ttyUSB2 before /dev/ttyUSB10; see ComparePorts. By default devices are in the
+// order the backend finds them.
+func WithSortByPort(enable bool) Option {
+	return func(o *options) {
+		o.sortByPort = enable
+	}
+}
+
+// WithExtendedInfo also collects the attributes that take extra reads per device: Topology,
+// Power, Driver, Attributes and, on Linux, Manufacturer, Product and InterfaceName. Basic scans only
+// read what identifies a device (VID, PID, serial number, port and location), which keeps
+// them fast on hosts with many ports. Backends that get an attribute with the core ones,
+// such as ioreg on macOS, report it either way. WithDriver implies it.
+func WithExtendedInfo(enable bool) Option {
+	return func(o *options) {
+		o.extendedInfo = enable
+	}
+}
+
+// wantExtended reports whether the backends collect the extended attributes, which
+// WithDriver needs to compare drivers
+func (o options) wantExtended() bool {
+	return o.extendedInfo || len(o.drivers) > 0
+}
+
+// excluded reports whether the device is left out of the results: it matches one of the
+// exclusion filters, or drivers were given and it is bound to none of them
+func (o options) excluded(device SerialDeviceInfo) bool {
+	for _, exclude := range o.excludes {
+		if exclude.Match(device) {
+			return true
+		}
+	}
+	if len(o.drivers) == 0 {
+		return false
+	}
+	for _, driver := range o.drivers {
+		if device.Driver != "" && strings.EqualFold(driver, device.Driver) {
+			return false
+		}
+	}
+	return true
+}
+
+// WithPreferDialin reports the dial-in node (/dev/tty.*) as Port on macOS instead of the
+// callout node (/dev/cu.*), for tools that rely on blocking-open semantics. It has no
+// effect on other platforms.
+func WithPreferDialin(prefer bool) Option {
+	return func(o *options) {
+		o.preferDialin = prefer
+	}
+}
+
+// WithAlternateControlSets also scans the numbered control sets (ControlSet001, ...) on
+// Windows, finding devices recorded only in a set other than the current one, e.g. after a
+// system restore. It has no effect on other platforms.
+func WithAlternateControlSets(enable bool) Option {
+	return func(o *options) {
+		o.alternateControlSets = enable
+	}
+}
+
+// WithInUseCheck sets InUse. On Linux and macOS it looks for processes that hold each port
+// open (and UUCP lock files on Linux) without opening the ports, but scans every process.
+// On Windows the port is opened briefly, since exclusive access is the only indicator; this
+// can toggle DTR on devices that are not in use.
+func WithInUseCheck(enable bool) Option {
+	return func(o *options) {
+		o.checkInUse = enable
+	}
+}
+
+// WithIncludeNonUSB also reports built-in, PCI and SoC UARTs (ttyS, ttyAMA, ttymxc, ...) on
+// Linux, the onboard, PCI, Bluetooth and virtual COM ports of the SERIALCOMM device map on
+// Windows, and the Bluetooth, built-in and virtual ports (/dev/cu.Bluetooth-Incoming-Port,
+// /dev/cu.debug-console, ...) on macOS, with empty VID and PID and the transport of their
+// bus. They never match a filter with a VID or PID.
+func WithIncludeNonUSB(include bool) Option {
+	return func(o *options) {
+		o.includeNonUSB = include
+	}
+}
+
+// WithPortProbe controls whether Windows scans may open COM ports. Ports are opened to tell
+// whether they are in use (WithInUseCheck) and to check presence when the configuration
+// manager cannot answer. Disabling the probe makes scans purely passive, so they cannot race
+// with other processes opening the same ports; presence is then taken from the SERIALCOMM
+// device map instead. Probing is enabled by default.
+func WithPortProbe(enable bool) Option {
+	return func(o *options) {
+		o.disablePortProbe = !enable
+	}
+}
+
+// WithIoregXML makes macOS scans read the XML property list output of ioreg (`ioreg -a`)
+// with a structured decoder instead of parsing its text output line by line. It has no
+// effect on other platforms.
+func WithIoregXML(enable bool) Option {
+	return func(o *options) {
+		o.ioregXML = enable
+	}
+}
+
+// WithPollInterval sets how often Watch rescans for devices on platforms that do not announce
+// device changes, one second by default
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+// WithInotifyWatch makes Watch on Linux learn about device changes from inotify on /dev and
+// /dev/serial/by-id instead of the uevent netlink socket. It needs no privileges beyond
+// reading /dev, for containers that block netlink; Watch also falls back to it on its own
+// when the socket cannot be opened.
+func WithInotifyWatch(enable bool) Option {
+	return func(o *options) {
+		o.inotifyWatch = enable
+	}
+}
+
+// WithBootloaders also reports boards in a bootloader that presents no serial port, such as
+// a Raspberry Pi Pico in BOOTSEL mode, which shows up as a mass storage device. They are
+// reported with an empty Port and Mode set to ModeBootloader.
+func WithBootloaders(enable bool) Option {
+	return func(o *options) {
+		o.bootloaders = enable
+	}
+}
+
+// WithAliasFile sets the alias file read by FindByAlias instead of DefaultAliasesPath
+func WithAliasFile(path string) Option {
+	return func(o *options) {
+		o.aliasFile = path
+	}
+}
+
+// WithVerifyOpen sets Openable by briefly opening each port, so pickers can grey out the
+// ports that would fail to open: ports held exclusively or locked by another process, or
+// not accessible to the current user. On Linux and macOS ports locked by a running process
+// through a UUCP lock file are not opened at all, and the others are opened without
+// blocking and closed without hanging up, their modem lines set back as they were. Linux
+// drivers still raise DTR and RTS on open, which resets boards such as Arduinos that were
+// not open. On Windows ports are opened even with WithPortProbe(false).
+func WithVerifyOpen(enable bool) Option {
+	return func(o *options) {
+		o.verifyOpen = enable
+	}
+}
@@ -0,0 +1,32 @@
+package serialfinder
+
+import (
+	"path"
+	"strings"
+)
+
+// CalloutToDialin returns the dial-in node paired with a macOS callout node, such as
+// "/dev/tty.usbserial-A50285BI" for "/dev/cu.usbserial-A50285BI". macOS creates both for
+// every serial port: opening the callout node does not wait for carrier detect, while
+// opening the dial-in node does. It returns false if port is not a callout node. Listed
+// devices carry both in Port and DialinPort, so this is for names that come from elsewhere,
+// such as a configuration file.
+func CalloutToDialin(port string) (string, bool) {
+	return swapNodePrefix(port, "cu.", "tty.")
+}
+
+// DialinToCallout returns the callout node paired with a macOS dial-in node, such as
+// "/dev/cu.usbserial-A50285BI" for "/dev/tty.usbserial-A50285BI". It returns false if port
+// is not a dial-in node. See CalloutToDialin.
+func DialinToCallout(port string) (string, bool) {
+	return swapNodePrefix(port, "tty.", "cu.")
+}
+
+// swapNodePrefix replaces the prefix of the name of a node in /dev
+func swapNodePrefix(port, from, to string) (string, bool) {
+	dir, name := path.Split(port)
+	if dir != "/dev/" || !strings.HasPrefix(name, from) || len(name) == len(from) {
+		return "", false
+	}
+	return dir + to + name[len(from):], true
+}
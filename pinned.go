@@ -0,0 +1,238 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PinnedDevice is a device a machine is expected to have, as listed in a devices file
+type PinnedDevice struct {
+	// ID is the StableID or the serial number of the device
+	ID string `yaml:"id"`
+	// Alias is a meaningful name for the device, such as "left-bench-probe"
+	Alias string `yaml:"alias,omitempty"`
+	// Optional devices are not reported missing when they are not attached
+	Optional bool `yaml:"optional,omitempty"`
+	// The attributes the device is expected to have; empty ones are not checked
+	Vid          string        `yaml:"vid,omitempty"`
+	Pid          string        `yaml:"pid,omitempty"`
+	Port         string        `yaml:"port,omitempty"`
+	Transport    TransportType `yaml:"transport,omitempty"`
+	Location     string        `yaml:"location,omitempty"`
+	Manufacturer string        `yaml:"manufacturer,omitempty"`
+	Product      string        `yaml:"product,omitempty"`
+}
+
+// name returns the alias of the device, or its ID when it has none
+func (p PinnedDevice) name() string {
+	if p.Alias != "" {
+		return p.Alias
+	}
+	return p.ID
+}
+
+// matches reports whether the device is the pinned one, whatever its attributes
+func (p PinnedDevice) matches(device SerialDeviceInfo) bool {
+	return p.ID != "" && (string(device.StableID()) == p.ID || device.SerialNumber == p.ID)
+}
+
+// mismatches compares the expected attributes with those of the device
+func (p PinnedDevice) mismatches(device SerialDeviceInfo) []AttributeMismatch {
+	var mismatches []AttributeMismatch
+	check := func(attribute, expected, actual string, equal func(a, b string) bool) {
+		if expected != "" && !equal(expected, actual) {
+			mismatches = append(mismatches, AttributeMismatch{Attribute: attribute, Expected: expected, Actual: actual})
+		}
+	}
+	exact := func(a, b string) bool { return a == b }
+
+	check("vid", p.Vid, device.Vid, strings.EqualFold)
+	check("pid", p.Pid, device.Pid, strings.EqualFold)
+	check("port", p.Port, device.Port, exact)
+	if p.Transport != TransportUnknown {
+		check("transport", p.Transport.String(), device.Transport.String(), exact)
+	}
+	check("location", p.Location, device.Location, exact)
+	check("manufacturer", p.Manufacturer, device.Manufacturer, exact)
+	check("product", p.Product, device.Product, exact)
+	return mismatches
+}
+
+// DevicesFile lists the devices a machine is expected to have, such as the probes and
+// boards of a test bench:
+//
+//	devices:
+//	  - id: A50285BI
+//	    alias: left-bench-probe
+//	    vid: "0403"
+//	    pid: "6001"
+//	    location: 1-1.4
+//	  - id: 3f1c9a0e5b7d2c4a8e6f1b3d5c7a9e0f
+//	    alias: gps
+//	    optional: true
+//	# report attached devices that are not listed
+//	strict: true
+type DevicesFile struct {
+	Devices []PinnedDevice `yaml:"devices"`
+	// Strict reports the attached devices that are not listed as unexpected
+	Strict bool `yaml:"strict,omitempty"`
+}
+
+// DefaultDevicesPath returns serialfinder/devices.yaml in the user configuration
+// directory, e.g. ~/.config/serialfinder/devices.yaml on Linux
+func DefaultDevicesPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "serialfinder", "devices.yaml"), nil
+}
+
+// LoadDevicesFile reads a devices file in YAML
+func LoadDevicesFile(path string) (*DevicesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file DevicesFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i, pinned := range file.Devices {
+		if pinned.ID == "" {
+			return nil, fmt.Errorf("%s: device %d has no id", path, i+1)
+		}
+	}
+	return &file, nil
+}
+
+// Aliases returns the aliases of the listed devices, for use like an alias file
+func (d *DevicesFile) Aliases() Aliases {
+	aliases := make(Aliases)
+	for _, pinned := range d.Devices {
+		if pinned.Alias != "" {
+			aliases[pinned.Alias] = pinned.ID
+		}
+	}
+	return aliases
+}
+
+// AttributeMismatch is an attribute of an attached device that differs from the devices file
+type AttributeMismatch struct {
+	Attribute string `json:"attribute"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+}
+
+// PinnedMatch is a listed device found attached
+type PinnedMatch struct {
+	Pinned PinnedDevice     `json:"pinned"`
+	Device SerialDeviceInfo `json:"device"`
+	// Mismatches are the expected attributes the device does not have
+	Mismatches []AttributeMismatch `json:"mismatches,omitempty"`
+}
+
+// Validation is the result of comparing the attached devices with a devices file
+type Validation struct {
+	Matched []PinnedMatch `json:"matched"`
+	// Missing are the listed devices, optional ones excepted, that are not attached
+	Missing []PinnedDevice `json:"missing"`
+	// Unexpected are the attached devices that are not listed, when the file is strict
+	Unexpected []SerialDeviceInfo `json:"unexpected"`
+}
+
+// OK reports whether every required device is attached with the expected attributes and,
+// for a strict file, no other device is
+func (v *Validation) OK() bool {
+	for _, match := range v.Matched {
+		if len(match.Mismatches) > 0 {
+			return false
+		}
+	}
+	return len(v.Missing) == 0 && len(v.Unexpected) == 0
+}
+
+// Validate compares the devices with the file. Each listed device is matched with the
+// first device of the same StableID or serial number not matched yet, so a serial number
+// shared by the ports of a composite device can be listed once per port.
+func (d *DevicesFile) Validate(devices []SerialDeviceInfo) *Validation {
+	validation := &Validation{}
+	matched := make([]bool, len(devices))
+	for _, pinned := range d.Devices {
+		found := false
+		for i, device := range devices {
+			if matched[i] || !pinned.matches(device) {
+				continue
+			}
+			matched[i], found = true, true
+			validation.Matched = append(validation.Matched, PinnedMatch{Pinned: pinned, Device: device, Mismatches: pinned.mismatches(device)})
+			break
+		}
+		if !found && !pinned.Optional {
+			validation.Missing = append(validation.Missing, pinned)
+		}
+	}
+
+	if d.Strict {
+		for i, device := range devices {
+			if !matched[i] {
+				validation.Unexpected = append(validation.Unexpected, device)
+			}
+		}
+	}
+	return validation
+}
+
+// ValidateDevices compares the attached devices with the devices file at path, or the one
+// at DefaultDevicesPath if path is empty. See Finder.ValidateDevices.
+func ValidateDevices(ctx context.Context, path string, opts ...Option) (*Validation, error) {
+	return NewFinder(opts...).ValidateDevices(ctx, path)
+}
+
+// ValidateDevices lists the attached devices and compares them with the devices file at
+// path, or the one at DefaultDevicesPath if path is empty, reporting the listed devices
+// that are missing or have other attributes than expected and, for a strict file, the
+// devices that are not listed. The extended attributes are collected to compare the
+// manufacturer and product names, as with WithExtendedInfo.
+func (f *Finder) ValidateDevices(ctx context.Context, path string) (*Validation, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultDevicesPath(); err != nil {
+			return nil, err
+		}
+	}
+	file, err := LoadDevicesFile(path)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := f.listExtended(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return file.Validate(devices), nil
+}
+
+// String describes the validation problems, one per line, or "ok"
+func (v *Validation) String() string {
+	var lines []string
+	for _, pinned := range v.Missing {
+		lines = append(lines, fmt.Sprintf("missing: %s", pinned.name()))
+	}
+	for _, match := range v.Matched {
+		for _, mismatch := range match.Mismatches {
+			lines = append(lines, fmt.Sprintf("%s: %s is %q, expected %q", match.Pinned.name(), mismatch.Attribute, mismatch.Actual, mismatch.Expected))
+		}
+	}
+	for _, device := range v.Unexpected {
+		lines = append(lines, fmt.Sprintf("unexpected: %s (%s)", device.Port, device.StableID()))
+	}
+	if len(lines) == 0 {
+		return "ok"
+	}
+	return strings.Join(lines, "\n")
+}
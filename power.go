@@ -0,0 +1,33 @@
+package serialfinder
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PowerInfo describes the power budget and power management state of a USB device
+type PowerInfo struct {
+	// MaxPowerMA is the current the device requests from the bus (bMaxPower), in milliamps
+	MaxPowerMA int `json:"max_power_ma,omitempty"`
+	// RuntimeStatus is the runtime power state: active, suspended, suspending or resuming
+	RuntimeStatus string `json:"runtime_status,omitempty"`
+	// Autosuspend reports that the OS may suspend the device when idle
+	Autosuspend bool `json:"autosuspend,omitempty"`
+}
+
+// Suspended reports whether the device is suspended or being suspended, a common cause of
+// ports that exist but do not respond
+func (p PowerInfo) Suspended() bool {
+	return p.RuntimeStatus == "suspended" || p.RuntimeStatus == "suspending"
+}
+
+// parseMilliamps parses a current like "500mA" as found in sysfs; it returns 0 if the
+// value cannot be parsed
+func parseMilliamps(value string) int {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "mA")
+	ma, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return ma
+}
@@ -0,0 +1,92 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeviceRef names a device by its serial number, its StableID or a port, such as
+// "A50285BI", "3f1c9a0e5b7d2c4a8e6f1b3d5c7a9e0f", "/dev/ttyUSB0",
+// "/dev/serial/by-id/usb-FTDI_FT232R_USB_UART_A50285BI-if00-port0" or "COM7". Absolute
+// paths and COM port names are taken as ports.
+type DeviceRef string
+
+// isPort reports whether the reference is a port rather than an ID
+func (r DeviceRef) isPort() bool {
+	name := string(r)
+	return filepath.IsAbs(name) || strings.HasPrefix(name, `\\.\`) || isCOMPort(name)
+}
+
+// match reports whether the device is the one the reference names
+func (r DeviceRef) match(device SerialDeviceInfo) bool {
+	if !r.isPort() {
+		return device.SerialNumber == string(r) || string(device.StableID()) == string(r)
+	}
+	name := canonicalPort(string(r))
+	for _, port := range []string{device.Port, device.DialinPort} {
+		if port != "" && (samePort(port, name) || samePort(canonicalPort(port), name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPresent reports whether the device a reference names is attached. See Finder.IsPresent.
+func IsPresent(ctx context.Context, ref DeviceRef, opts ...Option) (bool, SerialDeviceInfo, error) {
+	return NewFinder(opts...).IsPresent(ctx, ref)
+}
+
+// IsPresent reports whether the device a reference names is attached and returns it, for
+// readiness probes of test rigs. It is cheaper than List: a port whose device node does not
+// exist is reported absent without scanning, the scan skips the in-use and open checks, and
+// it stops at the first matching device. Otherwise the device is found as List finds it,
+// with the retries, bootloaders and port names the options ask for, and concurrent probes
+// of the same reference share one scan. Built-in and virtual ports are only found with
+// WithIncludeNonUSB.
+func (f *Finder) IsPresent(ctx context.Context, ref DeviceRef) (bool, SerialDeviceInfo, error) {
+	if f.err != nil {
+		return false, SerialDeviceInfo{}, f.err
+	}
+	if ref == "" {
+		return false, SerialDeviceInfo{}, nil
+	}
+
+	// A device node that is gone settles it, unless the devices come from elsewhere
+	local := f.opts.replay == "" && f.opts.customBackend == nil
+	if local && filepath.IsAbs(string(ref)) {
+		if _, err := os.Stat(string(ref)); errors.Is(err, fs.ErrNotExist) {
+			return false, SerialDeviceInfo{}, nil
+		}
+	}
+
+	o := f.opts
+	o.checkInUse = false
+	o.verifyOpen = false
+	o.stopAt = func(device SerialDeviceInfo) bool {
+		// The port is replaced by its by-path link only once the scan ends
+		if f.opts.preferByPath && device.ByPath != "" {
+			device.Port = device.ByPath
+		}
+		return ref.match(device) && !f.opts.excluded(device)
+	}
+	devices, err := f.scans.do(ctx, fmt.Sprintf("present %q", ref), func(ctx context.Context) ([]SerialDeviceInfo, error) {
+		return f.list(ctx, Filter{}, o)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNoDevicesInWSL) {
+			return false, SerialDeviceInfo{}, nil
+		}
+		return false, SerialDeviceInfo{}, err
+	}
+	for _, device := range devices {
+		if ref.match(device) {
+			return true, device, nil
+		}
+	}
+	return false, SerialDeviceInfo{}, nil
+}
@@ -0,0 +1,83 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// Mode is the firmware a device runs, as reported in SerialDeviceInfo.Mode
+type Mode = serialfinder.Mode
+
+const (
+	ModeUnknown     = serialfinder.ModeUnknown
+	ModeApplication = serialfinder.ModeApplication
+	ModeBootloader  = serialfinder.ModeBootloader
+)
+
+// espressifVid is the vendor ID of the USB peripherals built into Espressif chips
+const espressifVid = "303A"
+
+var (
+	// Espressif covers the USB peripherals built into the ESP32-S2, ESP32-S3, ESP32-C3,
+	// ESP32-C6 and ESP32-H2, in both their ROM and application firmware
+	Espressif = Profile{Name: "Espressif", IDs: []ID{{espressifVid, ""}}}
+
+	// EspressifBridges covers the USB-UART bridges of the Espressif development kits and
+	// of most ESP32 and ESP8266 boards: CP2102, CH340, CH9102 and the FT2232 of ESP-Prog.
+	// The same chips serve many other boards, so a match is only a hint.
+	EspressifBridges = Profile{Name: "Espressif bridges", IDs: []ID{
+		{"10C4", "EA60"}, {"1A86", "7523"}, {"1A86", "55D4"}, {"0403", "6010"},
+	}}
+)
+
+// espressifROMs are the product IDs the USB-OTG peripheral enumerates with in ROM download mode
+var espressifROMs = map[string]string{
+	"0002": "ESP32-S2",
+	"0009": "ESP32-S3",
+}
+
+// espressifUSBSerialJTAG is the product ID of the USB Serial/JTAG controller of the
+// ESP32-S3, ESP32-C3, ESP32-C6 and ESP32-H2. It is the same in every mode.
+const espressifUSBSerialJTAG = "1001"
+
+// EspressifDevice describes a device recognized by DetectEspressif
+type EspressifDevice struct {
+	// Native is set for the USB peripheral of the chip itself, and unset for a USB-UART
+	// bridge on the board
+	Native bool
+	// JTAG is set for the USB Serial/JTAG controller, whose port is the console and which
+	// also serves OpenOCD
+	JTAG bool
+	// Chip names the chip when the device tells it, e.g. "ESP32-S2"
+	Chip string
+	// Mode tells whether the chip is in its ROM download mode. Bridges and the USB
+	// Serial/JTAG controller look the same in both modes, so they are ModeUnknown.
+	Mode Mode
+}
+
+// DetectEspressif recognizes an Espressif chip behind a port. Native USB peripherals are
+// recognized by their VID. Bridges are only recognized as Espressif when the name they
+// report says so, since their IDs are shared with other boards; use EspressifBridges to
+// include them all.
+func DetectEspressif(device serialfinder.SerialDeviceInfo) (EspressifDevice, bool) {
+	if strings.EqualFold(device.Vid, espressifVid) {
+		pid := strings.ToUpper(device.Pid)
+		switch {
+		case espressifROMs[pid] != "":
+			return EspressifDevice{Native: true, Chip: espressifROMs[pid], Mode: ModeBootloader}, true
+		case pid == espressifUSBSerialJTAG:
+			return EspressifDevice{Native: true, JTAG: true}, true
+		}
+		// Any other product ID is assigned by the application firmware, e.g. TinyUSB
+		return EspressifDevice{Native: true, Mode: ModeApplication}, true
+	}
+
+	if EspressifBridges.Match(device) {
+		name := strings.ToUpper(device.Manufacturer + " " + device.Product)
+		if strings.Contains(name, "ESPRESSIF") || strings.Contains(name, "ESP32") || strings.Contains(name, "ESP8266") {
+			return EspressifDevice{}, true
+		}
+	}
+	return EspressifDevice{}, false
+}
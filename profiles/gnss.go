@@ -0,0 +1,37 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// GNSS covers the USB receivers of u-blox (generations 5 to 9) and Garmin
+var GNSS = Profile{Name: "GNSS", IDs: []ID{
+	{"1546", "01A5"}, {"1546", "01A6"}, {"1546", "01A7"}, {"1546", "01A8"}, {"1546", "01A9"},
+	{"091E", "0003"},
+}}
+
+// gnssNameMarkers appear in the names of receivers built on generic bridges, such as the
+// SiRF based GlobalSat BU-353
+var gnssNameMarkers = []string{"GNSS", "GPS", "SIRF", "U-BLOX", "UBLOX"}
+
+// LikelyGNSS hints that the port streams NMEA sentences, so mapping software can probe
+// it first: a receiver of the GNSS profile, the GNSS port of a cellular module, or a
+// device whose name mentions GPS, GNSS, SiRF or u-blox. A receiver behind a plain bridge
+// that does not name itself is not recognized.
+func LikelyGNSS(device serialfinder.SerialDeviceInfo) bool {
+	if GNSS.Match(device) {
+		return true
+	}
+	if modem, ok := DetectModem(device); ok {
+		return modem.Role == ModemRoleGNSS
+	}
+	name := strings.ToUpper(device.Manufacturer + " " + device.Product + " " + device.Description)
+	for _, marker := range gnssNameMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// ModemRole tells what a serial port of a cellular module is for
+type ModemRole string
+
+const (
+	// ModemRoleUnknown is reported for ports whose interface number is unknown or unmapped
+	ModemRoleUnknown ModemRole = ""
+	// ModemRoleAT takes AT commands while the data port carries a connection
+	ModemRoleAT ModemRole = "at"
+	// ModemRoleData is the modem port used for PPP; it also takes AT commands when idle
+	ModemRoleData ModemRole = "data"
+	// ModemRoleGNSS streams NMEA sentences from the GNSS receiver of the module
+	ModemRoleGNSS ModemRole = "gnss"
+	// ModemRoleDiag is the diagnostic port of the chipset (Qualcomm DM)
+	ModemRoleDiag ModemRole = "diag"
+	// ModemRoleAudio carries voice call audio
+	ModemRoleAudio ModemRole = "audio"
+)
+
+// Modem describes a serial port of a cellular module
+type Modem struct {
+	Vendor string
+	Model  string
+	Role   ModemRole
+}
+
+// modemLayout is the interface layout shared by the modules of a vendor family
+type modemLayout map[string]ModemRole
+
+var (
+	// Quectel and SIMCom modules share the Qualcomm layout
+	qualcommLayout = modemLayout{"00": ModemRoleDiag, "01": ModemRoleGNSS, "02": ModemRoleAT, "03": ModemRoleData}
+	simcomLayout   = modemLayout{"00": ModemRoleDiag, "01": ModemRoleGNSS, "02": ModemRoleAT, "03": ModemRoleData, "04": ModemRoleAudio}
+	sierraLayout   = modemLayout{"00": ModemRoleDiag, "02": ModemRoleGNSS, "03": ModemRoleAT}
+)
+
+// modemModels are the known cellular modules by "VID:PID"
+var modemModels = map[string]struct {
+	vendor, model string
+	layout        modemLayout
+}{
+	"2C7C:0125": {"Quectel", "EC25/EG25", qualcommLayout},
+	"2C7C:0121": {"Quectel", "EC21", qualcommLayout},
+	"05C6:9215": {"Quectel", "EC20", qualcommLayout},
+	"2C7C:0296": {"Quectel", "BG96", qualcommLayout},
+	"2C7C:0700": {"Quectel", "BG95", qualcommLayout},
+	"2C7C:0306": {"Quectel", "EG06/EP06", qualcommLayout},
+	"2C7C:0512": {"Quectel", "EG12/EM12", qualcommLayout},
+	"2C7C:0800": {"Quectel", "RM500Q", qualcommLayout},
+	"1E0E:9001": {"SIMCom", "SIM7600", simcomLayout},
+	"1E0E:9011": {"SIMCom", "SIM7600 (RNDIS)", modemLayout{"02": ModemRoleDiag, "03": ModemRoleGNSS, "04": ModemRoleAT, "05": ModemRoleData, "06": ModemRoleAudio}},
+	"1E0E:9205": {"SIMCom", "SIM7000/SIM7070", qualcommLayout},
+	"1E0E:9206": {"SIMCom", "SIM7080", qualcommLayout},
+	"1199:9071": {"Sierra Wireless", "MC7455", sierraLayout},
+	"1199:9091": {"Sierra Wireless", "EM7565", sierraLayout},
+}
+
+// DetectModem recognizes the ports of common Quectel, SIMCom and Sierra Wireless cellular
+// modules and tells which one takes AT commands, carries PPP data or streams GNSS
+// sentences. These modules expose several ttyUSB or COM ports that look alike, and the
+// role of each follows from its USB interface number. The role is ModemRoleUnknown when
+// the platform does not report the interface.
+func DetectModem(device serialfinder.SerialDeviceInfo) (Modem, bool) {
+	model, ok := modemModels[strings.ToUpper(device.Vid)+":"+strings.ToUpper(device.Pid)]
+	if !ok {
+		return Modem{}, false
+	}
+	return Modem{Vendor: model.vendor, Model: model.model, Role: model.layout[device.Interface]}, true
+}
+
+// ModemPorts returns the ports of cellular modules among the devices that have the role,
+// e.g. ModemRoleAT to send commands to every module attached to a gateway
+func ModemPorts(devices []serialfinder.SerialDeviceInfo, role ModemRole) []serialfinder.SerialDeviceInfo {
+	var ports []serialfinder.SerialDeviceInfo
+	for _, device := range devices {
+		if modem, ok := DetectModem(device); ok && modem.Role == role {
+			ports = append(ports, device)
+		}
+	}
+	return ports
+}
@@ -0,0 +1,52 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// raspberryPiVid is the vendor ID of Raspberry Pi
+const raspberryPiVid = "2E8A"
+
+// Pico covers the Raspberry Pi Pico and other RP2040 and RP2350 boards running the Pico SDK
+// or MicroPython, and both chips in BOOTSEL mode. The BOOTSEL devices have no port and are
+// only listed with serialfinder.WithBootloaders.
+var Pico = Profile{Name: "Pico", IDs: []ID{
+	{raspberryPiVid, "0003"}, {raspberryPiVid, "0005"}, {raspberryPiVid, "0009"},
+	{raspberryPiVid, "000A"}, {raspberryPiVid, "000F"},
+}}
+
+// picoChips are the chip of each Pico product ID
+var picoChips = map[string]string{
+	"0003": "RP2040", // BOOTSEL
+	"0005": "RP2040", // MicroPython
+	"000A": "RP2040", // Pico SDK stdio
+	"0009": "RP2350", // Pico SDK stdio
+	"000F": "RP2350", // BOOTSEL
+}
+
+// PicoDevice describes a board recognized by DetectPico
+type PicoDevice struct {
+	// Chip is "RP2040" or "RP2350"
+	Chip string
+	// Mode is ModeBootloader for BOOTSEL mode, where the board is a mass storage device
+	// that accepts UF2 files, and ModeApplication when it runs firmware with a serial port
+	Mode Mode
+}
+
+// DetectPico recognizes RP2040 and RP2350 boards from their IDs
+func DetectPico(device serialfinder.SerialDeviceInfo) (PicoDevice, bool) {
+	if !strings.EqualFold(device.Vid, raspberryPiVid) {
+		return PicoDevice{}, false
+	}
+	pid := strings.ToUpper(device.Pid)
+	chip, ok := picoChips[pid]
+	if !ok {
+		return PicoDevice{}, false
+	}
+	if pid == "0003" || pid == "000F" {
+		return PicoDevice{Chip: chip, Mode: ModeBootloader}, true
+	}
+	return PicoDevice{Chip: chip, Mode: ModeApplication}, true
+}
@@ -0,0 +1,85 @@
+package profiles
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// ProbeKind names a family of debug probes
+type ProbeKind string
+
+const (
+	ProbeJLink      ProbeKind = "J-Link"
+	ProbeSTLink     ProbeKind = "ST-Link"
+	ProbeCMSISDAP   ProbeKind = "CMSIS-DAP"
+	ProbeBlackMagic ProbeKind = "Black Magic Probe"
+)
+
+// PortRole tells what a serial port of a debug probe is for
+type PortRole string
+
+const (
+	// RoleUnknown is reported when the interface number of the port is unknown
+	RoleUnknown PortRole = ""
+	// RoleConsole is the virtual COM port bridged to the UART of the target
+	RoleConsole PortRole = "console"
+	// RoleGDB is the GDB server of probes that run one, such as the Black Magic Probe
+	RoleGDB PortRole = "gdb"
+)
+
+// DebugProbe describes a serial port of a debug probe
+type DebugProbe struct {
+	Kind ProbeKind
+	Role PortRole
+}
+
+// stLinkPids are the ST-Link product IDs; STMicroelectronics uses its VID for other
+// devices, such as the STM32 virtual COM port and DFU bootloader
+var stLinkPids = map[string]bool{
+	"3744": true, "3748": true, "374A": true, "374B": true, "374D": true, "374E": true,
+	"374F": true, "3752": true, "3753": true, "3754": true, "3755": true, "3757": true,
+}
+
+// cmsisDAPIDs are probes that implement CMSIS-DAP without saying so in their name: the Arm
+// DAPLink firmware and the Raspberry Pi Debug Probe
+var cmsisDAPIDs = Profile{IDs: []ID{{"0D28", "0204"}, {"2E8A", "000C"}}}
+
+// DetectDebugProbe recognizes the serial ports of J-Link, ST-Link, CMSIS-DAP and Black
+// Magic probes and tells which one is the console of the target. The Black Magic Probe
+// has two ports, the GDB server on interface 00 and the console on interface 02; the
+// other probes only expose the console.
+func DetectDebugProbe(device serialfinder.SerialDeviceInfo) (DebugProbe, bool) {
+	vid, pid := strings.ToUpper(device.Vid), strings.ToUpper(device.Pid)
+	name := strings.ToUpper(device.Product)
+	switch {
+	case vid == "1366":
+		return DebugProbe{Kind: ProbeJLink, Role: RoleConsole}, true
+	case vid == "0483" && stLinkPids[pid]:
+		return DebugProbe{Kind: ProbeSTLink, Role: RoleConsole}, true
+	case vid == "1D50" && pid == "6018":
+		probe := DebugProbe{Kind: ProbeBlackMagic}
+		switch device.Interface {
+		case "00":
+			probe.Role = RoleGDB
+		case "02":
+			probe.Role = RoleConsole
+		}
+		return probe, true
+	case cmsisDAPIDs.Match(device) || strings.Contains(name, "CMSIS-DAP") || strings.Contains(name, "DAPLINK"):
+		return DebugProbe{Kind: ProbeCMSISDAP, Role: RoleConsole}, true
+	}
+	return DebugProbe{}, false
+}
+
+// ProbeConsoles returns the console ports of the debug probes among the devices, for
+// tools that open the target console of whichever probe is attached
+func ProbeConsoles(devices []serialfinder.SerialDeviceInfo) []serialfinder.SerialDeviceInfo {
+	var consoles []serialfinder.SerialDeviceInfo
+	for _, device := range devices {
+		if probe, ok := DetectDebugProbe(device); ok && probe.Role == RoleConsole {
+			consoles = append(consoles, device)
+		}
+	}
+	return consoles
+}
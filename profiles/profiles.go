@@ -0,0 +1,85 @@
+// Package profiles holds curated VID/PID sets of common USB serial chips, so applications
+// can select devices by chip family instead of hard-coding hex IDs:
+//
+//	devices, err := serialfinder.FindByProfile(ctx, profiles.FTDI)
+//
+// The sets cover the product IDs the vendors assign to their serial bridges; boards that
+// ship a chip with a custom VID or PID are not included.
+package profiles
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// ID is a USB vendor and product ID pair, as four hex digits. An empty Pid matches every
+// product of the vendor.
+type ID struct {
+	Vid string
+	Pid string
+}
+
+// Profile is a family of serial devices
+type Profile struct {
+	Name string
+	// IDs are the VID/PID pairs of the family
+	IDs []ID
+	// Ports matches the port names of devices served by a class driver, which share no
+	// VID; it is nil for chip families
+	Ports *regexp.Regexp
+}
+
+var (
+	// FTDI covers the FT232R, FT2232, FT4232, FT232H and FT-X series
+	FTDI = Profile{Name: "FTDI", IDs: []ID{
+		{"0403", "6001"}, {"0403", "6010"}, {"0403", "6011"}, {"0403", "6014"}, {"0403", "6015"},
+	}}
+
+	// CH340 covers the WCH CH340, CH341, CH342, CH343, CH344 and CH910x bridges
+	CH340 = Profile{Name: "CH340", IDs: []ID{
+		{"1A86", "7523"}, {"1A86", "7522"}, {"1A86", "5523"}, {"1A86", "55D2"},
+		{"1A86", "55D3"}, {"1A86", "55D4"}, {"1A86", "55D5"}, {"1A86", "55D8"},
+	}}
+
+	// CP210x covers the Silicon Labs CP2102, CP2103, CP2104, CP2105, CP2108 and CP2109
+	CP210x = Profile{Name: "CP210x", IDs: []ID{
+		{"10C4", "EA60"}, {"10C4", "EA61"}, {"10C4", "EA70"}, {"10C4", "EA71"},
+	}}
+
+	// PL2303 covers the Prolific PL2303 series
+	PL2303 = Profile{Name: "PL2303", IDs: []ID{
+		{"067B", "2303"}, {"067B", "23A3"}, {"067B", "23C3"}, {"067B", "23D3"},
+	}}
+
+	// CDCACM covers devices using the USB CDC ACM class driver, such as Arduinos with
+	// native USB, Raspberry Pi Picos and most microcontroller boards. They are recognized
+	// by the port names Linux and macOS give them; Windows names them COM like any other
+	// port, so they are not matched there.
+	CDCACM = Profile{Name: "CDC-ACM", Ports: regexp.MustCompile(`^/dev/(ttyACM\d+|cu\.usbmodem.*|tty\.usbmodem.*)$`)}
+)
+
+// All lists the built-in profiles in the order MatchProfile tries them: Espressif and Pico
+// before the CDC ACM class their chips use, and the hint-only EspressifBridges left out
+var All = []Profile{FTDI, CH340, CP210x, PL2303, Espressif, Pico, CDCACM}
+
+// Match reports whether the device belongs to the profile
+func (p Profile) Match(device serialfinder.SerialDeviceInfo) bool {
+	for _, id := range p.IDs {
+		if strings.EqualFold(id.Vid, device.Vid) && (id.Pid == "" || strings.EqualFold(id.Pid, device.Pid)) {
+			return true
+		}
+	}
+	return p.Ports != nil && p.Ports.MatchString(device.Port)
+}
+
+// MatchProfile returns the first profile of All the device belongs to
+func MatchProfile(device serialfinder.SerialDeviceInfo) (Profile, bool) {
+	for _, p := range All {
+		if p.Match(device) {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
@@ -0,0 +1,114 @@
+package serialfinder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseQuery builds a Filter from a query such as
+//
+//	vid=0403 pid=6001 serial~^A5 transport=usb
+//
+// so command lines and configuration files can select devices without building a Filter
+// in code. A query is a list of terms separated by spaces, each a field, an operator and a
+// value. The fields are:
+//
+//	vid, pid    the IDs, as accepted by Filter, e.g. vid=0403, pid=60?? or pid=6001-6015
+//	serial      serial=A50285BI for the exact serial number, serial~^A5 for a regular expression
+//	port        port=/dev/ttyUSB0 for the exact port, port~ttyUSB for a regular expression
+//	transport   the bus, e.g. transport=usb
+//
+// Values containing spaces are written as double-quoted Go strings, e.g.
+// serial~"^FT 23". Each field can appear once. The filter is normalized; ParseQuery
+// returns an error wrapping ErrInvalidFilter for a malformed query. An empty query
+// matches every device.
+func ParseQuery(query string) (Filter, error) {
+	var f Filter
+	seen := make(map[string]bool)
+	rest := strings.TrimSpace(query)
+	for rest != "" {
+		term, value, next, err := nextQueryTerm(rest)
+		if err != nil {
+			return Filter{}, err
+		}
+		rest = strings.TrimLeftFunc(next, unicode.IsSpace)
+
+		field, op := term[:len(term)-1], term[len(term)-1]
+		if seen[field] {
+			return Filter{}, fmt.Errorf("%w: %s appears twice in query", ErrInvalidFilter, field)
+		}
+		seen[field] = true
+
+		switch {
+		case field == "vid" && op == '=':
+			f.Vid = value
+		case field == "pid" && op == '=':
+			f.Pid = value
+		case field == "transport" && op == '=':
+			transport, err := ParseTransport(value)
+			if err != nil {
+				return Filter{}, fmt.Errorf("%w: %v", ErrInvalidFilter, err)
+			}
+			f.Transport = transport
+		case field == "serial" && op == '=':
+			f.SerialNumber = value
+		case field == "serial" && op == '~':
+			if f.SerialRegexp, err = compileQueryRegexp(field, value); err != nil {
+				return Filter{}, err
+			}
+		case field == "port" && op == '=':
+			f.PortRegexp = regexp.MustCompile("^" + regexp.QuoteMeta(value) + "$")
+		case field == "port" && op == '~':
+			if f.PortRegexp, err = compileQueryRegexp(field, value); err != nil {
+				return Filter{}, err
+			}
+		case field == "vid" || field == "pid" || field == "transport":
+			return Filter{}, fmt.Errorf("%w: %s takes = in query, not %c", ErrInvalidFilter, field, op)
+		default:
+			return Filter{}, fmt.Errorf("%w: unknown field %q in query", ErrInvalidFilter, field)
+		}
+	}
+	return f.Normalize()
+}
+
+// nextQueryTerm splits the first term off a query, returning the field with its operator,
+// the unquoted value and the rest of the query
+func nextQueryTerm(query string) (term, value, rest string, err error) {
+	i := strings.IndexAny(query, "=~")
+	if i <= 0 || strings.IndexFunc(query[:i], unicode.IsSpace) >= 0 {
+		field, _, _ := strings.Cut(query, " ")
+		return "", "", "", fmt.Errorf("%w: %q in query is not field=value or field~regexp", ErrInvalidFilter, field)
+	}
+	term, rest = strings.ToLower(query[:i+1]), query[i+1:]
+
+	if strings.HasPrefix(rest, `"`) {
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return "", "", "", fmt.Errorf("%w: unterminated quoted value for %s in query", ErrInvalidFilter, term[:i])
+		}
+		value, _ = strconv.Unquote(quoted)
+		rest = rest[len(quoted):]
+		if rest != "" && !unicode.IsSpace(rune(rest[0])) {
+			return "", "", "", fmt.Errorf("%w: no space after the value of %s in query", ErrInvalidFilter, term[:i])
+		}
+		return term, value, rest, nil
+	}
+
+	end := strings.IndexFunc(rest, unicode.IsSpace)
+	if end < 0 {
+		end = len(rest)
+	}
+	return term, rest[:end], rest[end:], nil
+}
+
+// compileQueryRegexp compiles the regular expression of a query field
+func compileQueryRegexp(field, expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s regexp in query: %v", ErrInvalidFilter, field, err)
+	}
+	return re, nil
+}
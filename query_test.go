@@ -0,0 +1,84 @@
+package serialfinder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseQuery(t *testing.T) {
+	// filterText describes a filter with its regular expressions as text, to compare them
+	type filterText struct {
+		vid, pid, serial, serialExpr, portExpr string
+		transport                              TransportType
+	}
+	text := func(f Filter) filterText {
+		got := filterText{vid: f.Vid, pid: f.Pid, serial: f.SerialNumber, transport: f.Transport}
+		if f.SerialRegexp != nil {
+			got.serialExpr = f.SerialRegexp.String()
+		}
+		if f.PortRegexp != nil {
+			got.portExpr = f.PortRegexp.String()
+		}
+		return got
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  filterText
+	}{
+		{"", filterText{}},
+		{"   ", filterText{}},
+		{"vid=0403 pid=6001", filterText{vid: "0403", pid: "6001"}},
+		{"vid=403 pid=0x6001", filterText{vid: "0403", pid: "6001"}},
+		{"  VID=1a86\tPID=7523  ", filterText{vid: "1A86", pid: "7523"}},
+		{"vid=0403:6015", filterText{vid: "0403", pid: "6015"}},
+		{"pid=60?? vid=0403", filterText{vid: "0403", pid: "60??"}},
+		{"pid=6001-6015", filterText{pid: "6001-6015"}},
+		{"serial=A50285BI", filterText{serial: "A50285BI"}},
+		{"serial~^A5", filterText{serialExpr: "^A5"}},
+		{`serial~"^FT 23"`, filterText{serialExpr: "^FT 23"}},
+		{`serial="A B\tC"`, filterText{serial: "A B\tC"}},
+		{`serial="a=b~c" vid=0403`, filterText{vid: "0403", serial: "a=b~c"}},
+		{"port=/dev/ttyUSB0", filterText{portExpr: `^/dev/ttyUSB0$`}},
+		{"port=COM1.5", filterText{portExpr: `^COM1\.5$`}},
+		{"port~ttyACM[0-9]", filterText{portExpr: "ttyACM[0-9]"}},
+		{"transport=usb", filterText{transport: TransportUSB}},
+		{"transport=bluetooth serial~x", filterText{serialExpr: "x", transport: TransportBluetooth}},
+	} {
+		f, err := ParseQuery(tc.query)
+		if err != nil {
+			t.Errorf("ParseQuery(%q): %v", tc.query, err)
+			continue
+		}
+		if got := text(f); got != tc.want {
+			t.Errorf("ParseQuery(%q) = %+v, want %+v", tc.query, got, tc.want)
+		}
+	}
+
+	for _, query := range []string{
+		"vid",
+		"0403",
+		"=0403",
+		"vid =0403",
+		"color=blue",
+		"vid=0403 vid=0404",
+		"serial=A1 serial~A",
+		"vid~04",
+		"transport~usb",
+		"transport=carrier-pigeon",
+		"vid=xyz",
+		"pid=10000",
+		"pid=6015-6001",
+		"pid=6001-",
+		"vid=0403:6001 pid=6001",
+		"serial~[",
+		"port~(",
+		`serial="unterminated`,
+		`serial="A"vid=0403`,
+	} {
+		_, err := ParseQuery(query)
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("ParseQuery(%q) = %v, want an error wrapping ErrInvalidFilter", query, err)
+		}
+	}
+}
@@ -0,0 +1,585 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// registryTree reads the part of HKEY_LOCAL_MACHINE the Windows backend enumerates. Paths
+// are relative to HKEY_LOCAL_MACHINE, such as SYSTEM\CurrentControlSet\Enum\USB.
+type registryTree interface {
+	SubKeyNames(path string) ([]string, error)
+	ValueNames(path string) ([]string, error)
+	StringValue(path, name string) (string, error)
+}
+
+// devNodeSource answers the questions the Windows backend asks the configuration manager
+// about device instances such as USB\VID_0403&PID_6001\A50285BI
+type devNodeSource interface {
+	// Located reports whether the instance has a device node, i.e. is present
+	Located(instanceID string) bool
+	// Present reports whether the instance is present and started without a problem. An
+	// error means the configuration manager could not answer.
+	Present(instanceID string) (bool, error)
+	// Arrival returns when the instance was last connected, or the zero time
+	Arrival(instanceID string) time.Time
+	Topology(instanceID string) *Topology
+	Power(instanceID string) *PowerInfo
+}
+
+// registryScan finds devices by walking the USB enumeration tree in the registry
+type registryScan struct {
+	reg   registryTree
+	nodes devNodeSource
+	// probe opens a port to tell whether it is active and held by another process; nil when
+	// ports cannot be opened, as when replaying a capture
+	probe func(port string) (active, inUse bool)
+}
+
+// list retrieves USB devices, filtering by VID and PID, and finds the corresponding COM port
+func (s registryScan) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+
+	controlSets := []string{"CurrentControlSet"}
+	if o.alternateControlSets {
+		controlSets = append(controlSets, s.alternateControlSets()...)
+	}
+
+	// CurrentControlSet links to one of the numbered sets, so the same device can be found twice
+	seen := make(map[string]bool)
+	for i, controlSet := range controlSets {
+		found, err := s.scanControlSet(ctx, controlSet, f, o)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
+			// Only the current control set is required; alternate ones are best effort
+			if i == 0 {
+				return nil, err
+			}
+			continue
+		}
+		for _, device := range found {
+			if !seen[device.Port] {
+				seen[device.Port] = true
+				devices = append(devices, device)
+			}
+		}
+		if o.stopped(devices) {
+			return devices, nil
+		}
+	}
+
+	// The FTDI VCP driver enumerates its ports below FTDIBUS rather than below the USB device
+	ftdi, err := s.scanFTDIBus(ctx, f, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range ftdi {
+		if !seen[device.Port] {
+			seen[device.Port] = true
+			devices = append(devices, device)
+		}
+	}
+	if o.stopped(devices) {
+		return devices, nil
+	}
+
+	// Bluetooth SPP ports are enumerated by the Bluetooth stack rather than the USB hub
+	bluetooth, err := s.scanBluetooth(ctx, f, o)
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range bluetooth {
+		if !seen[device.Port] {
+			seen[device.Port] = true
+			devices = append(devices, device)
+		}
+	}
+	if o.stopped(devices) {
+		return devices, nil
+	}
+
+	// Ports that are not USB devices, or whose driver enumerates them outside Enum\USB,
+	// only appear in the SERIALCOMM device map
+	for _, device := range s.serialCommPorts(o) {
+		if !seen[device.Port] && f.matchIDs(device.Vid, device.Pid) {
+			seen[device.Port] = true
+			devices = append(devices, device)
+		}
+	}
+
+	return devices, nil
+}
+
+// bluetoothEnumKey is where the Bluetooth stack enumerates the services of paired devices
+const bluetoothEnumKey = `SYSTEM\CurrentControlSet\Enum\BTHENUM`
+
+// scanBluetooth walks Enum\BTHENUM for Bluetooth serial ports (SPP) that are paired and
+// present. Presence is checked through the configuration manager because opening a
+// Bluetooth COM port starts a connection attempt to the remote device.
+func (s registryScan) scanBluetooth(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+
+	serviceIDs, err := s.reg.SubKeyNames(bluetoothEnumKey)
+	if err != nil {
+		// No Bluetooth stack installed
+		return nil, nil
+	}
+
+	for _, serviceID := range serviceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Service IDs look like {00001101-0000-1000-8000-00805f9b34fb}_VID&0002054c_PID&0268
+		vid, pid := parseBluetoothServiceIDWindows(serviceID)
+		if !f.matchIDs(vid, pid) {
+			continue
+		}
+
+		instances, err := s.reg.SubKeyNames(bluetoothEnumKey + `\` + serviceID)
+		if err != nil {
+			continue
+		}
+		for _, instance := range instances {
+			instanceID := `BTHENUM\` + serviceID + `\` + instance
+			address := bluetoothAddressWindows(instance)
+			// Local service ports have no remote address
+			if address == "" {
+				continue
+			}
+			if !s.nodes.Located(instanceID) {
+				continue
+			}
+
+			portName, err := s.reg.StringValue(bluetoothEnumKey+`\`+serviceID+`\`+instance+`\Device Parameters`, "PortName")
+			if err != nil {
+				continue
+			}
+
+			devices = append(devices, SerialDeviceInfo{
+				SerialNumber: address,
+				Vid:          vid,
+				Pid:          pid,
+				Port:         portName,
+				Transport:    TransportBluetooth,
+				Location:     instanceID,
+			})
+			if o.stopped(devices) {
+				return devices, nil
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// parseBluetoothServiceIDWindows extracts the VID and PID a Bluetooth device announced
+// through its Device ID profile, if any
+func parseBluetoothServiceIDWindows(serviceID string) (vid, pid string) {
+	for _, part := range strings.Split(strings.ToUpper(serviceID), "_") {
+		switch {
+		case strings.HasPrefix(part, "VID&") && len(part) >= 8:
+			// The VID is prefixed with its 4-digit source (0001 Bluetooth SIG, 0002 USB-IF)
+			vid = part[len(part)-4:]
+		case strings.HasPrefix(part, "PID&"):
+			pid = part[4:]
+		}
+	}
+	return vid, pid
+}
+
+// bluetoothAddressWindows extracts the remote device address from a BTHENUM instance such as
+// 8&2f7c5d5&0&001122334455_C00000000. It returns an empty string for local services, whose
+// address is all zeros.
+func bluetoothAddressWindows(instance string) string {
+	i := strings.LastIndex(instance, "&")
+	if i < 0 {
+		return ""
+	}
+	address, _, _ := strings.Cut(instance[i+1:], "_")
+	if len(address) != 12 || strings.Trim(address, "0") == "" {
+		return ""
+	}
+	return strings.ToUpper(address)
+}
+
+// serialCommDrivers maps the device name prefixes found in the SERIALCOMM device map to the
+// transport of the driver that created them
+var serialCommDrivers = []struct {
+	prefix    string
+	transport TransportType
+}{
+	{`\Device\VCP`, TransportUSB},            // FTDI
+	{`\Device\USBSER`, TransportUSB},         // CDC ACM
+	{`\Device\Silabser`, TransportUSB},       // Silicon Labs CP210x
+	{`\Device\ProlificSerial`, TransportUSB}, // Prolific PL2303
+	{`\Device\CH341SER`, TransportUSB},       // WCH CH340/CH341
+	{`\Device\BthModem`, TransportBluetooth},
+	{`\Device\com0com`, TransportVirtual},
+	{`\Device\Serial`, TransportPlatform}, // onboard UARTs and many PCI cards
+}
+
+// serialCommKey is the device map of the serial ports present since boot
+const serialCommKey = `HARDWARE\DEVICEMAP\SERIALCOMM`
+
+// serialCommPorts lists the ports in HARDWARE\DEVICEMAP\SERIALCOMM, which Windows rebuilds at
+// boot and only holds ports that are present. Ports of USB drivers are always returned;
+// other ports only when WithIncludeNonUSB is set. VID and PID are unknown.
+func (s registryScan) serialCommPorts(o options) []SerialDeviceInfo {
+	names, err := s.reg.ValueNames(serialCommKey)
+	if err != nil {
+		return nil
+	}
+
+	var devices []SerialDeviceInfo
+	for _, name := range names {
+		port, err := s.reg.StringValue(serialCommKey, name)
+		if err != nil || port == "" {
+			continue
+		}
+
+		transport := TransportUnknown
+		for _, driver := range serialCommDrivers {
+			if strings.HasPrefix(name, driver.prefix) {
+				transport = driver.transport
+				break
+			}
+		}
+		if transport != TransportUSB && !o.includeNonUSB {
+			continue
+		}
+
+		devices = append(devices, SerialDeviceInfo{
+			Port:      port,
+			Transport: transport,
+		})
+	}
+	return devices
+}
+
+// inSerialComm reports whether the port is in the SERIALCOMM device map
+func (s registryScan) inSerialComm(port string) bool {
+	names, err := s.reg.ValueNames(serialCommKey)
+	if err != nil {
+		return false
+	}
+	for _, name := range names {
+		if value, err := s.reg.StringValue(serialCommKey, name); err == nil && strings.EqualFold(value, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// alternateControlSets lists the numbered control sets (ControlSet001, ControlSet002, ...)
+func (s registryScan) alternateControlSets() []string {
+	names, err := s.reg.SubKeyNames(`SYSTEM`)
+	if err != nil {
+		return nil
+	}
+
+	var controlSets []string
+	for _, name := range names {
+		if strings.HasPrefix(name, "ControlSet") {
+			controlSets = append(controlSets, name)
+		}
+	}
+	return controlSets
+}
+
+// scanControlSet walks the USB enumeration tree of one control set
+func (s registryScan) scanControlSet(ctx context.Context, controlSet string, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+
+	// Read the list of subkeys (device IDs) of the USB devices
+	usbKey := `SYSTEM\` + controlSet + `\Enum\USB`
+	deviceIDs, err := s.reg.SubKeyNames(usbKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The parents of composite devices are only looked up once an interface is found
+	var parents map[string]string
+
+	// Iterate over each device ID
+	for _, deviceID := range deviceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Check if the deviceID carries the specified VID and PID
+		vid, pid, ok := parseDeviceIDWindows(deviceID)
+		if !ok || !f.matchIDs(vid, pid) {
+			continue
+		}
+
+		// Read the list of subkeys under each device ID (which usually include serial numbers)
+		serials, err := s.reg.SubKeyNames(usbKey + `\` + deviceID)
+		if err != nil {
+			// A key locked while a driver installs is worth scanning again
+			if isTransientErrno(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		// Iterate over each serial number
+		for _, serial := range serials {
+			_, span := startSpan(ctx, "serialfinder.resolve", attribute.String("serialfinder.instance", `USB\`+deviceID+`\`+serial))
+			device, ok := s.iterateSerials("USB", serial, deviceID, usbKey, o)
+			span.End()
+			if ok { // Append only if the device is active
+				device.Vid = vid
+				device.Pid = pid
+				device.Interface = interfaceFromDeviceIDWindows(deviceID)
+				device.Location = `USB\` + deviceID + `\` + serial
+
+				// Interfaces of a composite device are named after the ParentIdPrefix of the
+				// parent instance, e.g. 6&2a1b3c4d&0&0000 below the parent with prefix 6&2a1b3c4d&0
+				if i := strings.LastIndex(serial, "&"); i > 0 && strings.Contains(strings.ToUpper(deviceID), "&MI_") {
+					if parents == nil {
+						parents = s.compositeParents(usbKey, deviceIDs)
+					}
+					device.Location = serial[:i]
+					if parent, ok := parents[serial[:i]]; ok {
+						device.Location = parent
+					}
+				}
+				s.addExtendedInfo(&device, o)
+				devices = append(devices, device)
+				if o.stopped(devices) {
+					return devices, nil
+				}
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// compositeParents maps the ParentIdPrefix of each USB device instance to its instance ID,
+// so interfaces can be traced back to the physical device
+func (s registryScan) compositeParents(usbKey string, deviceIDs []string) map[string]string {
+	parents := make(map[string]string)
+	for _, deviceID := range deviceIDs {
+		if strings.Contains(strings.ToUpper(deviceID), "&MI_") {
+			continue
+		}
+		instances, err := s.reg.SubKeyNames(usbKey + `\` + deviceID)
+		if err != nil {
+			continue
+		}
+		for _, instance := range instances {
+			prefix, err := s.reg.StringValue(usbKey+`\`+deviceID+`\`+instance, "ParentIdPrefix")
+			if err == nil && prefix != "" {
+				parents[prefix] = `USB\` + deviceID + `\` + instance
+			}
+		}
+	}
+	return parents
+}
+
+// ftdiBusKey is where the FTDI VCP driver enumerates the ports of its devices, with
+// instance IDs such as FTDIBUS\VID_0403+PID_6001+A50285BIA\0000
+const ftdiBusKey = `SYSTEM\CurrentControlSet\Enum\FTDIBUS`
+
+// scanFTDIBus walks Enum\FTDIBUS for the present ports of the FTDI VCP driver. Their
+// serial number is taken from the device ID, since the instance is always named 0000, and
+// their location is the USB device they belong to.
+func (s registryScan) scanFTDIBus(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+
+	deviceIDs, err := s.reg.SubKeyNames(ftdiBusKey)
+	if err != nil {
+		// The VCP driver is not installed
+		return nil, nil
+	}
+
+	for _, deviceID := range deviceIDs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		vid, pid, serial, iface, ok := parseFTDIBusDeviceIDWindows(deviceID)
+		if !ok || !f.matchIDs(vid, pid) {
+			continue
+		}
+
+		instances, err := s.reg.SubKeyNames(ftdiBusKey + `\` + deviceID)
+		if err != nil {
+			continue
+		}
+		for _, instance := range instances {
+			device, ok := s.iterateSerials("FTDIBUS", instance, deviceID, ftdiBusKey, o)
+			if !ok {
+				continue
+			}
+			device.SerialNumber = serial
+			device.Vid = vid
+			device.Pid = pid
+			device.Interface = iface
+
+			// The port is a child of the USB device, or of one of its interfaces for
+			// multi-channel chips; both are children of the instance named after the serial
+			device.Location = `FTDIBUS\` + deviceID + `\` + instance
+			if serial != "" {
+				if parent := `USB\VID_` + vid + `&PID_` + pid + `\` + serial; s.nodes.Located(parent) {
+					device.Location = parent
+				}
+			}
+			s.addExtendedInfo(&device, o)
+			devices = append(devices, device)
+			if o.stopped(devices) {
+				return devices, nil
+			}
+		}
+	}
+
+	return devices, nil
+}
+
+// addExtendedInfo looks up the hub chain and power state of the device node at the
+// Location of the device if WithExtendedInfo or WithDriver asks for them
+func (s registryScan) addExtendedInfo(device *SerialDeviceInfo, o options) {
+	if o.wantExtended() {
+		device.Topology = s.nodes.Topology(device.Location)
+		device.Power = s.nodes.Power(device.Location)
+	}
+}
+
+// parseFTDIBusDeviceIDWindows splits an FTDIBUS device ID such as VID_0403+PID_6001+A50285BIA
+// into the VID, PID, the serial number the device reports over USB and its interface. The
+// driver appends the channel letter (A for the first channel, B for the second of an
+// FT2232, ...) to the serial number; generated IDs of devices without a serial number
+// contain '&' instead.
+func parseFTDIBusDeviceIDWindows(deviceID string) (vid, pid, serial, iface string, ok bool) {
+	parts := strings.SplitN(deviceID, "+", 3)
+	if len(parts) < 2 {
+		return "", "", "", "", false
+	}
+	vid, pid, ok = parseDeviceIDWindows(parts[0] + "&" + parts[1])
+	if !ok {
+		return "", "", "", "", false
+	}
+	if len(parts) == 3 && !strings.Contains(parts[2], "&") && len(parts[2]) > 1 {
+		channel := parts[2][len(parts[2])-1]
+		if channel >= 'A' && channel <= 'H' {
+			serial = parts[2][:len(parts[2])-1]
+			iface = fmt.Sprintf("%02X", channel-'A')
+		} else {
+			serial = parts[2]
+		}
+	}
+	return vid, pid, serial, iface, true
+}
+
+// parseDeviceIDWindows extracts the VID and PID from a device ID like VID_0403&PID_6001&MI_00
+func parseDeviceIDWindows(deviceID string) (vid, pid string, ok bool) {
+	parts := strings.Split(strings.ToUpper(deviceID), "&")
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "VID_") || !strings.HasPrefix(parts[1], "PID_") {
+		return "", "", false
+	}
+	return parts[0][4:], parts[1][4:], true
+}
+
+// interfaceFromDeviceIDWindows returns the interface number of a composite device ID like
+// VID_0403&PID_6010&MI_01, or an empty string for a non-composite device
+func interfaceFromDeviceIDWindows(deviceID string) string {
+	for _, part := range strings.Split(strings.ToUpper(deviceID), "&") {
+		if strings.HasPrefix(part, "MI_") {
+			return part[3:]
+		}
+	}
+	return ""
+}
+
+// iterateSerials gets the COM port of one instance of a device enumerated by enumerator
+// (USB or FTDIBUS) below enumKey, if it is present
+func (s registryScan) iterateSerials(enumerator, serial, deviceID, enumKey string, o options) (SerialDeviceInfo, bool) {
+	// Read the `PortName` value of the `Device Parameters` key, which should contain the COM port
+	portName, err := s.reg.StringValue(enumKey+`\`+deviceID+`\`+serial+`\Device Parameters`, "PortName")
+	if err != nil {
+		return SerialDeviceInfo{}, false
+	}
+
+	// Ask the configuration manager whether the device is present and started. Unlike
+	// opening the port, this does not touch the device, so DTR is not toggled and boards
+	// that reset on connection (e.g. Arduinos) are left alone.
+	instanceID := enumerator + `\` + deviceID + `\` + serial
+	present, err := s.nodes.Present(instanceID)
+	if err != nil {
+		// The configuration manager could not answer, so fall back to opening the port or,
+		// when probing is disabled, to the device map, which only holds present ports. The
+		// registry alone keeps the COM number of every device ever attached.
+		if !o.disablePortProbe && s.probe != nil {
+			present, _ = s.probe(portName)
+		} else {
+			present = s.inSerialComm(portName)
+		}
+	}
+	if !present {
+		return SerialDeviceInfo{}, false
+	}
+
+	// Opening the port is the only way to tell whether another process holds it
+	inUse := false
+	if o.checkInUse && !o.disablePortProbe && s.probe != nil {
+		_, inUse = s.probe(portName)
+	}
+
+	// The arrival time and driver have to be looked up before the serial number is cleared
+	// below. The Service value names the driver installed for the device, e.g. usbser.
+	connectedAt := s.nodes.Arrival(instanceID)
+	var driver string
+	var attributes map[string]string
+	if o.wantExtended() {
+		driver, _ = s.reg.StringValue(enumKey+`\`+deviceID+`\`+serial, "Service")
+		attributes = s.readAttributes(enumKey + `\` + deviceID + `\` + serial)
+	}
+
+	// Instance IDs containing '&' are generated by Windows for devices without a serial number
+	if strings.Contains(serial, "&") {
+		serial = ""
+	}
+
+	return SerialDeviceInfo{
+		SerialNumber: serial,
+		Port:         portName,
+		Transport:    TransportUSB,
+		ConnectedAt:  connectedAt,
+		InUse:        inUse,
+		Driver:       driver,
+		Attributes:   attributes,
+	}, true
+}
+
+// readAttributes returns the string values of the device key for Attributes, such as
+// FriendlyName and ContainerID, but not the Service value reported as Driver
+func (s registryScan) readAttributes(key string) map[string]string {
+	names, err := s.reg.ValueNames(key)
+	if err != nil {
+		return nil
+	}
+	var attributes map[string]string
+	for _, name := range names {
+		if name == "Service" {
+			continue
+		}
+		// Values of other types, such as the REG_MULTI_SZ HardwareID, are skipped
+		value, err := s.reg.StringValue(key, name)
+		if err != nil || value == "" {
+			continue
+		}
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[name] = value
+	}
+	return attributes
+}
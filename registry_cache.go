@@ -0,0 +1,97 @@
+package serialfinder
+
+import "strings"
+
+// registryHost is the registry API of the operating system, on open keys of type K
+type registryHost[K any] interface {
+	// root is HKEY_LOCAL_MACHINE
+	root() K
+	openKey(parent K, path string) (K, error)
+	closeKey(key K)
+	subKeyNames(key K) ([]string, error)
+	valueNames(key K) ([]string, error)
+	stringValue(key K, name string) (string, error)
+}
+
+// cachedRegistry reads the registry of a host. The keys whose subkeys are listed stay open
+// until Close, and every other key is opened relative to its nearest open ancestor, so
+// walking thousands of instances (most of them ghosts of detached devices) does not parse
+// the full path from HKEY_LOCAL_MACHINE for each read, and the keys read again, such as the
+// device IDs walked for composite parents, are not reopened.
+type cachedRegistry[K any, H registryHost[K]] struct {
+	host H
+	open map[string]K
+}
+
+// openKey opens the key at path relative to its nearest ancestor kept open. The caller
+// closes the key unless keep is set, in which case it stays open until Close.
+func (r *cachedRegistry[K, H]) openKey(path string, keep bool) (key K, cached bool, err error) {
+	if key, ok := r.open[path]; ok {
+		return key, true, nil
+	}
+
+	parent, rel := r.host.root(), path
+	for dir := path; ; {
+		i := strings.LastIndex(dir, `\`)
+		if i < 0 {
+			break
+		}
+		dir = dir[:i]
+		if key, ok := r.open[dir]; ok {
+			parent, rel = key, path[i+1:]
+			break
+		}
+	}
+
+	key, err = r.host.openKey(parent, rel)
+	if err != nil {
+		return key, false, err
+	}
+	if keep {
+		if r.open == nil {
+			r.open = make(map[string]K)
+		}
+		r.open[path] = key
+		return key, true, nil
+	}
+	return key, false, nil
+}
+
+// Close closes the keys kept open
+func (r *cachedRegistry[K, H]) Close() {
+	for _, key := range r.open {
+		r.host.closeKey(key)
+	}
+	r.open = nil
+}
+
+func (r *cachedRegistry[K, H]) SubKeyNames(path string) ([]string, error) {
+	// Keys with subkeys are the parents of the next reads, so they are kept open
+	key, _, err := r.openKey(path, true)
+	if err != nil {
+		return nil, err
+	}
+	return r.host.subKeyNames(key)
+}
+
+func (r *cachedRegistry[K, H]) ValueNames(path string) ([]string, error) {
+	key, cached, err := r.openKey(path, false)
+	if err != nil {
+		return nil, err
+	}
+	if !cached {
+		defer r.host.closeKey(key)
+	}
+	return r.host.valueNames(key)
+}
+
+func (r *cachedRegistry[K, H]) StringValue(path, name string) (string, error) {
+	key, cached, err := r.openKey(path, false)
+	if err != nil {
+		return "", err
+	}
+	if !cached {
+		defer r.host.closeKey(key)
+	}
+	return r.host.stringValue(key, name)
+}
@@ -0,0 +1,208 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingHost is a registry whose open keys are their full paths. It counts the attempts to
+// open each key, the keys still open and the path components each attempt resolves.
+type countingHost struct {
+	subKeys map[string][]string
+	values  map[string]map[string]string
+
+	opens      map[string]int
+	components []int
+	handles    int
+}
+
+func newCountingHost() *countingHost {
+	return &countingHost{subKeys: make(map[string][]string), values: make(map[string]map[string]string), opens: make(map[string]int)}
+}
+
+// set creates the key with its parents and sets one of its values
+func (h *countingHost) set(key, name, value string) {
+	h.addKey(key)
+	if h.values[key] == nil {
+		h.values[key] = make(map[string]string)
+	}
+	h.values[key][name] = value
+}
+
+// addKey creates the key and its missing parents
+func (h *countingHost) addKey(key string) {
+	if _, ok := h.subKeys[key]; ok {
+		return
+	}
+	h.subKeys[key] = nil
+	if i := strings.LastIndex(key, `\`); i >= 0 {
+		h.addKey(key[:i])
+		h.subKeys[key[:i]] = append(h.subKeys[key[:i]], key[i+1:])
+	}
+}
+
+func (h *countingHost) root() string { return "" }
+
+func (h *countingHost) openKey(parent, path string) (string, error) {
+	key := path
+	if parent != "" {
+		key = parent + `\` + path
+	}
+	h.opens[key]++
+	h.components = append(h.components, strings.Count(path, `\`)+1)
+	if _, ok := h.subKeys[key]; !ok {
+		return "", &fs.PathError{Op: "open", Path: key, Err: fs.ErrNotExist}
+	}
+	h.handles++
+	return key, nil
+}
+
+func (h *countingHost) closeKey(key string) { h.handles-- }
+
+func (h *countingHost) subKeyNames(key string) ([]string, error) {
+	return h.subKeys[key], nil
+}
+
+func (h *countingHost) valueNames(key string) ([]string, error) {
+	var names []string
+	for name := range h.values[key] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (h *countingHost) stringValue(key, name string) (string, error) {
+	value, ok := h.values[key][name]
+	if !ok {
+		return "", &fs.PathError{Op: "read", Path: key + `\` + name, Err: fs.ErrNotExist}
+	}
+	return value, nil
+}
+
+// presentSet reports the instances it holds as present
+type presentSet map[string]bool
+
+func (p presentSet) Located(id string) bool          { return p[id] }
+func (p presentSet) Present(id string) (bool, error) { return p[id], nil }
+func (p presentSet) Arrival(string) time.Time        { return time.Time{} }
+func (p presentSet) Topology(string) *Topology       { return nil }
+func (p presentSet) Power(string) *PowerInfo         { return nil }
+
+func TestCachedRegistryOpensPerScan(t *testing.T) {
+	const usbKey = `SYSTEM\CurrentControlSet\Enum\USB`
+	host := newCountingHost()
+	present := make(presentSet)
+
+	// Every device ever attached keeps its instance, so most of them are ghosts
+	const deviceIDs, ghosts = 20, 100
+	instances := 0
+	for d := 0; d < deviceIDs; d++ {
+		deviceID := fmt.Sprintf("VID_0403&PID_%04X", 0x6000+d)
+		for g := 0; g < ghosts; g++ {
+			key := fmt.Sprintf(`%s\%s\G%03d`, usbKey, deviceID, g)
+			host.set(key, "Service", "FTDIBUS")
+			host.set(key+`\Device Parameters`, "PortName", fmt.Sprintf("COM%d", 10+d*ghosts+g))
+			instances++
+		}
+	}
+	// A composite device, so the device IDs are walked a second time for its parent
+	host.set(usbKey+`\VID_2341&PID_0043\A1`, "ParentIdPrefix", "6&2a1b3c4d&0")
+	host.set(usbKey+`\VID_2341&PID_0043&MI_00\6&2a1b3c4d&0&0000\Device Parameters`, "PortName", "COM3")
+	present[`USB\VID_2341&PID_0043&MI_00\6&2a1b3c4d&0&0000`] = true
+	instances += 2
+	host.set(`SYSTEM\CurrentControlSet\Enum\USB\VID_0403&PID_6000\G000`, "Service", "FTDIBUS")
+	present[`USB\VID_0403&PID_6000\G000`] = true
+
+	reg := &cachedRegistry[string, *countingHost]{host: host}
+	devices, err := registryScan{reg: reg, nodes: present}.list(context.Background(), Filter{}, options{})
+	reg.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("found %d devices, want 2: %+v", len(devices), devices)
+	}
+
+	// Every key is opened once: Enum\USB, the device IDs, the Device Parameters of each
+	// instance, the instances of the devices that are not interfaces for their
+	// ParentIdPrefix, and the missing FTDIBUS, BTHENUM and SERIALCOMM keys
+	opens := 0
+	for key, n := range host.opens {
+		if n > 1 {
+			t.Errorf("opened %s %d times", key, n)
+		}
+		opens += n
+	}
+	if want := 1 + (deviceIDs + 2) + instances + (instances - 1) + 3; opens != want {
+		t.Errorf("opened %d keys for %d device IDs and %d instances, want %d", opens, deviceIDs+2, instances, want)
+	}
+	// Only Enum\USB and the missing keys are opened from HKEY_LOCAL_MACHINE; the others
+	// below an open key
+	long := 0
+	for _, n := range host.components {
+		if n > 2 {
+			long++
+		}
+	}
+	if long != 4 {
+		t.Errorf("%d opens resolved more than two path components, want 4", long)
+	}
+	if host.handles != 0 {
+		t.Errorf("%d keys left open", host.handles)
+	}
+}
+
+func TestRegistryScanStopsAtMatch(t *testing.T) {
+	const ftdiKey, bthKey = `SYSTEM\CurrentControlSet\Enum\FTDIBUS`, `SYSTEM\CurrentControlSet\Enum\BTHENUM`
+	const service = `{00001101-0000-1000-8000-00805f9b34fb}_VID&0002054c_PID&0268`
+	host := newCountingHost()
+	present := make(presentSet)
+	host.addKey(`SYSTEM\CurrentControlSet\Enum\USB`)
+	for i := 0; i < 3; i++ {
+		deviceID := fmt.Sprintf("VID_0403+PID_6015+B%dA", i)
+		host.set(fmt.Sprintf(`%s\%s\0000\Device Parameters`, ftdiKey, deviceID), "PortName", fmt.Sprintf("COM%d", 3+i))
+		present[`FTDIBUS\`+deviceID+`\0000`] = true
+		instance := fmt.Sprintf("8&2f7c5d5&0&00112233445%d_C00000000", i)
+		host.set(fmt.Sprintf(`%s\%s\%s\Device Parameters`, bthKey, service, instance), "PortName", fmt.Sprintf("COM%d", 10+i))
+		present[`BTHENUM\`+service+`\`+instance] = true
+	}
+
+	for _, tc := range []struct {
+		port string
+		// walked are the keys whose ports the scan reads, unread the keys it must not open
+		walked, unread []string
+	}{
+		{"COM3", nil, []string{ftdiKey + `\VID_0403+PID_6015+B1A\0000\Device Parameters`, bthKey}},
+		{"COM4", []string{ftdiKey + `\VID_0403+PID_6015+B1A\0000\Device Parameters`}, []string{ftdiKey + `\VID_0403+PID_6015+B2A\0000\Device Parameters`, bthKey}},
+		{"COM10", []string{ftdiKey + `\VID_0403+PID_6015+B2A\0000\Device Parameters`}, []string{bthKey + `\` + service + `\8&2f7c5d5&0&001122334451_C00000000\Device Parameters`, serialCommKey}},
+		{"COM12", []string{bthKey + `\` + service + `\8&2f7c5d5&0&001122334452_C00000000\Device Parameters`}, []string{serialCommKey}},
+	} {
+		host.opens = make(map[string]int)
+		o := options{stopAt: func(device SerialDeviceInfo) bool { return device.Port == tc.port }}
+		reg := &cachedRegistry[string, *countingHost]{host: host}
+		devices, err := registryScan{reg: reg, nodes: present}.list(context.Background(), Filter{}, o)
+		reg.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(devices) == 0 || devices[len(devices)-1].Port != tc.port {
+			t.Errorf("the scan stopping at %s found %+v", tc.port, devices)
+		}
+		for _, key := range tc.walked {
+			if host.opens[key] == 0 {
+				t.Errorf("the scan stopping at %s did not open %s", tc.port, key)
+			}
+		}
+		for _, key := range tc.unread {
+			if host.opens[key] != 0 {
+				t.Errorf("the scan stopping at %s opened %s", tc.port, key)
+			}
+		}
+	}
+}
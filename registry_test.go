@@ -0,0 +1,71 @@
+package serialfinder_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// countingNodes counts the lookups of the extended information of device nodes
+type countingNodes struct {
+	presentNodes
+	mu              sync.Mutex
+	topology, power map[string]int
+}
+
+func (n *countingNodes) Topology(location string) *serialfinder.Topology {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.topology[location]++
+	return nil
+}
+
+func (n *countingNodes) Power(location string) *serialfinder.PowerInfo {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.power[location]++
+	return nil
+}
+
+func TestRegistryExtendedInfoOncePerDevice(t *testing.T) {
+	reg := newFakeRegistry()
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf(`SYSTEM\CurrentControlSet\Enum\USB\VID_0403&PID_6001\A%d`, i)
+		reg.set(key, "Service", "usbser")
+		reg.set(key+`\Device Parameters`, "PortName", fmt.Sprintf("COM%d", i+3))
+	}
+	// A port of the FTDI VCP driver, enumerated below FTDIBUS
+	key := `SYSTEM\CurrentControlSet\Enum\FTDIBUS\VID_0403+PID_6015+B1A\0000`
+	reg.set(key, "Service", "FTDIBUS")
+	reg.set(key+`\Device Parameters`, "PortName", "COM9")
+
+	nodes := &countingNodes{topology: make(map[string]int), power: make(map[string]int)}
+	backend := serialfinder.RegistryBackend(reg, nodes, serialfinder.WithExtendedInfo(true))
+	devices, err := backend.List(context.Background(), serialfinder.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 4 {
+		t.Fatalf("found %d devices, want 4", len(devices))
+	}
+
+	var topology, power int
+	for location, n := range nodes.topology {
+		if n != 1 {
+			t.Errorf("looked up the topology of %q %d times", location, n)
+		}
+		topology += n
+	}
+	for location, n := range nodes.power {
+		if n != 1 {
+			t.Errorf("looked up the power state of %q %d times", location, n)
+		}
+		power += n
+	}
+	if topology != len(devices) || power != len(devices) {
+		t.Errorf("looked up the topology %d and the power state %d times for %d devices", topology, power, len(devices))
+	}
+}
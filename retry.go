@@ -0,0 +1,56 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WithRetry runs a scan again, up to n times, when it fails with an error that may not
+// happen twice: a sysfs attribute that is busy while the kernel binds a driver, a registry
+// key locked by another process, or ioreg exiting while a USB device resets. Scans made
+// right as a device is plugged in often race the kernel this way. The first retry waits
+// backoff and each next one twice as long as the one before. Other errors, and the
+// cancellation of the context, end the scan at once.
+func WithRetry(n int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = n
+		o.retryBackoff = backoff
+	}
+}
+
+// transientError marks a scan failure that may not happen again, such as a tool exiting
+// while a device resets
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// isTransient reports whether a scan that failed with err may succeed if run again
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var transient *transientError
+	return errors.As(err, &transient) || isTransientErrno(err)
+}
+
+// listWithRetry runs the backend scan, retrying it as WithRetry configures
+func (f *Finder) listWithRetry(ctx context.Context, filter Filter, o options) ([]SerialDeviceInfo, error) {
+	devices, err := f.backend.list(ctx, filter, o)
+	wait := o.retryBackoff
+	for attempt := 0; err != nil && attempt < o.retries && isTransient(err); attempt++ {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+		wait *= 2
+		devices, err = f.backend.list(ctx, filter, o)
+	}
+	return devices, err
+}
@@ -0,0 +1,114 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+)
+
+// scanGroup coalesces concurrent scans with the same filter into one, so a burst of List
+// calls in a server runs one ioreg or registry walk instead of one per caller
+type scanGroup struct {
+	mu    sync.Mutex
+	calls map[string]*scanCall
+}
+
+// scanCall is a scan in flight and the callers waiting for it
+type scanCall struct {
+	done    chan struct{}
+	devices []SerialDeviceInfo
+	err     error
+	// waiters counts the callers still waiting; the scan is canceled when all of them gave up
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// do runs scan once for all the callers passing the same key while it is in flight, and
+// gives each of them its own copy of the result. The scan keeps the values of the context
+// of the caller that started it, and is only canceled once every caller's context is.
+func (g *scanGroup) do(ctx context.Context, key string, scan func(context.Context) ([]SerialDeviceInfo, error)) ([]SerialDeviceInfo, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*scanCall)
+	}
+	call, ok := g.calls[key]
+	if !ok {
+		scanCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &scanCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go func() {
+			devices, err := scan(scanCtx)
+			cancel()
+
+			// A canceled call may already have been replaced by a newer scan
+			g.mu.Lock()
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+			g.mu.Unlock()
+
+			call.devices, call.err = devices, err
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return cloneDevices(call.devices), call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			call.cancel()
+			// A new caller must not join a scan that is being canceled
+			if g.calls[key] == call {
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// scanKey identifies the scans that can be shared: those with the same filter
+func scanKey(filter Filter) string {
+	var serialExpr, portExpr string
+	if filter.SerialRegexp != nil {
+		serialExpr = filter.SerialRegexp.String()
+	}
+	if filter.PortRegexp != nil {
+		portExpr = filter.PortRegexp.String()
+	}
+	return fmt.Sprintf("%q %q %d %q %q %q", filter.Vid, filter.Pid, filter.Transport, filter.SerialNumber, serialExpr, portExpr)
+}
+
+// cloneDevices copies devices deeply enough that callers sharing a scan cannot see each
+// other's changes
+func cloneDevices(devices []SerialDeviceInfo) []SerialDeviceInfo {
+	if devices == nil {
+		return nil
+	}
+	clones := make([]SerialDeviceInfo, len(devices))
+	for i, device := range devices {
+		if device.Siblings != nil {
+			device.Siblings = append([]string(nil), device.Siblings...)
+		}
+		if device.Topology != nil {
+			topology := *device.Topology
+			topology.Ports = append([]int(nil), topology.Ports...)
+			device.Topology = &topology
+		}
+		if device.Power != nil {
+			power := *device.Power
+			device.Power = &power
+		}
+		if device.Attributes != nil {
+			device.Attributes = maps.Clone(device.Attributes)
+		}
+		clones[i] = device
+	}
+	return clones
+}
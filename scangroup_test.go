@@ -0,0 +1,166 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// blockingScan is a scan that waits for release, counting how often it ran
+type blockingScan struct {
+	runs    atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingScan() *blockingScan {
+	return &blockingScan{started: make(chan struct{}, 16), release: make(chan struct{})}
+}
+
+func (s *blockingScan) scan(ctx context.Context) ([]SerialDeviceInfo, error) {
+	s.runs.Add(1)
+	s.started <- struct{}{}
+	<-s.release
+	return []SerialDeviceInfo{{Port: "/dev/ttyUSB0", Siblings: []string{"/dev/ttyUSB1"}}}, nil
+}
+
+// current returns the call in flight for key
+func (g *scanGroup) current(key string) *scanCall {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.calls[key]
+}
+
+// waitWaiters waits until n callers wait for the call
+func (g *scanGroup) waitWaiters(call *scanCall, n int) {
+	for {
+		g.mu.Lock()
+		waiters := call.waiters
+		g.mu.Unlock()
+		if waiters == n {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestScanGroupShares(t *testing.T) {
+	var g scanGroup
+	s := newBlockingScan()
+
+	const callers = 20
+	results := make([][]SerialDeviceInfo, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			devices, err := g.do(context.Background(), "key", s.scan)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = devices
+		}(i)
+	}
+	<-s.started
+	// Let every caller join before the scan completes
+	g.waitWaiters(g.current("key"), callers)
+	close(s.release)
+	wg.Wait()
+
+	if runs := s.runs.Load(); runs != 1 {
+		t.Fatalf("scan ran %d times, want 1", runs)
+	}
+	results[0][0].Siblings[0] = "changed"
+	for i, devices := range results[1:] {
+		if len(devices) != 1 || devices[0].Siblings[0] != "/dev/ttyUSB1" {
+			t.Fatalf("caller %d got %+v, want its own copy of the result", i+1, devices)
+		}
+	}
+}
+
+func TestScanGroupCancel(t *testing.T) {
+	var g scanGroup
+	scanCanceled := make(chan struct{})
+	started := make(chan struct{})
+	scan := func(ctx context.Context) ([]SerialDeviceInfo, error) {
+		close(started)
+		<-ctx.Done()
+		close(scanCanceled)
+		return nil, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	go func() {
+		_, err := g.do(ctx, "key", scan)
+		errs <- err
+	}()
+	<-started
+	go func() {
+		_, err := g.do(ctx, "key", scan)
+		errs <- err
+	}()
+	g.waitWaiters(g.current("key"), 2)
+
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Fatalf("got error %v, want context.Canceled", err)
+		}
+	}
+	// The scan is only canceled once every caller gave up
+	<-scanCanceled
+}
+
+func TestScanGroupCanceledScanKeepsNewerCall(t *testing.T) {
+	var g scanGroup
+	old, fresh := newBlockingScan(), newBlockingScan()
+
+	// The only caller of the first scan gives up, which removes the call
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.do(ctx, "key", old.scan)
+	}()
+	<-old.started
+	oldCall := g.current("key")
+	cancel()
+	<-done
+
+	// A new caller starts a fresh scan under the same key
+	results := make(chan error, 2)
+	go func() {
+		_, err := g.do(context.Background(), "key", fresh.scan)
+		results <- err
+	}()
+	<-fresh.started
+	freshCall := g.current("key")
+
+	// The first scan ends while the fresh one is in flight, and must leave it in place
+	close(old.release)
+	<-oldCall.done
+	if g.current("key") != freshCall {
+		t.Fatal("the canceled scan removed the scan in flight")
+	}
+
+	// A later caller joins the fresh scan instead of starting another one
+	go func() {
+		_, err := g.do(context.Background(), "key", fresh.scan)
+		results <- err
+	}()
+	g.waitWaiters(freshCall, 2)
+	close(fresh.release)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if runs := fresh.runs.Load(); runs != 1 {
+		t.Fatalf("fresh scan ran %d times, want 1", runs)
+	}
+}
@@ -1,8 +1,89 @@
 package serialfinder
 
+import (
+	"context"
+	"time"
+)
+
+// SerialDeviceInfo describes one serial port and the device behind it. VID and PID are
+// upper-case hex strings such as "0403". See MarshalJSON for the JSON schema.
 type SerialDeviceInfo struct {
-	SerialNumber string
-	Vid          string
-	Pid          string
-	Port         string
+	SerialNumber string `json:"serial,omitempty"`
+	Vid          string `json:"vid"`
+	Pid          string `json:"pid"`
+	Port         string `json:"port"`
+	// DialinPort is the dial-in (/dev/tty.*) node on macOS; empty on other platforms
+	DialinPort string `json:"dialin_port,omitempty"`
+	// Transport is the bus the port is attached through
+	Transport TransportType `json:"transport"`
+	// ConnectedAt is when the device was attached; zero if the platform does not record it
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+	// InUse reports that another process holds the port open. It requires WithInUseCheck.
+	InUse bool `json:"in_use,omitempty"`
+	// ByPath is the link udev made to the port in /dev/serial/by-path on Linux, named after
+	// the USB port the device is plugged into. Unlike the /dev/serial/by-id links, it tells
+	// apart identical devices without a serial number. Empty on other platforms.
+	ByPath string `json:"by_path,omitempty"`
+	// Location identifies the physical USB device the port belongs to: the sysfs device
+	// name on Linux (e.g. 1-1.4), the locationID on macOS and the device instance ID on Windows
+	Location string `json:"location,omitempty"`
+	// Siblings are the other ports exposed by the same physical device
+	Siblings []string `json:"siblings,omitempty"`
+	// Interface is the USB interface number of the port as two hex digits, e.g. "01";
+	// empty when the backend cannot tell
+	Interface string `json:"interface,omitempty"`
+	// InterfaceName is the string the device gives the interface of the port, such as
+	// "CMSIS-DAP" or "Debug UART" on multi-port debug probes; empty when it gives none or
+	// the backend cannot read it
+	InterfaceName string `json:"interface_name,omitempty"`
+	// Topology is the chain of hub ports leading to the device; nil when unknown
+	Topology *Topology `json:"topology,omitempty"`
+	// Power is the power configuration and state of the device; nil when unknown
+	Power *PowerInfo `json:"power,omitempty"`
+	// Manufacturer and Product are the names the device reports for itself, when the
+	// backend can read them
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Product      string `json:"product,omitempty"`
+	// Description is a human-readable label for the port, such as "pl011 uart0" for a SoC
+	// UART described by the device tree, or the name given to RegisterKnownDevice
+	Description string `json:"description,omitempty"`
+	// Driver is the kernel driver bound to the port on Linux (e.g. cdc_acm or ftdi_sio), the
+	// driver class on macOS (e.g. AppleUSBACMData) and the driver service on Windows (e.g.
+	// usbser or FTDIBUS); empty when the backend cannot tell
+	Driver string `json:"driver,omitempty"`
+	// Attributes holds the raw properties the backend read that have no field of their own,
+	// named as the platform names them: the sysfs files of the USB device (e.g. bcdDevice or
+	// speed) and the udev properties on Linux, the properties of the USB device in the I/O
+	// Registry on macOS (e.g. "kUSBCurrentConfiguration") and the values of the device key
+	// in the registry on Windows (e.g. "FriendlyName"). Their names and formats differ
+	// between platforms and releases; nil when the backend read none.
+	Attributes map[string]string `json:"attributes,omitempty"`
+	// Remote reports that the device is attached over the network through USB/IP
+	Remote bool `json:"remote,omitempty"`
+	// RemoteHost is the host exporting a remote device, when the platform records it
+	RemoteHost string `json:"remote_host,omitempty"`
+	// Mode tells whether the device runs its application or its bootloader, when known.
+	// Devices in a bootloader without a serial port, reported with WithBootloaders, have
+	// an empty Port.
+	Mode Mode `json:"mode,omitempty"`
+	// Openable reports that the port could be opened when it was listed; it is only
+	// checked with WithVerifyOpen
+	Openable bool `json:"openable,omitempty"`
+}
+
+// setAttribute records a raw backend property in Attributes, unless its value is empty
+func (d *SerialDeviceInfo) setAttribute(name, value string) {
+	if value == "" {
+		return
+	}
+	if d.Attributes == nil {
+		d.Attributes = make(map[string]string)
+	}
+	d.Attributes[name] = value
+}
+
+// GetSerialDevices retrieves the USB serial devices matching the VID and PID. Empty values
+// match any device.
+func GetSerialDevices(vid, pid string, opts ...Option) ([]SerialDeviceInfo, error) {
+	return NewFinder(opts...).List(context.Background(), Filter{Vid: vid, Pid: pid})
 }
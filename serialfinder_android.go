@@ -0,0 +1,10 @@
+//go:build android
+// +build android
+
+package serialfinder
+
+// defaultBackend returns the backend used when no other backend is selected. Android has
+// no udev and therefore no `/dev/serial/by-id`, so sysfs is walked directly.
+func defaultBackend() backend {
+	return ttyClassBackend{}
+}
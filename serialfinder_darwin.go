@@ -4,166 +4,120 @@
 package serialfinder
 
 import (
-	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os/exec"
-	"regexp"
-	"strconv"
-	"strings"
 )
 
-// GetSerialDevices retrieves USB serial devices on macOS by querying the I/O Registry,
+// ioregBackend finds devices by parsing the output of the ioreg tool
+type ioregBackend struct{}
+
+// defaultBackend returns the backend used when no other backend is selected
+func defaultBackend() backend {
+	return ioregBackend{}
+}
+
+// list retrieves USB serial devices on macOS by querying the I/O Registry,
 // filtering by VID and PID, and finding the corresponding device path.
-func GetSerialDevices(vid, pid string) ([]SerialDeviceInfo, error) {
-	var devices []SerialDeviceInfo
+func (ioregBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	if o.ioregXML {
+		return listIoregXML(ctx, f, o)
+	}
 
 	// Use ioreg to get device information in a parseable format
 	// -c IOSerialBSDClient: Focus on serial port client drivers
-	// -r: Recursive search up the device tree to find parent USB devices
+	// -r: Print the subtree rooted at each client rather than the whole registry
+	// -t: Print the path from the root to each client, through the parent USB device
 	// -l: Show properties for each device
-	cmd := exec.Command("ioreg", "-r", "-c", "IOSerialBSDClient", "-l")
+	cmd := exec.CommandContext(ctx, "ioreg", "-r", "-t", "-c", "IOSerialBSDClient", "-l")
 	var out bytes.Buffer
 	cmd.Stdout = &out
-	err := cmd.Run()
+	err := runCommand(ctx, cmd, o.toolTimeout())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	var timeout *CommandTimeoutError
+	if errors.As(err, &timeout) {
+		return nil, err
+	}
 	if err != nil {
+		// ioreg is missing or broken; system_profiler reports the same devices
+		if devices, spErr := (systemProfilerBackend{}).list(ctx, f, o); spErr == nil {
+			return devices, nil
+		}
+
 		// Handle case where ioreg might fail or return non-zero if no devices found
 		// Check stderr? For now, assume error means failure or no devices.
 		// An empty output might just mean no serial devices connected.
 		if out.Len() == 0 {
 			// No output probably means no serial devices, not necessarily an error
-			return devices, nil
+			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to run ioreg: %v, output: %s", err, out.String())
+		// ioreg exits with an error when a device resets while it walks the registry
+		return nil, &transientError{fmt.Errorf("failed to run ioreg: %w, output: %s", err, out.String())}
 	}
 
-	// Prepare VID/PID for case-insensitive comparison
-	targetVidUpper := strings.ToUpper(vid)
-	targetPidUpper := strings.ToUpper(pid)
-
-	scanner := bufio.NewScanner(&out)
-	var currentDevice *SerialDeviceInfo
-	var inUSBDeviceBlock bool // Flag to track if we are inside a relevant USB device entry
-
-	// Regex to extract key-value pairs like "key" = value
-	// Handles strings ("value"), numbers (123), hex numbers (0x123)
-	reKeyValue := regexp.MustCompile(`"([^"]+)"\s*=\s*(.*)`)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Check if we are entering a new device potentially containing USB info
-		// Reset state if we leave an indented block associated with a potential USB parent
-		// This parsing logic is simplified; a full tree parser would be more robust.
-		// We primarily look for IOUSBHostDevice or IOUSBDevice containing VID/PID/Serial,
-		// and then find the child IOSerialBSDClient for the port.
-		if strings.Contains(line, "<class IOUSB") { // IOUSBHostDevice or IOUSBDevice
-			inUSBDeviceBlock = true
-			// Prepare a potential device structure, but don't add it yet
-			currentDevice = &SerialDeviceInfo{}
-		} else if !strings.HasPrefix(strings.TrimSpace(line), "|") && !strings.HasPrefix(strings.TrimSpace(line), "+-o") && !strings.HasPrefix(strings.TrimSpace(line), "{") && !strings.HasPrefix(strings.TrimSpace(line), "}") {
-			// If indentation level decreases significantly or line structure changes, assume we left the block
-			if !strings.Contains(line, "=") { // Heuristic: Lines without '=' are less likely part of the property block
-				inUSBDeviceBlock = false
-				currentDevice = nil // Reset current device context
-			}
-		}
+	devices, clients, parsed, err := parseIoregText(out.Bytes(), f, o)
+	if err != nil {
+		return nil, err
+	}
 
-		if currentDevice != nil {
-			match := reKeyValue.FindStringSubmatch(strings.TrimSpace(line))
-			if len(match) == 3 {
-				key := match[1]
-				value := strings.TrimSpace(match[2])
-
-				// Extract VID, PID, SerialNumber from the USB device block
-				if inUSBDeviceBlock {
-					switch key {
-					case "idVendor":
-						hexVal, err := parseHexValue(value)
-						if err == nil {
-							currentDevice.Vid = fmt.Sprintf("%04X", hexVal)
-						}
-					case "idProduct":
-						hexVal, err := parseHexValue(value)
-						if err == nil {
-							currentDevice.Pid = fmt.Sprintf("%04X", hexVal)
-						}
-					case "USB Serial Number": // Note: Key name can vary slightly (sometimes kUSBSerialNumberString)
-						currentDevice.SerialNumber = parseStringValue(value)
-					case "kUSBSerialNumberString": // Alternative key name
-						if currentDevice.SerialNumber == "" { // Prefer "USB Serial Number" if available
-							currentDevice.SerialNumber = parseStringValue(value)
-						}
-					}
-				}
-
-				// Extract Port from the IOSerialBSDClient block (which is a child)
-				if key == "IOCalloutDevice" {
-					// This property belongs to the IOSerialBSDClient, which should be listed *after*
-					// its parent USB device properties in the `ioreg -r` output.
-					portPath := parseStringValue(value)
-					if portPath != "" && currentDevice.Vid != "" && currentDevice.Pid != "" {
-						currentDevice.Port = portPath
-
-						// Check if VID/PID match the filter (if provided)
-						vidMatch := (targetVidUpper == "" || currentDevice.Vid == targetVidUpper)
-						pidMatch := (targetPidUpper == "" || currentDevice.Pid == targetPidUpper)
-
-						if vidMatch && pidMatch {
-							// Found a matching device, add a copy to the list
-							devices = append(devices, *currentDevice)
-						}
-						// Reset for the next potential device block found by ioreg
-						// Since IOCalloutDevice is usually the last relevant piece, reset here.
-						currentDevice = nil
-						inUSBDeviceBlock = false
-					}
-				}
-			}
-		}
+	// Serial clients printed without their path from the root mean the output format is not
+	// the one the parser expects, as from an ioreg that ignores -t
+	if clients > 0 && parsed == 0 {
+		return systemProfilerBackend{}.list(ctx, f, o)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error scanning ioreg output: %v", err)
+	if o.checkInUse {
+		markInUse(ctx, devices, o)
 	}
 
 	return devices, nil
 }
 
-// parseHexValue converts ioreg number values (like 0x1234 or 1234) to int64
-func parseHexValue(value string) (int64, error) {
-	value = strings.TrimSpace(value)
-	// Remove trailing comma if present (sometimes happens in ioreg output)
-	value = strings.TrimSuffix(value, ",")
+// listIoregXML retrieves USB serial devices from the XML output of ioreg. USB devices are
+// listed with their whole subtree, so each serial client is found below its own device.
+func listIoregXML(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
 
-	// Check if it's already a decimal number
-	decVal, errDec := strconv.ParseInt(value, 10, 64)
-	if errDec == nil {
-		return decVal, nil
-	}
+	// IOUSBHostDevice is the USB device class since OS X 10.11; older releases use IOUSBDevice
+	for _, class := range []string{"IOUSBHostDevice", "IOUSBDevice"} {
+		cmd := exec.CommandContext(ctx, "ioreg", "-a", "-r", "-c", class, "-l")
+		var out bytes.Buffer
+		cmd.Stdout = &out
+		err := runCommand(ctx, cmd, o.toolTimeout())
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		var timeout *CommandTimeoutError
+		if errors.As(err, &timeout) {
+			return nil, err
+		}
+		// ioreg prints nothing when no object of the class exists
+		if err != nil || len(bytes.TrimSpace(out.Bytes())) == 0 {
+			continue
+		}
 
-	// Try parsing as hex (ioreg usually uses 0x prefix, but let's be flexible)
-	if strings.HasPrefix(value, "0x") {
-		return strconv.ParseInt(value[2:], 16, 64)
-	}
-	// Fallback attempt if no prefix but maybe hex? Unlikely needed for VID/PID.
-	hexVal, errHex := strconv.ParseInt(value, 16, 64)
-	if errHex == nil {
-		return hexVal, nil
+		found, err := parseIoregXML(out.Bytes(), f, o.preferDialin)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, found...)
+		if len(found) > 0 {
+			break
+		}
 	}
 
-	// Return the original decimal error if hex also failed
-	return 0, errDec
-}
+	devices, err := appendNonUSB(ctx, f, o, devices)
+	if err != nil {
+		return nil, err
+	}
 
-// parseStringValue extracts string values like "My String" -> My String
-func parseStringValue(value string) string {
-	value = strings.TrimSpace(value)
-	// Remove trailing comma if present
-	value = strings.TrimSuffix(value, ",")
-	// Remove surrounding quotes
-	if strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
-		return value[1 : len(value)-1]
+	if o.checkInUse {
+		markInUse(ctx, devices, o)
 	}
-	return value // Return as-is if not quoted
+
+	return devices, nil
 }
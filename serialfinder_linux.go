@@ -3,119 +3,49 @@
 
 package serialfinder
 
-import (
-	"fmt"
-	"os"
-	"path/filepath"
-	"strings"
-)
+import "context"
 
-// GetSerialDevices retrieves USB devices on Linux by searching the `/dev/serial/by-id` directory, filtering by VID and PID, and finding the corresponding port
-func GetSerialDevices(vid, pid string) ([]SerialDeviceInfo, error) {
-	var devices []SerialDeviceInfo
+// byIDBackend finds devices through the udev-maintained `/dev/serial/by-id` links
+type byIDBackend struct{}
 
-	// Path to the serial devices by ID directory
-	serialByIDPath := "/dev/serial/by-id"
-
-	// Read all the symlinks in the directory
-	entries, err := os.ReadDir(serialByIDPath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Iterate over each entry in the directory
-	for _, entry := range entries {
-		if entry.IsDir() {
-			continue
-		}
-
-		// Full path to the symbolic link
-		symlinkPath := filepath.Join(serialByIDPath, entry.Name())
-
-		// Resolve the symbolic link to get the actual device path
-		devicePath, err := filepath.EvalSymlinks(symlinkPath)
-		if err != nil {
-			continue
-		}
-
-		// Find the USB device directory associated with this tty device
-		usbDir := findSerialDeviceInfoDir(devicePath)
-		if usbDir == "" {
-			continue
-		}
-
-		// Read the VID and PID
-		idVendor, err := os.ReadFile(filepath.Join(usbDir, "idVendor"))
-		if err != nil {
-			fmt.Printf("Error reading idVendor: %v\n", err)
-			continue
-		}
-
-		idProduct, err := os.ReadFile(filepath.Join(usbDir, "idProduct"))
-		if err != nil {
-			fmt.Printf("Error reading idProduct: %v\n", err)
-			continue
-		}
-
-		// Log the VID and PID for debugging
-		vidStr := strings.ToUpper(strings.TrimSpace(string(idVendor)))
-		pidStr := strings.ToUpper(strings.TrimSpace(string(idProduct)))
-
-		// Check if the VID and PID match the specified values
-		if vidStr != "" && vidStr != vid {
-			continue
-		}
-		if pidStr != "" && pidStr != pid {
-			continue
-		}
-
-		// Read the serial number
-		serialNumber, err := os.ReadFile(filepath.Join(usbDir, "serial"))
-		if err != nil {
-			fmt.Printf("Error reading serial: %v\n", err)
-			serialNumber = []byte("")
-		}
-
-		// Add the device to the list
-		devices = append(devices, SerialDeviceInfo{
-			SerialNumber: strings.TrimSpace(string(serialNumber)),
-			Vid:          vidStr,
-			Pid:          pidStr,
-			Port:         symlinkPath,
-		})
-	}
+// list retrieves USB devices on Linux by searching the `/dev/serial/by-id` directory, filtering by VID and PID, and finding the corresponding port
+func (byIDBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	return hostSysfsScan(true).list(ctx, f, o)
+}
 
-	return devices, nil
+// lookupPort reads the device behind a port and its by-id link from sysfs
+func (byIDBackend) lookupPort(ctx context.Context, port string, o options) (SerialDeviceInfo, bool, error) {
+	return lookupHostSysfsPort(ctx, port, true, o)
 }
 
-// findSerialDeviceInfoDir returns the directory path of the USB device corresponding to the device path
-func findSerialDeviceInfoDir(devicePath string) string {
-	// Get the full path to the tty device in /sys/class/tty
-	sysTTYPath := filepath.Join("/sys/class/tty", filepath.Base(devicePath), "device")
+// hostSysfsScan scans the sysfs of the running system, checking the ports held open and the
+// devices missing under WSL
+func hostSysfsScan(byID bool) sysfsScan {
+	return sysfsScan{sys: hostSysfs{}, byID: byID, inUse: portsInUse, check: checkWSLDevices}
+}
 
-	// Follow the symlink to the actual device directory
-	usbDir, err := filepath.EvalSymlinks(sysTTYPath)
-	if err != nil {
-		return ""
+// lookupHostSysfsPort describes one port of the running system with lookupSysfsPort,
+// checking whether it is in use if asked. Ports named after their by-path links are left
+// to a full scan.
+func lookupHostSysfsPort(ctx context.Context, port string, byID bool, o options) (SerialDeviceInfo, bool, error) {
+	if o.preferByPath {
+		return SerialDeviceInfo{}, false, nil
 	}
-
-	// Navigate up one or two directories to find the actual USB device directory
-	parentDir := filepath.Dir(usbDir)
-	if checkForVIDPIDFiles(parentDir) {
-		return parentDir
+	device, node, ok, err := lookupSysfsPort(hostSysfs{}, port, byID)
+	if err != nil || !ok {
+		return SerialDeviceInfo{}, false, err
 	}
-
-	grandparentDir := filepath.Dir(parentDir)
-	if checkForVIDPIDFiles(grandparentDir) {
-		return grandparentDir
+	devices, err := hostSysfsScan(byID).finish(ctx, []SerialDeviceInfo{device}, []string{node}, o)
+	if err != nil {
+		return SerialDeviceInfo{}, false, err
 	}
-
-	return ""
+	return devices[0], true, nil
 }
 
-// checkForVIDPIDFiles checks if the directory contains idVendor and idProduct files
-func checkForVIDPIDFiles(dir string) bool {
-	_, errVid := os.Stat(filepath.Join(dir, "idVendor"))
-	_, errPid := os.Stat(filepath.Join(dir, "idProduct"))
-	return errVid == nil && errPid == nil
+// addExtendedInfo reads the extended attributes of the devices, whose nodes are given, from
+// sysfs if WithExtendedInfo or WithDriver asks for them
+func addExtendedInfo(devices []SerialDeviceInfo, nodes []string, o options) {
+	if o.wantExtended() {
+		readSysfsExtendedInfo(hostSysfs{}, devices, nodes)
+	}
 }
@@ -4,91 +4,85 @@
 package serialfinder
 
 import (
+	"context"
 	"fmt"
-	"strings"
 	"syscall"
+	"time"
 
 	"golang.org/x/sys/windows/registry"
 )
 
-// GetSerialDevices retrieves USB devices on Windows, filtering by VID and PID, and finds the corresponding COM port
-func GetSerialDevices(vid, pid string) ([]SerialDeviceInfo, error) {
-	var devices []SerialDeviceInfo
+// registryAccess is the access mask used for every registry key opened during enumeration.
+// The 64-bit view is requested explicitly so 32-bit builds running under WOW64 see the same keys.
+const registryAccess = registry.READ | registry.WOW64_64KEY
 
-	// Open the registry key for USB devices
-	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Enum\USB`, registry.READ)
-	if err != nil {
-		return nil, err
-	}
-	defer key.Close()
+// registryBackend finds devices by walking the USB enumeration tree in the registry
+type registryBackend struct{}
 
-	// Read the list of subkeys (device IDs)
-	deviceIDs, err := key.ReadSubKeyNames(-1)
-	if err != nil {
-		return nil, err
-	}
+// defaultBackend returns the backend used when no other backend is selected
+func defaultBackend() backend {
+	return registryBackend{}
+}
 
-	// Iterate over each device ID
-	for _, deviceID := range deviceIDs {
-		// Check if the deviceID contains the specified VID and PID
-		if strings.Contains(deviceID, fmt.Sprintf("VID_%s&PID_%s", vid, pid)) {
-			deviceKey, err := registry.OpenKey(key, deviceID, registry.READ)
-			if err != nil {
-				continue
-			}
-			defer deviceKey.Close()
-
-			// Read the list of subkeys under each device ID (which usually include serial numbers)
-			serials, err := deviceKey.ReadSubKeyNames(-1)
-			if err != nil {
-				continue
-			}
-
-			// Iterate over each serial number
-			for _, serial := range serials {
-				device := iterateSerialsWindows(serial, deviceID, key)
-				if device != (SerialDeviceInfo{}) { // Append only if the device is active
-					devices = append(devices, device)
-				}
-			}
-		}
-	}
+// list retrieves USB devices on Windows, filtering by VID and PID, and finds the corresponding COM port
+func (registryBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	reg := &hostRegistry{}
+	defer reg.Close()
+	scan := registryScan{reg: reg, nodes: hostDevNodes{}, probe: checkCOMPortActiveWindows}
+	return scan.list(ctx, f, o)
+}
 
-	return devices, nil
+// hostRegistry reads the registry of the running system
+type hostRegistry = cachedRegistry[registry.Key, windowsRegistry]
+
+// windowsRegistry is the registry API of Windows
+type windowsRegistry struct{}
+
+func (windowsRegistry) root() registry.Key {
+	return registry.LOCAL_MACHINE
 }
 
-// Helper function to iterate over serials and get the corresponding COM ports on Windows.
-func iterateSerialsWindows(serial, deviceID string, key registry.Key) SerialDeviceInfo {
-	// Open the `Device Parameters` key to find the COM port
-	deviceParamsKeyPath := fmt.Sprintf(`%s\%s\Device Parameters`, deviceID, serial)
-	deviceParamsKey, err := registry.OpenKey(key, deviceParamsKeyPath, registry.READ)
-	if err != nil {
-		return SerialDeviceInfo{}
-	}
-	defer deviceParamsKey.Close()
+func (windowsRegistry) openKey(parent registry.Key, path string) (registry.Key, error) {
+	return registry.OpenKey(parent, path, registryAccess)
+}
 
-	// Read the `PortName` value, which should contain the COM port
-	portName, _, err := deviceParamsKey.GetStringValue("PortName")
-	if err != nil {
-		return SerialDeviceInfo{}
-	}
+func (windowsRegistry) closeKey(key registry.Key) {
+	key.Close()
+}
 
-	// Check if the COM port can be opened to determine if the device is active
-	isActive := checkCOMPortActiveWindows(portName)
-	if !isActive {
-		return SerialDeviceInfo{}
-	}
+func (windowsRegistry) subKeyNames(key registry.Key) ([]string, error) {
+	return key.ReadSubKeyNames(-1)
+}
 
-	return SerialDeviceInfo{
-		SerialNumber: serial,
-		Vid:          strings.Split(deviceID, "&")[0][4:],
-		Pid:          strings.Split(deviceID, "&")[1][4:],
-		Port:         portName,
-	}
+func (windowsRegistry) valueNames(key registry.Key) ([]string, error) {
+	return key.ReadValueNames(-1)
 }
 
-// checkCOMPortActiveWindows tries to open the COM port to check if it is active on Windows
-func checkCOMPortActiveWindows(portName string) bool {
+func (windowsRegistry) stringValue(key registry.Key, name string) (string, error) {
+	value, _, err := key.GetStringValue(name)
+	return value, err
+}
+
+// hostDevNodes asks the configuration manager of the running system
+type hostDevNodes struct{}
+
+func (hostDevNodes) Located(instanceID string) bool {
+	_, err := locateDevNodeWindows(instanceID)
+	return err == nil
+}
+
+func (hostDevNodes) Present(instanceID string) (bool, error) {
+	return devNodePresentWindows(instanceID)
+}
+
+func (hostDevNodes) Arrival(instanceID string) time.Time  { return arrivalTimeWindows(instanceID) }
+func (hostDevNodes) Topology(instanceID string) *Topology { return topologyWindows(instanceID) }
+func (hostDevNodes) Power(instanceID string) *PowerInfo   { return powerWindows(instanceID) }
+
+// checkCOMPortActiveWindows tries to open the COM port to check if it is active on Windows.
+// COM ports are opened exclusively, so a port held by another process fails with
+// ERROR_ACCESS_DENIED: it is active, but in use.
+func checkCOMPortActiveWindows(portName string) (active, inUse bool) {
 	comPort := fmt.Sprintf("\\\\.\\%s", portName)
 	handle, err := syscall.CreateFile(
 		syscall.StringToUTF16Ptr(comPort),
@@ -99,10 +93,13 @@ func checkCOMPortActiveWindows(portName string) bool {
 		0,
 		0,
 	)
+	if err == syscall.ERROR_ACCESS_DENIED {
+		return true, true
+	}
 	if err != nil {
-		return false
+		return false, false
 	}
 	defer syscall.CloseHandle(handle)
 
-	return true
+	return true, false
 }
@@ -0,0 +1,5 @@
+// Package serialfinderpb holds the protobuf messages and gRPC stubs of the serialfinder
+// service. The grpcfinder package serves a Finder with them and implements a client.
+package serialfinderpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative serialfinder.proto
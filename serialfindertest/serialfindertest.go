@@ -0,0 +1,130 @@
+// Package serialfindertest provides a conformance suite that every serialfinder backend
+// must pass, so all platforms behave identically at the API level.
+//
+// A backend author writes an Installer that turns a System description into the raw input
+// the backend reads (a sysfs tree, ioreg output, registry contents, ...) and calls Run
+// from a test.
+package serialfindertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"testing"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// System describes the state of the machine a backend is asked to scan
+type System struct {
+	// Devices are the serial devices attached to the machine
+	Devices []serialfinder.SerialDeviceInfo
+	// PermissionDenied makes the device source unreadable for the current user
+	PermissionDenied bool
+}
+
+// Lister lists the devices of an installed System
+type Lister func(ctx context.Context, filter serialfinder.Filter) ([]serialfinder.SerialDeviceInfo, error)
+
+// Installer prepares the backend under test to scan the given system
+type Installer func(t *testing.T, sys System) Lister
+
+// Case is one entry of the shared behavior table
+type Case struct {
+	Name   string
+	System System
+	Filter serialfinder.Filter
+	// Canceled runs the scan with an already canceled context
+	Canceled bool
+	// Want are the expected devices, in any order
+	Want []serialfinder.SerialDeviceInfo
+	// WantErr is matched with errors.Is when set
+	WantErr error
+}
+
+var (
+	ftdi     = serialfinder.SerialDeviceInfo{SerialNumber: "A50285BI", Vid: "0403", Pid: "6001", Port: "port-ftdi"}
+	ch340    = serialfinder.SerialDeviceInfo{SerialNumber: "5735001923", Vid: "1A86", Pid: "55D4", Port: "port-ch340"}
+	noSerial = serialfinder.SerialDeviceInfo{Vid: "1A86", Pid: "7523", Port: "port-clone"}
+)
+
+// Cases returns the behaviors every backend must implement
+func Cases() []Case {
+	all := []serialfinder.SerialDeviceInfo{ftdi, ch340, noSerial}
+	return []Case{
+		{Name: "empty system", System: System{}},
+		{Name: "empty filter matches all", System: System{Devices: all}, Want: all},
+		{Name: "vid filter", System: System{Devices: all}, Filter: serialfinder.Filter{Vid: "1A86"}, Want: []serialfinder.SerialDeviceInfo{ch340, noSerial}},
+		{Name: "vid and pid filter", System: System{Devices: all}, Filter: serialfinder.Filter{Vid: "0403", Pid: "6001"}, Want: []serialfinder.SerialDeviceInfo{ftdi}},
+		{Name: "filter is case-insensitive", System: System{Devices: all}, Filter: serialfinder.Filter{Vid: "1a86", Pid: "55d4"}, Want: []serialfinder.SerialDeviceInfo{ch340}},
+		{Name: "pid pattern", System: System{Devices: all}, Filter: serialfinder.Filter{Vid: "1A86", Pid: "55??"}, Want: []serialfinder.SerialDeviceInfo{ch340}},
+		{Name: "pid range", System: System{Devices: all}, Filter: serialfinder.Filter{Pid: "6000-7600"}, Want: []serialfinder.SerialDeviceInfo{ftdi, noSerial}},
+		{Name: "no match", System: System{Devices: all}, Filter: serialfinder.Filter{Vid: "FFFF"}},
+		{Name: "missing serial", System: System{Devices: []serialfinder.SerialDeviceInfo{noSerial}}, Want: []serialfinder.SerialDeviceInfo{noSerial}},
+		{Name: "permission denied", System: System{Devices: all, PermissionDenied: true}, WantErr: fs.ErrPermission},
+		{Name: "canceled", System: System{Devices: all}, Canceled: true, WantErr: context.Canceled},
+	}
+}
+
+// Run checks the backend installed by install against every case of Cases
+func Run(t *testing.T, install Installer) {
+	for _, c := range Cases() {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			list := install(t, c.System)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if c.Canceled {
+				cancel()
+			}
+
+			got, err := list(ctx, c.Filter)
+			if c.WantErr != nil {
+				if !errors.Is(err, c.WantErr) {
+					t.Fatalf("got error %v, want %v", err, c.WantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := Compare(got, c.Want); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// Compare reports a difference between the devices a backend returned and the expected ones.
+// Devices are compared by port, VID, PID and serial number, ignoring order and any extra
+// attributes a backend may fill in.
+func Compare(got, want []serialfinder.SerialDeviceInfo) error {
+	g, w := coreFields(got), coreFields(want)
+	if len(g) != len(w) {
+		return fmt.Errorf("got %d devices %v, want %d devices %v", len(g), g, len(w), w)
+	}
+	for i := range g {
+		if g[i] != w[i] {
+			return fmt.Errorf("got device %+v, want %+v", g[i], w[i])
+		}
+	}
+	return nil
+}
+
+// core holds the attributes every backend reports
+type core struct {
+	Port, Vid, Pid, SerialNumber string
+}
+
+// coreFields strips the devices to their core attributes and sorts them by port
+func coreFields(devices []serialfinder.SerialDeviceInfo) []core {
+	result := make([]core, 0, len(devices))
+	for _, d := range devices {
+		result = append(result, core{Port: d.Port, Vid: d.Vid, Pid: d.Pid, SerialNumber: d.SerialNumber})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Port < result[j].Port })
+	return result
+}
@@ -0,0 +1,27 @@
+//go:build serialopen
+// +build serialopen
+
+package serialopen
+
+import (
+	"errors"
+
+	"go.bug.st/serial"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// ErrNoPort is returned for devices without a port, such as boards in a bootloader
+var ErrNoPort = errors.New("serialopen: device has no port")
+
+// Open opens the port of the device at Path with the given mode. A nil mode opens it at
+// 9600 baud, 8N1.
+func Open(device serialfinder.SerialDeviceInfo, mode *serial.Mode) (serial.Port, error) {
+	if device.Port == "" {
+		return nil, ErrNoPort
+	}
+	if mode == nil {
+		mode = &serial.Mode{BaudRate: 9600, DataBits: 8}
+	}
+	return serial.Open(Path(device), mode)
+}
@@ -0,0 +1,34 @@
+// Package serialopen opens the ports serialfinder finds with go.bug.st/serial.
+//
+// Open is only built with the serialopen build tag, so the serialfinder module does not
+// depend on go.bug.st/serial unless asked to:
+//
+//	go get go.bug.st/serial
+//	go build -tags serialopen
+//
+// Path is always available, for code that opens ports some other way.
+package serialopen
+
+import (
+	"strings"
+
+	"github.com/hs0zip/serialfinder"
+)
+
+// Path returns the path to open for the device. On macOS it is the callout node
+// (/dev/cu.*), which opens without waiting for carrier, even when the device was listed
+// with WithPreferDialin. On Windows it is the device namespace path (\\.\COM12), the only
+// form that works for ports above COM9. Other ports are returned as listed.
+func Path(device serialfinder.SerialDeviceInfo) string {
+	for _, port := range []string{device.Port, device.DialinPort} {
+		if strings.HasPrefix(port, "/dev/cu.") {
+			return port
+		}
+	}
+
+	port := device.Port
+	if len(port) > 3 && strings.EqualFold(port[:3], "com") {
+		return `\\.\` + port
+	}
+	return port
+}
@@ -0,0 +1,96 @@
+package serialfinder
+
+import "sort"
+
+// DeviceSet is a set of devices keyed by their StableID
+type DeviceSet map[DeviceID]SerialDeviceInfo
+
+// NewDeviceSet returns a set holding the given devices. Later duplicates replace earlier ones.
+func NewDeviceSet(devices ...SerialDeviceInfo) DeviceSet {
+	s := make(DeviceSet, len(devices))
+	for _, device := range devices {
+		s.Add(device)
+	}
+	return s
+}
+
+// Add inserts the device, replacing any device with the same ID
+func (s DeviceSet) Add(device SerialDeviceInfo) {
+	s[device.StableID()] = device
+}
+
+// Remove deletes the device with the given ID
+func (s DeviceSet) Remove(id DeviceID) {
+	delete(s, id)
+}
+
+// Contains reports whether a device with the given ID is in the set
+func (s DeviceSet) Contains(id DeviceID) bool {
+	_, ok := s[id]
+	return ok
+}
+
+// Get returns the device with the given ID
+func (s DeviceSet) Get(id DeviceID) (SerialDeviceInfo, bool) {
+	device, ok := s[id]
+	return device, ok
+}
+
+// Len returns the number of devices in the set
+func (s DeviceSet) Len() int {
+	return len(s)
+}
+
+// IDs returns the IDs in the set in sorted order
+func (s DeviceSet) IDs() []DeviceID {
+	ids := make([]DeviceID, 0, len(s))
+	for id := range s {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Devices returns the devices in the set, ordered by ID so the result is stable between calls
+func (s DeviceSet) Devices() []SerialDeviceInfo {
+	devices := make([]SerialDeviceInfo, 0, len(s))
+	for _, id := range s.IDs() {
+		devices = append(devices, s[id])
+	}
+	return devices
+}
+
+// Union returns a new set with the devices of both sets. Devices in other take precedence,
+// so the union of an old and a new snapshot carries the newest attributes.
+func (s DeviceSet) Union(other DeviceSet) DeviceSet {
+	result := make(DeviceSet, len(s)+len(other))
+	for id, device := range s {
+		result[id] = device
+	}
+	for id, device := range other {
+		result[id] = device
+	}
+	return result
+}
+
+// Intersect returns a new set with the devices of s whose ID is also in other
+func (s DeviceSet) Intersect(other DeviceSet) DeviceSet {
+	result := make(DeviceSet)
+	for id, device := range s {
+		if other.Contains(id) {
+			result[id] = device
+		}
+	}
+	return result
+}
+
+// Difference returns a new set with the devices of s whose ID is not in other
+func (s DeviceSet) Difference(other DeviceSet) DeviceSet {
+	result := make(DeviceSet)
+	for id, device := range s {
+		if !other.Contains(id) {
+			result[id] = device
+		}
+	}
+	return result
+}
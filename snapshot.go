@@ -0,0 +1,76 @@
+package serialfinder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot format written by SaveSnapshot.
+// LoadSnapshot reads this version and every earlier one, as well as the bare JSON array of
+// devices printed by `serialfinder list -json`, which it reports as version 0.
+const SnapshotVersion = 1
+
+// ErrInvalidSnapshot is returned by LoadSnapshot for data that is not a snapshot, or one
+// written by a newer version
+var ErrInvalidSnapshot = errors.New("serialfinder: not a snapshot of a supported version")
+
+// Snapshot is a device inventory as stored by SaveSnapshot, along with where and when it
+// was taken
+type Snapshot struct {
+	Version  int                `json:"version"`
+	Taken    time.Time          `json:"taken"`
+	Hostname string             `json:"hostname,omitempty"`
+	OS       string             `json:"os"`
+	Devices  []SerialDeviceInfo `json:"devices"`
+}
+
+// SaveSnapshot writes the devices to w as a versioned JSON document stamped with the
+// current time, host name and operating system, so inventories can be stored, attached
+// to bug reports and compared later with LoadSnapshot and Diff
+func SaveSnapshot(w io.Writer, devices []SerialDeviceInfo) error {
+	hostname, _ := os.Hostname()
+	snapshot := Snapshot{
+		Version:  SnapshotVersion,
+		Taken:    time.Now().UTC(),
+		Hostname: hostname,
+		OS:       runtime.GOOS,
+		Devices:  devices,
+	}
+	if snapshot.Devices == nil {
+		snapshot.Devices = []SerialDeviceInfo{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot. It returns an error wrapping
+// ErrInvalidSnapshot if the data has no version or a version newer than SnapshotVersion.
+func LoadSnapshot(r io.Reader) (*Snapshot, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+
+	var snapshot Snapshot
+	if bytes.HasPrefix(raw, []byte("[")) {
+		if err := json.Unmarshal(raw, &snapshot.Devices); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+		}
+		return &snapshot, nil
+	}
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if snapshot.Version < 1 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("%w: version %d", ErrInvalidSnapshot, snapshot.Version)
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,95 @@
+package serialfinder
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	devices := []SerialDeviceInfo{
+		{
+			Port:         "/dev/serial/by-id/usb-FTDI_FT232R_USB_UART_A50285BI-if00-port0",
+			Vid:          "0403",
+			Pid:          "6001",
+			SerialNumber: "A50285BI",
+			Transport:    TransportUSB,
+			ConnectedAt:  time.Date(2026, 3, 14, 9, 26, 53, 0, time.UTC),
+			InUse:        true,
+			ByPath:       "/dev/serial/by-path/pci-0000:00:14.0-usb-0:2:1.0-port0",
+			Location:     "1-2",
+			Siblings:     []string{"/dev/ttyUSB1"},
+			Interface:    "00",
+			Topology:     &Topology{Bus: 1, Ports: []int{2}},
+			Power:        &PowerInfo{MaxPowerMA: 90, RuntimeStatus: "active"},
+			Manufacturer: "FTDI",
+			Product:      "FT232R USB UART",
+			Driver:       "ftdi_sio",
+			Attributes:   map[string]string{"bcdDevice": "0600"},
+		},
+		{Port: "/dev/ttyS0", Transport: TransportPlatform, Description: "16550A"},
+	}
+
+	var buf bytes.Buffer
+	if err := SaveSnapshot(&buf, devices); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := LoadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Version != SnapshotVersion || snapshot.OS != runtime.GOOS || snapshot.Taken.IsZero() {
+		t.Errorf("snapshot is version %d from %q taken %v", snapshot.Version, snapshot.OS, snapshot.Taken)
+	}
+	if !reflect.DeepEqual(snapshot.Devices, devices) {
+		t.Errorf("loaded devices\n%+v\nwant\n%+v", snapshot.Devices, devices)
+	}
+
+	// No devices are saved as an empty list and loaded as one
+	buf.Reset()
+	if err := SaveSnapshot(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"devices": []`) {
+		t.Errorf("empty snapshot is %s", buf.String())
+	}
+	if snapshot, err := LoadSnapshot(&buf); err != nil || len(snapshot.Devices) != 0 {
+		t.Errorf("empty snapshot loaded as %+v, %v", snapshot, err)
+	}
+}
+
+func TestLoadSnapshotListOutput(t *testing.T) {
+	// The output of `serialfinder list -json`, a bare array, is version 0
+	snapshot, err := LoadSnapshot(strings.NewReader(`[{"port": "COM3", "vid": "0403", "pid": "6001"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Version != 0 || len(snapshot.Devices) != 1 || snapshot.Devices[0].Port != "COM3" {
+		t.Errorf("list output loaded as %+v", snapshot)
+	}
+}
+
+func TestLoadSnapshotInvalid(t *testing.T) {
+	for _, tc := range []struct{ name, data string }{
+		{"no version", `{"os": "linux", "devices": []}`},
+		{"version 0", `{"version": 0, "devices": []}`},
+		{"negative version", `{"version": -1, "devices": []}`},
+		{"newer version", `{"version": 2, "devices": []}`},
+		{"version as a string", `{"version": "1", "devices": []}`},
+		{"empty", ``},
+		{"not JSON", `devices: []`},
+		{"truncated", `{"version": 1, "devices": [{"port": "COM3"`},
+		{"scalar", `42`},
+		{"devices not a list", `{"version": 1, "devices": {"port": "COM3"}}`},
+		{"list of strings", `["COM3"]`},
+	} {
+		_, err := LoadSnapshot(strings.NewReader(tc.data))
+		if !errors.Is(err, ErrInvalidSnapshot) {
+			t.Errorf("%s: LoadSnapshot = %v, want an error wrapping ErrInvalidSnapshot", tc.name, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package serialfinder
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+)
+
+// ComparePorts compares two port names in natural order, returning -1, 0 or +1. Runs of
+// digits are compared by their value, so COM2 sorts before COM10 and /dev/ttyUSB2 before
+// /dev/ttyUSB10, and letters are compared regardless of case, as Windows treats COM port
+// names. Names that only differ in case or leading zeros are ordered bytewise, so the
+// order is total.
+func ComparePorts(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the runs of digits without their leading zeros: a longer run is a
+			// larger number, and runs of the same length compare like strings
+			endA, endB := digitsEnd(a, i), digitsEnd(b, j)
+			numA, numB := trimZeros(a[i:endA]), trimZeros(b[j:endB])
+			if len(numA) != len(numB) {
+				return cmp.Compare(len(numA), len(numB))
+			}
+			if numA != numB {
+				return strings.Compare(numA, numB)
+			}
+			i, j = endA, endB
+			continue
+		}
+
+		ca, cb := lowerASCII(a[i]), lowerASCII(b[j])
+		if ca != cb {
+			return cmp.Compare(ca, cb)
+		}
+		i++
+		j++
+	}
+	if rest := cmp.Compare(len(a)-i, len(b)-j); rest != 0 {
+		return rest
+	}
+	return strings.Compare(a, b)
+}
+
+// SortByPort sorts the devices by port in the natural order of ComparePorts, keeping the
+// order of devices with the same port, such as bootloaders without one
+func SortByPort(devices []SerialDeviceInfo) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		return ComparePorts(devices[i].Port, devices[j].Port) < 0
+	})
+}
+
+// isDigit reports whether c is an ASCII digit
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// digitsEnd returns the end of the run of digits starting at i
+func digitsEnd(s string, i int) int {
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return i
+}
+
+// trimZeros removes the leading zeros of a run of digits, keeping one digit of "000"
+func trimZeros(digits string) string {
+	for len(digits) > 1 && digits[0] == '0' {
+		digits = digits[1:]
+	}
+	return digits
+}
+
+// lowerASCII lower-cases an ASCII letter
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
@@ -0,0 +1,58 @@
+package serialfinder
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestComparePorts(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"COM2", "COM10", -1},
+		{"COM10", "COM2", 1},
+		{"COM3", "COM3", 0},
+		{"/dev/ttyUSB2", "/dev/ttyUSB10", -1},
+		{"/dev/ttyUSB9", "/dev/ttyUSB10", -1},
+		{"/dev/ttyUSB10", "/dev/ttyUSB10", 0},
+		// Letters before the digits decide between prefixes
+		{"/dev/ttyACM10", "/dev/ttyUSB2", -1},
+		{"/dev/ttyS1", "/dev/ttyUSB0", -1},
+		{"/dev/ttyUSB1", "/dev/ttyS10", 1},
+		{"/dev/cu.usbmodem2101", "/dev/cu.usbserial-A10K4QJ7", -1},
+		// Case is ignored, then decides bytewise so the order is total
+		{"com2", "COM10", -1},
+		{"COM3", "com3", -1},
+		{"com3", "COM3", 1},
+		// Leading zeros do not change the value, then decide bytewise
+		{"COM02", "COM10", -1},
+		{"COM007", "COM7", -1},
+		{"COM0", "COM00", -1},
+		// Runs of digits inside the name are compared one by one
+		{"/dev/serial/by-path/pci-0000:00:14.0-usb-0:2:1.0", "/dev/serial/by-path/pci-0000:00:14.0-usb-0:10:1.0", -1},
+		{"/dev/ttyUSB1", "/dev/ttyUSB1a", -1},
+		{"", "COM1", -1},
+		{"", "", 0},
+		{"99999999999999999999", "100000000000000000000", -1},
+	} {
+		if got := ComparePorts(tc.a, tc.b); got != tc.want {
+			t.Errorf("ComparePorts(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+
+	ports := []string{"COM10", "/dev/ttyUSB10", "COM2", "/dev/ttyACM0", "/dev/ttyUSB2", "COM1", "/dev/ttyS0", "com3"}
+	want := []string{"/dev/ttyACM0", "/dev/ttyS0", "/dev/ttyUSB2", "/dev/ttyUSB10", "COM1", "COM2", "com3", "COM10"}
+	var devices []SerialDeviceInfo
+	for _, port := range ports {
+		devices = append(devices, SerialDeviceInfo{Port: port})
+	}
+	SortByPort(devices)
+	var got []string
+	for _, device := range devices {
+		got = append(got, device.Port)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("SortByPort ordered %q, want %q", got, want)
+	}
+}
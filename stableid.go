@@ -0,0 +1,38 @@
+package serialfinder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// DeviceID identifies a device independently of the port name it was assigned
+type DeviceID string
+
+// StableID returns an identifier for the device that survives reboots and re-enumeration,
+// so configuration can pin "this exact device" instead of a volatile COM or tty name.
+//
+// It is a hash of the VID, PID and serial number, which makes it identical across
+// platforms. Devices without a serial number fall back to their physical Location, which
+// stays the same while the device is plugged into the same USB port but differs between
+// platforms. Ports of a composite device are told apart by their interface number.
+func (d SerialDeviceInfo) StableID() DeviceID {
+	var key string
+	switch {
+	case d.SerialNumber != "":
+		key = "serial|" + strings.ToLower(d.Vid) + "|" + strings.ToLower(d.Pid) + "|" + d.SerialNumber
+	case d.Location != "":
+		key = "location|" + strings.ToLower(d.Vid) + "|" + strings.ToLower(d.Pid) + "|" + d.Location
+	default:
+		key = "port|" + d.Port
+	}
+
+	// Interface 00 is left out so single-interface devices hash the same on platforms
+	// that do not report an interface for them
+	if d.Interface != "" && d.Interface != "00" {
+		key += "|" + strings.ToLower(d.Interface)
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return DeviceID(hex.EncodeToString(sum[:16]))
+}
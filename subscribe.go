@@ -0,0 +1,134 @@
+package serialfinder
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// subscriptions holds the listeners registered with Finder.Subscribe and the devices they
+// have been told about. One watch serves every listener while at least one is registered.
+type subscriptions struct {
+	mu        sync.Mutex
+	nextID    int
+	listeners map[int]*listener
+	devices   DeviceSet
+	cancel    context.CancelFunc
+	// generation tells the watch of the current listeners apart from one being stopped
+	generation int
+}
+
+// listener is one registered callback. Its mutex keeps deliveries in order, including the
+// replay of the devices present when it subscribed.
+type listener struct {
+	mu     sync.Mutex
+	fn     func(Event)
+	active atomic.Bool
+}
+
+// deliver calls the callback unless the listener unsubscribed meanwhile
+func (l *listener) deliver(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active.Load() {
+		l.fn(event)
+	}
+}
+
+// Subscribe calls fn for every device attached or detached, like the events of Watch with
+// an empty filter, until the returned function is called. Each new listener first receives
+// an EventAdded for every device already present.
+//
+// All listeners share one watch, which starts with the first subscription and stops after
+// the last unsubscribe. Calls to one listener never overlap and arrive in order; a slow
+// listener delays the others. It is safe to unsubscribe from within fn.
+func (f *Finder) Subscribe(fn func(Event)) (unsubscribe func()) {
+	s := &f.subs
+	l := &listener{fn: fn}
+	l.active.Store(true)
+
+	// Hold the listener while registering it, so events from the watch wait for the replay
+	l.mu.Lock()
+	s.mu.Lock()
+	if s.listeners == nil {
+		s.listeners = make(map[int]*listener)
+	}
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = l
+	if len(s.listeners) == 1 {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+		s.generation++
+		s.devices = make(DeviceSet)
+		go f.dispatch(ctx, s.generation)
+	}
+	present := s.devices.Devices()
+	s.mu.Unlock()
+
+	for _, device := range present {
+		l.fn(Event{Type: EventAdded, Device: device})
+	}
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.active.Store(false)
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.listeners, id)
+			if len(s.listeners) == 0 {
+				s.cancel()
+				s.cancel = nil
+				s.devices = nil
+				s.generation++
+			}
+		})
+	}
+}
+
+// dispatch runs the watch of one generation of listeners, restarting it if it cannot be
+// set up, and hands its events to every listener
+func (f *Finder) dispatch(ctx context.Context, generation int) {
+	s := &f.subs
+	interval := f.opts.pollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	for ctx.Err() == nil {
+		events, err := f.Watch(ctx, Filter{})
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+			continue
+		}
+
+		for event := range events {
+			s.mu.Lock()
+			if s.generation != generation {
+				s.mu.Unlock()
+				return
+			}
+			switch event.Type {
+			case EventAdded:
+				s.devices.Add(event.Device)
+			case EventRemoved:
+				s.devices.Remove(event.Device.StableID())
+			}
+			listeners := make([]*listener, 0, len(s.listeners))
+			for _, l := range s.listeners {
+				listeners = append(listeners, l)
+			}
+			s.mu.Unlock()
+
+			for _, l := range listeners {
+				l.deliver(event)
+			}
+		}
+	}
+}
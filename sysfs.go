@@ -0,0 +1,795 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sysfsFS is the file system the Linux backends read sysfs through. Names are absolute,
+// slash-separated paths such as /sys/class/tty, whether they refer to the running system or
+// to a captured tree.
+type sysfsFS interface {
+	ReadFile(name string) ([]byte, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Stat(name string) (fs.FileInfo, error)
+	// EvalSymlinks returns the name after resolving every symbolic link in it
+	EvalSymlinks(name string) (string, error)
+}
+
+// hostSysfs reads the file system of the running system
+type hostSysfs struct{}
+
+func (hostSysfs) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+func (hostSysfs) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (hostSysfs) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (hostSysfs) EvalSymlinks(name string) (string, error)   { return filepath.EvalSymlinks(name) }
+
+// readLinkFS is implemented by file systems that expose symbolic links, like fs.ReadLinkFS
+// in Go 1.25
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// maxSymlinks bounds the links followed while resolving one name, as the kernel does
+const maxSymlinks = 40
+
+// capturedSysfs reads a captured tree whose root stands for the root of the system it was
+// captured on
+type capturedSysfs struct {
+	fsys fs.FS
+}
+
+// fsName converts an absolute name to the unrooted form fs.FS expects
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean(name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func (c capturedSysfs) ReadFile(name string) ([]byte, error) {
+	resolved, err := c.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(c.fsys, fsName(resolved))
+}
+
+func (c capturedSysfs) ReadDir(name string) ([]fs.DirEntry, error) {
+	resolved, err := c.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(c.fsys, fsName(resolved))
+}
+
+func (c capturedSysfs) Stat(name string) (fs.FileInfo, error) {
+	resolved, err := c.EvalSymlinks(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(c.fsys, fsName(resolved))
+}
+
+// EvalSymlinks resolves the links of name with the ReadLink method of the file system, if any
+func (c capturedSysfs) EvalSymlinks(name string) (string, error) {
+	readLink := func(name string) (string, error) {
+		if links, ok := c.fsys.(readLinkFS); ok {
+			return links.ReadLink(fsName(name))
+		}
+		return "", fs.ErrInvalid
+	}
+	exists := func(name string) error {
+		_, err := fs.Stat(c.fsys, fsName(name))
+		return err
+	}
+	return evalSymlinks(name, readLink, exists)
+}
+
+// evalSymlinks resolves the links of an absolute name one component at a time. readLink
+// returns the target of a link, or an error if the name is not one; exists fails if the
+// name does not exist. Relative targets are relative to the directory of the link.
+func evalSymlinks(name string, readLink func(string) (string, error), exists func(string) error) (string, error) {
+	resolved := "/"
+	rest := strings.Split(strings.Trim(path.Clean("/"+name), "/"), "/")
+	for followed := 0; len(rest) > 0; {
+		component := rest[0]
+		rest = rest[1:]
+		if component == "" {
+			continue
+		}
+
+		next := path.Join(resolved, component)
+		if target, err := readLink(next); err == nil {
+			if followed++; followed > maxSymlinks {
+				return "", &fs.PathError{Op: "evalsymlinks", Path: name, Err: errors.New("too many links")}
+			}
+			if !path.IsAbs(target) {
+				target = path.Join(resolved, target)
+			}
+			rest = append(strings.Split(strings.Trim(path.Clean(target), "/"), "/"), rest...)
+			resolved = "/"
+			continue
+		}
+		if err := exists(next); err != nil {
+			return "", err
+		}
+		resolved = next
+	}
+	return resolved, nil
+}
+
+// ScanSysfs lists the serial devices recorded in a sysfs tree captured from a Linux system,
+// such as a tar snapshot of a remote board, for offline debugging. The root of fsys stands
+// for the root of that system, so it holds sys/class/tty and sys/devices. Symbolic links are
+// followed when fsys has a ReadLink method like fs.ReadLinkFS, which os.DirFS has since Go
+// 1.25; sysfs relies on them, so without it no device is found. Ports are named after the
+// tty in /dev, which the capture does not need to include. WithIncludeNonUSB also lists the
+// built-in and SoC UARTs. Every attribute is read, as with WithExtendedInfo; options that
+// query the live system, such as WithInUseCheck, are ignored.
+func ScanSysfs(fsys fs.FS, opts ...Option) ([]SerialDeviceInfo, error) {
+	o := newOptions(opts)
+	sys := capturedSysfs{fsys: fsys}
+
+	devices, nodes, err := listUSBTTYs(context.Background(), sys, Filter{}, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	if devices, nodes, err = addByPathLinks(context.Background(), sys, Filter{}, devices, nodes, nil); err != nil {
+		return nil, err
+	}
+	if o.includeNonUSB {
+		extra, extraNodes, err := listNonUSBTTYs(context.Background(), sys, Filter{}, false)
+		if err != nil {
+			return nil, err
+		}
+		devices, nodes = append(devices, extra...), append(nodes, extraNodes...)
+	}
+	readSysfsExtendedInfo(sys, devices, nodes)
+
+	assignSiblings(devices)
+	labelKnownDevices(devices)
+	return devices, nil
+}
+
+// serialByIDDir holds the links udev creates for each serial device, named after its IDs
+const serialByIDDir = "/dev/serial/by-id"
+
+// listByIDLinks reports the ttys behind the links in /dev/serial/by-id, with the link as the
+// port, along with the node of each device. It returns false if the directory does not
+// exist. A non-nil stop ends the walk at the first device it accepts.
+func listByIDLinks(ctx context.Context, sys sysfsFS, f Filter, stop func(SerialDeviceInfo) bool) (devices []SerialDeviceInfo, nodes []string, found bool, err error) {
+	entries, err := sys.ReadDir(serialByIDDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, false, nil
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var links []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			links = append(links, path.Join(serialByIDDir, entry.Name()))
+		}
+	}
+
+	devices, nodes, err = resolveTTYs(ctx, len(links), stop, func(i int) (SerialDeviceInfo, string, bool, error) {
+		// Resolve the symbolic link to get the actual device path
+		symlinkPath := links[i]
+		devicePath, err := sys.EvalSymlinks(symlinkPath)
+		if err != nil {
+			return SerialDeviceInfo{}, "", false, nil
+		}
+
+		// Read the USB attributes of the tty device behind the link
+		_, span := startSpan(ctx, "serialfinder.resolve", attribute.String("serialfinder.node", devicePath))
+		device, ok, err := readSysfsDevice(sys, f, devicePath)
+		endSpan(span, err)
+		device.Port = symlinkPath
+		return device, devicePath, ok, err
+	})
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return devices, nodes, true, nil
+}
+
+// lookupSysfsPort describes the USB serial tty behind a port, such as /dev/ttyUSB0 or one of
+// its links in /dev/serial/by-id, reading sysfs for that device alone. With byID the port
+// and its siblings are named after their links, as listByIDLinks names them; otherwise they
+// are named after their nodes, which must exist, as listUSBTTYs names them. It returns false
+// for ports it cannot describe this way, such as built-in UARTs or, with byID, ttys without
+// a link, leaving them to a full scan.
+func lookupSysfsPort(sys sysfsFS, port string, byID bool) (device SerialDeviceInfo, node string, ok bool, err error) {
+	if !path.IsAbs(port) {
+		return SerialDeviceInfo{}, "", false, nil
+	}
+	node, err = sys.EvalSymlinks(port)
+	if err != nil || path.Dir(node) != "/dev" || !hasAnyPrefix(path.Base(node), usbTTYPrefixes) {
+		return SerialDeviceInfo{}, "", false, nil
+	}
+
+	device, ok, err = readSysfsDevice(sys, Filter{}, node)
+	if err != nil || !ok {
+		return SerialDeviceInfo{}, "", false, err
+	}
+
+	// portName names a tty of the device the way the scan of the backend does
+	var links map[string]string
+	if byID {
+		links = serialLinksByNode(sys, serialByIDDir)
+	}
+	portName := func(tty string) (string, bool) {
+		node := path.Join("/dev", tty)
+		if byID {
+			link, ok := links[node]
+			return link, ok
+		}
+		_, err := sys.Stat(node)
+		return node, err == nil
+	}
+
+	if device.Port, ok = portName(path.Base(node)); !ok {
+		return SerialDeviceInfo{}, "", false, nil
+	}
+	for _, tty := range usbDeviceTTYs(sys, findSerialDeviceInfoDir(sys, node)) {
+		if sibling, ok := portName(tty); ok && sibling != device.Port {
+			device.Siblings = append(device.Siblings, sibling)
+		}
+	}
+	sort.Strings(device.Siblings)
+	device.ByPath = serialLinksByNode(sys, serialByPathDir)[node]
+	return device, node, true, nil
+}
+
+// serialLink is a link udev made to a tty node
+type serialLink struct {
+	link, node string
+}
+
+// readSerialLinks resolves the links in dir, /dev/serial/by-id or /dev/serial/by-path, in
+// the order of their names, keeping the first link of each node
+func readSerialLinks(sys sysfsFS, dir string) []serialLink {
+	entries, _ := sys.ReadDir(dir)
+	var links []serialLink
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		link := path.Join(dir, entry.Name())
+		if node, err := sys.EvalSymlinks(link); err == nil && !seen[node] {
+			seen[node] = true
+			links = append(links, serialLink{link, node})
+		}
+	}
+	return links
+}
+
+// serialLinksByNode maps the tty nodes to their links in dir
+func serialLinksByNode(sys sysfsFS, dir string) map[string]string {
+	links := make(map[string]string)
+	for _, l := range readSerialLinks(sys, dir) {
+		links[l.node] = l.link
+	}
+	return links
+}
+
+// serialByPathDir holds the links udev creates for each serial device, named after the
+// bus path the device is plugged into
+const serialByPathDir = "/dev/serial/by-path"
+
+// addByPathLinks sets ByPath of the devices, whose nodes are given, to their links in
+// /dev/serial/by-path, and appends the USB ttys that only have such a link, with their node
+// as the port. udev gives devices without a serial number, such as most CH340 adapters, the
+// same by-id name, so only one of them keeps a by-id link, while their by-path links
+// differ. A non-nil stop ends the walk at the first device it accepts.
+func addByPathLinks(ctx context.Context, sys sysfsFS, f Filter, devices []SerialDeviceInfo, nodes []string, stop func(SerialDeviceInfo) bool) ([]SerialDeviceInfo, []string, error) {
+	links := readSerialLinks(sys, serialByPathDir)
+	if len(links) == 0 {
+		return devices, nodes, nil
+	}
+
+	byNode := make(map[string]string, len(links))
+	for _, l := range links {
+		byNode[l.node] = l.link
+	}
+	known := make(map[string]bool, len(nodes))
+	for i, node := range nodes {
+		devices[i].ByPath = byNode[node]
+		known[node] = true
+	}
+	if stop != nil && len(devices) > 0 && stop(devices[len(devices)-1]) {
+		return devices, nodes, nil
+	}
+
+	var extra []serialLink
+	for _, l := range links {
+		if !known[l.node] && path.Dir(l.node) == "/dev" && hasAnyPrefix(path.Base(l.node), usbTTYPrefixes) {
+			extra = append(extra, l)
+		}
+	}
+	found, foundNodes, err := resolveTTYs(ctx, len(extra), stop, func(i int) (SerialDeviceInfo, string, bool, error) {
+		_, span := startSpan(ctx, "serialfinder.resolve", attribute.String("serialfinder.node", extra[i].node))
+		device, ok, err := readSysfsDevice(sys, f, extra[i].node)
+		endSpan(span, err)
+		device.Port, device.ByPath = extra[i].node, extra[i].link
+		return device, extra[i].node, ok, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(devices, found...), append(nodes, foundNodes...), nil
+}
+
+// usbDeviceTTYs returns the names of the USB serial ttys of the USB device at usbDir, found
+// below its interfaces: in a tty directory for ACM (1-1:1.0/tty/ttyACM0) and as a port
+// directory for usb-serial drivers (1-1:1.0/ttyUSB0)
+func usbDeviceTTYs(sys sysfsFS, usbDir string) []string {
+	entries, err := sys.ReadDir(usbDir)
+	if err != nil {
+		return nil
+	}
+	var ttys []string
+	for _, iface := range entries {
+		if !strings.Contains(iface.Name(), ":") {
+			continue
+		}
+		children, _ := sys.ReadDir(path.Join(usbDir, iface.Name()))
+		for _, child := range children {
+			switch {
+			case child.Name() == "tty":
+				classTTYs, _ := sys.ReadDir(path.Join(usbDir, iface.Name(), "tty"))
+				for _, tty := range classTTYs {
+					ttys = append(ttys, tty.Name())
+				}
+			case hasAnyPrefix(child.Name(), usbTTYPrefixes):
+				ttys = append(ttys, child.Name())
+			}
+		}
+	}
+	return ttys
+}
+
+// usbTTYPrefixes are the tty names created by USB serial drivers
+var usbTTYPrefixes = []string{"ttyUSB", "ttyACM"}
+
+// listUSBTTYs looks up every USB serial tty in sysfs and reports its node in /dev as the
+// port, along with the node of each device. With requireNodes, ttys whose node is missing
+// are skipped, as happens when no device manager populates /dev. A non-nil stop ends the walk
+// at the first device it accepts.
+func listUSBTTYs(ctx context.Context, sys sysfsFS, f Filter, requireNodes bool, stop func(SerialDeviceInfo) bool) ([]SerialDeviceInfo, []string, error) {
+	entries, err := sys.ReadDir("/sys/class/tty")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if hasAnyPrefix(entry.Name(), usbTTYPrefixes) {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return resolveTTYs(ctx, len(names), stop, func(i int) (SerialDeviceInfo, string, bool, error) {
+		devicePath := path.Join("/dev", names[i])
+		if requireNodes {
+			if _, err := sys.Stat(devicePath); err != nil {
+				return SerialDeviceInfo{}, "", false, nil
+			}
+		}
+
+		_, span := startSpan(ctx, "serialfinder.resolve", attribute.String("serialfinder.node", devicePath))
+		device, ok, err := readSysfsDevice(sys, f, devicePath)
+		endSpan(span, err)
+		device.Port = devicePath
+		return device, devicePath, ok, err
+	})
+}
+
+// resolveWorkers bounds the ttys resolved at once. Reading sysfs waits on the kernel and on
+// USB drivers rather than the CPU, so machines with dozens of adapters gain from overlapping
+// the reads.
+const resolveWorkers = 8
+
+// resolvedTTY is the outcome of resolving one tty
+type resolvedTTY struct {
+	device SerialDeviceInfo
+	node   string
+	ok     bool
+	err    error
+}
+
+// sysfsScan is the scan of the Linux backends over a sysfs tree: the /dev/serial/by-id links
+// when byID is set and there are any, or else the USB ttys, then the ttys only linked from
+// /dev/serial/by-path and the other UARTs with WithIncludeNonUSB
+type sysfsScan struct {
+	sys  sysfsFS
+	byID bool
+	// inUse returns which of the nodes are held open, for WithInUseCheck. It is nil for
+	// trees other than the running system.
+	inUse func(ctx context.Context, nodes []string) map[string]bool
+	// check fails scans whose devices reveal a problem of the system, such as WSL without
+	// attached USB devices
+	check func(devices []SerialDeviceInfo) error
+}
+
+func (s sysfsScan) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	o.stopAt = sysfsStopAt(s.sys, o)
+	var devices []SerialDeviceInfo
+	var nodes []string
+	var found bool
+	var err error
+	if s.byID {
+		if devices, nodes, found, err = listByIDLinks(ctx, s.sys, f, o.stopAt); err != nil {
+			return nil, err
+		}
+	}
+	if !found {
+		// The directory is missing when the last serial device was unplugged, but also on
+		// systems without udev (BusyBox, initramfs, containers), so fall back to sysfs
+		if devices, nodes, err = listUSBTTYs(ctx, s.sys, f, true, o.stopAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if !o.stopped(devices) {
+		// Systems without udev have no by-path links, but the links may have been made by hand
+		if devices, nodes, err = addByPathLinks(ctx, s.sys, f, devices, nodes, o.stopAt); err != nil {
+			return nil, err
+		}
+	}
+	if !o.stopped(devices) && o.includeNonUSB {
+		extra, extraNodes, err := listNonUSBTTYs(ctx, s.sys, f, true)
+		if err != nil {
+			return nil, err
+		}
+		devices, nodes = append(devices, extra...), append(nodes, extraNodes...)
+	}
+	return s.finish(ctx, devices, nodes, o)
+}
+
+// finish adds what the options ask for to the devices of a scan, whose nodes are given,
+// whether the scan ran in full or stopped at a match
+func (s sysfsScan) finish(ctx context.Context, devices []SerialDeviceInfo, nodes []string, o options) ([]SerialDeviceInfo, error) {
+	if o.wantExtended() {
+		readSysfsExtendedInfo(s.sys, devices, nodes)
+	}
+	if s.check != nil {
+		if err := s.check(devices); err != nil {
+			return nil, err
+		}
+	}
+	if o.checkInUse && s.inUse != nil {
+		inUse := s.inUse(ctx, nodes)
+		for i := range devices {
+			devices[i].InUse = inUse[nodes[i]]
+		}
+	}
+	return devices, nil
+}
+
+// sysfsStopAt returns the stopAt of o for the scans of sys. The devices they offer it lack
+// the driver, which is read with the extended attributes once the scan ends, so with
+// WithDriver the driver is read for each device first and stopAt can match on it.
+func sysfsStopAt(sys sysfsFS, o options) func(SerialDeviceInfo) bool {
+	if o.stopAt == nil || len(o.drivers) == 0 {
+		return o.stopAt
+	}
+	return func(device SerialDeviceInfo) bool {
+		if device.Driver == "" {
+			if node, err := sys.EvalSymlinks(device.Port); err == nil {
+				device.Driver = readTTYDriver(sys, path.Base(node))
+			}
+		}
+		return o.stopAt(device)
+	}
+}
+
+// resolveTTYs calls resolve for the n ttys of a scan on a bounded pool of workers and
+// returns the devices it accepts with their nodes, in the order of the ttys, or the error of
+// the first tty that failed. A non-nil stop resolves them one at a time instead, ending at
+// the first device stop accepts.
+func resolveTTYs(ctx context.Context, n int, stop func(SerialDeviceInfo) bool, resolve func(i int) (SerialDeviceInfo, string, bool, error)) ([]SerialDeviceInfo, []string, error) {
+	var devices []SerialDeviceInfo
+	var nodes []string
+
+	if stop != nil || n <= 1 {
+		for i := 0; i < n; i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+			device, node, ok, err := resolve(i)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				continue
+			}
+			devices = append(devices, device)
+			nodes = append(nodes, node)
+			if stop != nil && stop(device) {
+				break
+			}
+		}
+		return devices, nodes, nil
+	}
+
+	results := make([]resolvedTTY, n)
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(resolveWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() == nil {
+					device, node, ok, err := resolve(i)
+					results[i] = resolvedTTY{device, node, ok, err}
+				}
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	for _, result := range results {
+		if result.err != nil {
+			return nil, nil, result.err
+		}
+		if result.ok {
+			devices = append(devices, result.device)
+			nodes = append(nodes, result.node)
+		}
+	}
+	return devices, nodes, nil
+}
+
+// hasAnyPrefix reports whether s starts with one of the prefixes
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readSysfsDevice reads the USB attributes of the tty device node at devicePath from sysfs.
+// It returns false if the device is not a USB device or does not match the VID/PID filter,
+// and an error if an attribute could not be read. A device busy right after it is plugged
+// in fails with EBUSY or EAGAIN, which WithRetry retries. Only the core attributes are
+// read; readSysfsExtendedInfo adds the others. Port is left for the caller to fill in.
+func readSysfsDevice(sys sysfsFS, f Filter, devicePath string) (SerialDeviceInfo, bool, error) {
+	// Find the USB device directory associated with this tty device
+	usbDir := findSerialDeviceInfoDir(sys, devicePath)
+	if usbDir == "" {
+		return SerialDeviceInfo{}, false, nil
+	}
+
+	// Read the VID and PID
+	idVendor, err := readSysfsID(sys, usbDir, "idVendor")
+	if err != nil {
+		return SerialDeviceInfo{}, false, err
+	}
+	idProduct, err := readSysfsID(sys, usbDir, "idProduct")
+	if err != nil {
+		return SerialDeviceInfo{}, false, err
+	}
+	if idVendor == "" || idProduct == "" {
+		// The device was unplugged while it was being read
+		return SerialDeviceInfo{}, false, nil
+	}
+
+	// Check if the VID and PID match the specified values
+	if !f.matchIDs(idVendor, idProduct) {
+		return SerialDeviceInfo{}, false, nil
+	}
+
+	// Devices without a serial number, such as most CH340 adapters, have no serial file
+	serialNumber, err := sys.ReadFile(path.Join(usbDir, "serial"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return SerialDeviceInfo{}, false, fmt.Errorf("reading the serial number of %s: %w", devicePath, err)
+	}
+
+	// sysfs creates the USB device directory when the device is attached, so its
+	// modification time is the connection time
+	var connectedAt time.Time
+	if info, err := sys.Stat(usbDir); err == nil {
+		connectedAt = info.ModTime()
+	}
+
+	remote, remoteHost := readUSBIPRemote(sys, usbDir)
+
+	return SerialDeviceInfo{
+		SerialNumber: strings.TrimSpace(string(serialNumber)),
+		Vid:          idVendor,
+		Pid:          idProduct,
+		Transport:    TransportUSB,
+		ConnectedAt:  connectedAt,
+		Location:     path.Base(usbDir),
+		Interface:    strings.ToUpper(readSysfsString(sys, findInterfaceDir(sys, devicePath, usbDir), "bInterfaceNumber")),
+		Remote:       remote,
+		RemoteHost:   remoteHost,
+	}, true, nil
+}
+
+// readSysfsID reads the idVendor or idProduct file of a USB device directory in upper case.
+// It returns "" if the file is gone, as when the device is unplugged during the scan, and
+// the error of any other failure.
+func readSysfsID(sys sysfsFS, usbDir, name string) (string, error) {
+	data, err := sys.ReadFile(path.Join(usbDir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading %s of %s: %w", name, usbDir, err)
+	}
+	return strings.ToUpper(strings.TrimSpace(string(data))), nil
+}
+
+// readSysfsExtendedInfo adds the attributes WithExtendedInfo asks for to the devices, whose
+// tty nodes are given: the driver and, for USB devices, the topology, power state and the
+// strings the device reports, keeping those udev already gave, and the files listed in
+// sysfsAttributeFiles as Attributes
+func readSysfsExtendedInfo(sys sysfsFS, devices []SerialDeviceInfo, nodes []string) {
+	for i, node := range nodes {
+		device := &devices[i]
+		device.Driver = readTTYDriver(sys, path.Base(node))
+		if device.Transport != TransportUSB {
+			continue
+		}
+		usbDir := findSerialDeviceInfoDir(sys, node)
+		if usbDir == "" {
+			continue
+		}
+		device.Topology = parseSysfsTopology(path.Base(usbDir))
+		device.Power = readPowerInfo(sys, usbDir)
+		if device.Manufacturer == "" {
+			device.Manufacturer = readSysfsString(sys, usbDir, "manufacturer")
+		}
+		if device.Product == "" {
+			device.Product = readSysfsString(sys, usbDir, "product")
+		}
+		ifaceDir := findInterfaceDir(sys, node, usbDir)
+		device.InterfaceName = readSysfsString(sys, ifaceDir, "interface")
+		for _, name := range sysfsAttributeFiles {
+			device.setAttribute(name, readSysfsString(sys, usbDir, name))
+		}
+		for _, name := range sysfsInterfaceAttributeFiles {
+			device.setAttribute(name, readSysfsString(sys, ifaceDir, name))
+		}
+	}
+}
+
+// sysfsAttributeFiles are the files of a USB device in sysfs reported in Attributes, those
+// that no field of SerialDeviceInfo is read from
+var sysfsAttributeFiles = []string{
+	"bcdDevice", "bDeviceClass", "bDeviceSubClass", "bDeviceProtocol", "bMaxPacketSize0",
+	"bConfigurationValue", "bNumConfigurations", "bNumInterfaces", "busnum", "devnum",
+	"devpath", "speed", "version", "removable", "quirks",
+}
+
+// sysfsInterfaceAttributeFiles are the files of the USB interface of a port reported in
+// Attributes
+var sysfsInterfaceAttributeFiles = []string{
+	"bInterfaceClass", "bInterfaceSubClass", "bInterfaceProtocol", "bNumEndpoints", "bAlternateSetting",
+}
+
+// readTTYDriver returns the name of the driver bound to the device of a tty, such as cdc_acm
+// for an ACM interface or ftdi_sio for a usb-serial port, or "" if none is. Since Linux 6.5
+// the ports of UARTs sit below the "ctrl" and "port" devices of the serial-base bus, whose
+// drivers are the same for every UART, so those are skipped for the one of the controller.
+func readTTYDriver(sys sysfsFS, name string) string {
+	dir, err := sys.EvalSymlinks(path.Join("/sys/class/tty", name, "device"))
+	if err != nil {
+		return ""
+	}
+	for ; dir != "/sys/devices" && dir != path.Dir(dir); dir = path.Dir(dir) {
+		driver, err := sys.EvalSymlinks(path.Join(dir, "driver"))
+		if err != nil {
+			return ""
+		}
+		if !strings.HasPrefix(driver, "/sys/bus/serial-base/") {
+			return path.Base(driver)
+		}
+	}
+	return ""
+}
+
+// findSerialDeviceInfoDir returns the directory path of the USB device corresponding to the device path
+func findSerialDeviceInfoDir(sys sysfsFS, devicePath string) string {
+	// Get the full path to the tty device in /sys/class/tty
+	sysTTYPath := path.Join("/sys/class/tty", path.Base(devicePath), "device")
+
+	// Follow the symlink to the actual device directory
+	usbDir, err := sys.EvalSymlinks(sysTTYPath)
+	if err != nil {
+		return ""
+	}
+
+	// Walk up to the USB device directory. It is usually the parent (ACM) or grandparent
+	// (usb-serial) of the tty device; walking instead of checking fixed levels also copes
+	// with layouts that add a level, as under the vhci_hcd host that usbipd uses in WSL.
+	for dir := path.Dir(usbDir); dir != "/sys/devices" && dir != path.Dir(dir); dir = path.Dir(dir) {
+		if checkForVIDPIDFiles(sys, dir) {
+			return dir
+		}
+	}
+
+	return ""
+}
+
+// findInterfaceDir returns the directory of the USB interface below usbDir that the tty
+// device belongs to, or "" if it is not below one
+func findInterfaceDir(sys sysfsFS, devicePath, usbDir string) string {
+	dir, err := sys.EvalSymlinks(path.Join("/sys/class/tty", path.Base(devicePath), "device"))
+	if err != nil {
+		return ""
+	}
+
+	// Walk up until the direct child of the USB device directory, which is the interface
+	for path.Dir(dir) != usbDir {
+		parent := path.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	return dir
+}
+
+// readSysfsString reads a sysfs attribute holding a string, such as the product string
+// descriptor of a USB device, without its trailing newline. It returns "" if dir is empty
+// or the attribute is missing, as it is for descriptors the device does not provide.
+func readSysfsString(sys sysfsFS, dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+	data, err := sys.ReadFile(path.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readPowerInfo reads the power budget and runtime power management state of a USB device
+func readPowerInfo(sys sysfsFS, usbDir string) *PowerInfo {
+	maxPower, errMax := sys.ReadFile(path.Join(usbDir, "bMaxPower"))
+	status, errStatus := sys.ReadFile(path.Join(usbDir, "power", "runtime_status"))
+	if errMax != nil && errStatus != nil {
+		return nil
+	}
+
+	power := &PowerInfo{
+		MaxPowerMA:    parseMilliamps(string(maxPower)),
+		RuntimeStatus: strings.TrimSpace(string(status)),
+	}
+	if control, err := sys.ReadFile(path.Join(usbDir, "power", "control")); err == nil {
+		power.Autosuspend = strings.TrimSpace(string(control)) == "auto"
+	}
+	return power
+}
+
+// checkForVIDPIDFiles checks if the directory contains idVendor and idProduct files
+func checkForVIDPIDFiles(sys sysfsFS, dir string) bool {
+	_, errVid := sys.Stat(path.Join(dir, "idVendor"))
+	_, errPid := sys.Stat(path.Join(dir, "idProduct"))
+	return errVid == nil && errPid == nil
+}
@@ -0,0 +1,14 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import "context"
+
+// markInUse sets InUse on each device whose node, given at the same index, is held open
+func markInUse(ctx context.Context, devices []SerialDeviceInfo, nodes []string) {
+	inUse := portsInUse(ctx, nodes)
+	for i := range devices {
+		devices[i].InUse = inUse[nodes[i]]
+	}
+}
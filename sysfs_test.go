@@ -0,0 +1,61 @@
+package serialfinder
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+)
+
+// symlinkFS is a MapFS whose symbolic links hold their target as data
+type symlinkFS struct {
+	fstest.MapFS
+}
+
+func (l symlinkFS) ReadLink(name string) (string, error) {
+	file, ok := l.MapFS[name]
+	if !ok || file.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return string(file.Data), nil
+}
+
+// usbSerialSysfs lays out a usb-serial tty bound to each of the drivers
+func usbSerialSysfs(drivers ...string) sysfsFS {
+	fsys := fstest.MapFS{}
+	for i, driver := range drivers {
+		tty := fmt.Sprintf("ttyUSB%d", i)
+		usbDir := fmt.Sprintf("sys/devices/pci0000:00/0000:00:14.0/usb1/1-%d", i+1)
+		ttyDir := fmt.Sprintf("%s/1-%d:1.0/%s", usbDir, i+1, tty)
+		fsys[usbDir+"/idVendor"] = &fstest.MapFile{Data: []byte("0403\n")}
+		fsys[usbDir+"/idProduct"] = &fstest.MapFile{Data: []byte("6001\n")}
+		fsys[usbDir+"/serial"] = &fstest.MapFile{Data: []byte(fmt.Sprintf("A%d\n", i))}
+		fsys[ttyDir+"/driver"] = &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte("/sys/bus/usb-serial/drivers/" + driver)}
+		fsys["sys/bus/usb-serial/drivers/"+driver] = &fstest.MapFile{Mode: fs.ModeDir | 0o755}
+		fsys[path.Join("sys/class/tty", tty, "device")] = &fstest.MapFile{Mode: fs.ModeSymlink, Data: []byte("/" + ttyDir)}
+		fsys[path.Join("dev", tty)] = &fstest.MapFile{Mode: fs.ModeDevice | fs.ModeCharDevice | 0o660}
+	}
+	return capturedSysfs{fsys: symlinkFS{fsys}}
+}
+
+func TestSysfsStopAtDriver(t *testing.T) {
+	sys := usbSerialSysfs("ftdi_sio", "cp210x", "cp210x")
+	// The stopAt of FindFirst with WithDriver
+	o := newOptions([]Option{WithDriver("cp210x")})
+	o.stopAt = func(device SerialDeviceInfo) bool {
+		return !o.excluded(device)
+	}
+
+	devices, _, err := listUSBTTYs(context.Background(), sys, Filter{}, true, sysfsStopAt(sys, o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(devices) != 2 || devices[1].Port != "/dev/ttyUSB1" {
+		t.Fatalf("the scan resolved %+v, want it to stop at /dev/ttyUSB1", devices)
+	}
+	if !sysfsStopAt(sys, o)(devices[1]) || sysfsStopAt(sys, o)(devices[0]) {
+		t.Error("stopAt did not match the devices on their driver")
+	}
+}
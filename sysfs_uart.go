@@ -0,0 +1,140 @@
+package serialfinder
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// nonUSBTTYPrefixes are the tty names of built-in, PCI and SoC UARTs
+var nonUSBTTYPrefixes = []string{
+	"ttyS",     // 8250/16550 compatible, built-in or PCI
+	"ttyAMA",   // ARM PL011 (Raspberry Pi)
+	"ttymxc",   // NXP i.MX
+	"ttySAC",   // Samsung
+	"ttyO",     // TI OMAP
+	"ttyTHS",   // NVIDIA Tegra high-speed (Jetson)
+	"ttyMSM",   // Qualcomm
+	"ttyLP",    // NXP LPUART
+	"ttyAML",   // Amlogic
+	"ttyMV",    // Marvell
+	"ttySTM",   // STM32
+	"ttySIF",   // SiFive
+	"ttyPS",    // Xilinx Zynq
+	"ttyHS",    // high-speed UARTs on various SoCs
+	"ttyFIQ",   // Rockchip debug UART
+	"ttyRPMSG", // remote processor messaging
+}
+
+// listNonUSBTTYs returns the UARTs that are not USB devices, with empty VID and PID and the
+// transport of the bus they sit on, and their nodes. With requireNodes, UARTs whose node is
+// missing from /dev are skipped.
+func listNonUSBTTYs(ctx context.Context, sys sysfsFS, f Filter, requireNodes bool) ([]SerialDeviceInfo, []string, error) {
+	var devices []SerialDeviceInfo
+	var nodes []string
+
+	entries, err := sys.ReadDir("/sys/class/tty")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, err
+		}
+		name := entry.Name()
+		if !hasAnyPrefix(name, nonUSBTTYPrefixes) || !f.matchIDs("", "") {
+			continue
+		}
+
+		// Virtual consoles and other ttys without hardware have no device link
+		deviceDir, err := sys.EvalSymlinks(path.Join("/sys/class/tty", name, "device"))
+		if err != nil {
+			continue
+		}
+
+		// The 8250 driver registers placeholder ports; type 0 (PORT_UNKNOWN) means no UART
+		if strings.HasPrefix(name, "ttyS") {
+			portType, err := sys.ReadFile(path.Join("/sys/class/tty", name, "type"))
+			if err == nil && strings.TrimSpace(string(portType)) == "0" {
+				continue
+			}
+		}
+
+		devicePath := path.Join("/dev", name)
+		if requireNodes {
+			if _, err := sys.Stat(devicePath); err != nil {
+				continue
+			}
+		}
+
+		devices = append(devices, SerialDeviceInfo{
+			Port:        devicePath,
+			Transport:   busTransport(sys, deviceDir),
+			Description: deviceTreeDescription(sys, deviceDir),
+		})
+		nodes = append(nodes, devicePath)
+	}
+
+	return devices, nodes, nil
+}
+
+// deviceTreeBase is where the kernel exposes the flattened device tree
+const deviceTreeBase = "/sys/firmware/devicetree/base"
+
+// deviceTreeDescription labels a UART from its device tree node, combining the model part of
+// its first compatible string with its alias, e.g. "pl011 uart0". It returns an empty
+// string on systems without a device tree.
+func deviceTreeDescription(sys sysfsFS, deviceDir string) string {
+	node, err := sys.EvalSymlinks(path.Join(deviceDir, "of_node"))
+	if err != nil {
+		return ""
+	}
+
+	var parts []string
+
+	// compatible is a NUL-separated list such as "arm,pl011\x00arm,primecell"
+	if compatible, err := sys.ReadFile(path.Join(node, "compatible")); err == nil {
+		first, _, _ := strings.Cut(string(compatible), "\x00")
+		if _, model, ok := strings.Cut(first, ","); ok {
+			first = model
+		}
+		if first != "" {
+			parts = append(parts, first)
+		}
+	}
+
+	// Aliases map names such as serial0 or uart0 to node paths
+	nodePath := strings.TrimPrefix(node, deviceTreeBase)
+	aliasesDir := path.Join(deviceTreeBase, "aliases")
+	if aliases, err := sys.ReadDir(aliasesDir); err == nil {
+		for _, alias := range aliases {
+			target, err := sys.ReadFile(path.Join(aliasesDir, alias.Name()))
+			if err == nil && strings.TrimRight(string(target), "\x00") == nodePath {
+				parts = append(parts, alias.Name())
+				break
+			}
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// busTransport walks up from a sysfs device directory to the first bus it recognizes
+func busTransport(sys sysfsFS, dir string) TransportType {
+	for dir != "/" && dir != "." {
+		subsystem, err := sys.EvalSymlinks(path.Join(dir, "subsystem"))
+		if err == nil {
+			switch path.Base(subsystem) {
+			case "usb":
+				return TransportUSB
+			case "pci":
+				return TransportPCI
+			case "platform", "pnp", "amba", "acpi":
+				return TransportPlatform
+			}
+		}
+		dir = path.Dir(dir)
+	}
+	return TransportUnknown
+}
@@ -0,0 +1,132 @@
+package serialfinder
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// systemProfilerItem is one entry of `system_profiler SPUSBDataType -json`. Buses and hubs
+// list the devices attached to them in Items.
+type systemProfilerItem struct {
+	Name         string               `json:"_name"`
+	VendorID     string               `json:"vendor_id"`
+	ProductID    string               `json:"product_id"`
+	SerialNumber string               `json:"serial_num"`
+	LocationID   string               `json:"location_id"`
+	Manufacturer string               `json:"manufacturer"`
+	Items        []systemProfilerItem `json:"_items"`
+}
+
+// parseSystemProfiler matches the USB devices in the JSON output of system_profiler to the
+// callout nodes in ports. system_profiler does not report device nodes, so a node belongs to
+// a device when its name contains the serial number (usbserial-A50285BI) or is derived from
+// the locationID (usbmodem14201 for 0x14200000). Devices without a node are skipped.
+func parseSystemProfiler(data []byte, ports []string, f Filter, preferDialin bool) ([]SerialDeviceInfo, error) {
+	var report struct {
+		Items []systemProfilerItem `json:"SPUSBDataType"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("error decoding system_profiler output: %v", err)
+	}
+
+	var devices []SerialDeviceInfo
+	claimed := make(map[string]bool)
+
+	var walk func(items []systemProfilerItem)
+	walk = func(items []systemProfilerItem) {
+		for _, item := range items {
+			// Children are matched first so a hub never claims the nodes of its devices
+			walk(item.Items)
+
+			vid, okVid := parseSystemProfilerID(item.VendorID)
+			pid, okPid := parseSystemProfilerID(item.ProductID)
+			if !okVid || !okPid {
+				continue
+			}
+
+			device := SerialDeviceInfo{
+				SerialNumber: item.SerialNumber,
+				Vid:          vid,
+				Pid:          pid,
+				Transport:    TransportUSB,
+				Manufacturer: item.Manufacturer,
+				Product:      item.Name,
+			}
+
+			// location_id looks like "0x14200000 / 3", the second part being the device address
+			var locationPrefix string
+			locationField := strings.TrimSpace(strings.SplitN(item.LocationID, "/", 2)[0])
+			if locationID, err := strconv.ParseInt(strings.TrimPrefix(locationField, "0x"), 16, 64); err == nil && locationID != 0 {
+				device.Location = fmt.Sprintf("0x%08x", locationID)
+				device.Topology = parseLocationIDTopology(locationID)
+				locationPrefix = strings.TrimRight(strconv.FormatInt(locationID, 16), "0")
+			}
+
+			for _, port := range ports {
+				if claimed[port] || !systemProfilerPortMatches(filepath.Base(port), device.SerialNumber, locationPrefix) {
+					continue
+				}
+				claimed[port] = true
+
+				matched := device
+				matched.Port = port
+				if dialin, ok := CalloutToDialin(port); ok {
+					matched.DialinPort = dialin
+					if preferDialin {
+						matched.Port = dialin
+					}
+				}
+				if f.matchIDs(matched.Vid, matched.Pid) {
+					devices = append(devices, matched)
+				}
+			}
+		}
+	}
+	walk(report.Items)
+
+	return devices, nil
+}
+
+// parseSystemProfilerID converts IDs like "0x0403  (Future Technology Devices International
+// Limited)" to upper-case hex such as "0403"
+func parseSystemProfilerID(value string) (string, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return "", false
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(fields[0], "0x"), 16, 16)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("%04X", id), true
+}
+
+// systemProfilerPortMatches reports whether the node named name, such as cu.usbmodem14201,
+// belongs to the device with the serial number and locationID prefix
+func systemProfilerPortMatches(name, serial, locationPrefix string) bool {
+	if serial != "" && strings.Contains(name, serial) {
+		return true
+	}
+	if locationPrefix == "" {
+		return false
+	}
+
+	// Nodes of devices without a serial number are named after the locationID, followed by
+	// one or two digits for the interface
+	for _, base := range []string{"cu.usbmodem", "cu.usbserial-"} {
+		if !strings.HasPrefix(name, base+locationPrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(name, base+locationPrefix)
+		if len(rest) > 2 {
+			continue
+		}
+		if _, err := strconv.Atoi(rest); err == nil || rest == "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+//go:build darwin
+// +build darwin
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// systemProfilerBackend finds devices through `system_profiler SPUSBDataType -json`, whose
+// format is documented and stable across macOS releases, unlike the text output of ioreg
+type systemProfilerBackend struct{}
+
+func init() {
+	platformBackends[BackendSystemProfiler] = func() backend { return systemProfilerBackend{} }
+}
+
+// list retrieves USB serial devices from system_profiler and matches them to the callout
+// nodes in /dev
+func (systemProfilerBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	cmd := exec.CommandContext(ctx, "system_profiler", "SPUSBDataType", "-json")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := runCommand(ctx, cmd, o.toolTimeout())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to run system_profiler: %w", err)
+	}
+
+	ports, err := filepath.Glob("/dev/cu.*")
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := parseSystemProfiler(out.Bytes(), ports, f, o.preferDialin)
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err = appendNonUSB(ctx, f, o, devices)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.checkInUse {
+		markInUse(ctx, devices, o)
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,94 @@
+package serialfinder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Topology is the position of a USB device in the hub tree, so devices can be mapped to
+// physical connectors
+type Topology struct {
+	// Bus is the USB bus (root hub) number. Buses are numbered by the operating system, so
+	// the same connector can have different bus numbers on different platforms.
+	Bus int `json:"bus"`
+	// Ports holds the port number at each level, from the root hub down to the device
+	Ports []int `json:"ports"`
+}
+
+// String formats the topology like Linux names USB devices, e.g. "1-1.4.2"
+func (t Topology) String() string {
+	ports := make([]string, len(t.Ports))
+	for i, p := range t.Ports {
+		ports[i] = strconv.Itoa(p)
+	}
+	return fmt.Sprintf("%d-%s", t.Bus, strings.Join(ports, "."))
+}
+
+// parseSysfsTopology parses a Linux USB device name such as "1-1.4.2"
+func parseSysfsTopology(name string) *Topology {
+	bus, path, ok := strings.Cut(name, "-")
+	if !ok {
+		return nil
+	}
+	busNumber, err := strconv.Atoi(bus)
+	if err != nil {
+		return nil
+	}
+
+	t := &Topology{Bus: busNumber}
+	for _, p := range strings.Split(path, ".") {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return nil
+		}
+		t.Ports = append(t.Ports, port)
+	}
+	return t
+}
+
+// parseLocationIDTopology decodes a macOS locationID such as 0x14200000: the top byte is the
+// bus and each following nibble is a port number, until the first zero nibble
+func parseLocationIDTopology(locationID int64) *Topology {
+	t := &Topology{Bus: int(locationID >> 24 & 0xff)}
+	for shift := 20; shift >= 0; shift -= 4 {
+		port := int(locationID >> shift & 0xf)
+		if port == 0 {
+			break
+		}
+		t.Ports = append(t.Ports, port)
+	}
+	if len(t.Ports) == 0 {
+		return nil
+	}
+	return t
+}
+
+// parseLocationPathTopology parses a Windows location path such as
+// PCIROOT(0)#PCI(1400)#USBROOT(0)#USB(2)#USB(4). USBROOT indexes are zero-based, so the
+// bus is numbered from one like on Linux.
+func parseLocationPathTopology(path string) *Topology {
+	var t *Topology
+	for _, segment := range strings.Split(path, "#") {
+		name, rest, ok := strings.Cut(segment, "(")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(rest, ")"))
+		if err != nil {
+			continue
+		}
+		switch name {
+		case "USBROOT":
+			t = &Topology{Bus: n + 1}
+		case "USB":
+			if t != nil {
+				t.Ports = append(t.Ports, n)
+			}
+		}
+	}
+	if t == nil || len(t.Ports) == 0 {
+		return nil
+	}
+	return t
+}
@@ -0,0 +1,48 @@
+package serialfinder
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation scope of the spans serialfinder emits
+const tracerName = "github.com/hs0zip/serialfinder"
+
+// WithTracerProvider emits OpenTelemetry spans through tp: one for each scan, as a child of
+// the span in the context passed to List, with children for the resolution of each device
+// and for each tool a backend runs (ioreg, lsof, PowerShell, ...). Without it, no span is
+// emitted.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(o *options) {
+		o.tracerProvider = tp
+	}
+}
+
+// startScanSpan starts the span of a scan with the tracer provider of the options. Without
+// one, the span in ctx is replaced by a non-recording one with the same span context, so
+// the spans of the scan are dropped while trace context still propagates to backends.
+func startScanSpan(ctx context.Context, o options, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if o.tracerProvider == nil {
+		ctx = trace.ContextWithSpanContext(ctx, trace.SpanContextFromContext(ctx))
+		return ctx, trace.SpanFromContext(ctx)
+	}
+	return o.tracerProvider.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// startSpan starts a child of the span in ctx with the same tracer provider, which only
+// records anything below a span started by startScanSpan with a tracer provider
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err on the span, if any, and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
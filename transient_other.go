@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+// isTransientErrno reports false on platforms without a serial backend
+func isTransientErrno(err error) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package serialfinder
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isTransientErrno reports whether err is a system error that goes away once the device
+// settles: sysfs attributes answer EBUSY or EAGAIN while a driver binds
+func isTransientErrno(err error) bool {
+	return errors.Is(err, syscall.EBUSY) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EINTR)
+}
@@ -0,0 +1,16 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isTransientErrno reports whether err is a system error that goes away once the device
+// settles, such as a registry key locked by the driver installer
+func isTransientErrno(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) || errors.Is(err, windows.ERROR_LOCK_VIOLATION) || errors.Is(err, windows.ERROR_BUSY)
+}
@@ -0,0 +1,67 @@
+package serialfinder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransportType is the bus a serial port is attached through
+type TransportType int
+
+const (
+	// TransportUnknown is reported when the backend cannot tell the bus
+	TransportUnknown TransportType = iota
+	TransportUSB
+	TransportPCI
+	// TransportPlatform covers built-in and SoC UARTs
+	TransportPlatform
+	TransportBluetooth
+	// TransportVirtual covers pseudo terminals and software-emulated ports
+	TransportVirtual
+	// TransportNetwork covers serial servers reached over TCP, such as ser2net or RFC 2217
+	// device servers
+	TransportNetwork
+)
+
+var transportNames = map[TransportType]string{
+	TransportUnknown:   "unknown",
+	TransportUSB:       "usb",
+	TransportPCI:       "pci",
+	TransportPlatform:  "platform",
+	TransportBluetooth: "bluetooth",
+	TransportVirtual:   "virtual",
+	TransportNetwork:   "network",
+}
+
+// String returns the lowercase name of the transport, e.g. "usb"
+func (t TransportType) String() string {
+	if name, ok := transportNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("TransportType(%d)", int(t))
+}
+
+// ParseTransport returns the transport with the given name, as returned by String
+func ParseTransport(name string) (TransportType, error) {
+	for t, n := range transportNames {
+		if strings.EqualFold(n, name) {
+			return t, nil
+		}
+	}
+	return TransportUnknown, fmt.Errorf("unknown transport %q", name)
+}
+
+// MarshalText encodes the transport as its name
+func (t TransportType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText decodes a transport name written by MarshalText
+func (t *TransportType) UnmarshalText(text []byte) error {
+	parsed, err := ParseTransport(string(text))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
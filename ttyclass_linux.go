@@ -0,0 +1,21 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import "context"
+
+// ttyClassBackend finds devices by walking `/sys/class/tty` directly, for systems without
+// udev-maintained `/dev/serial/by-id` links
+type ttyClassBackend struct{}
+
+// list retrieves USB devices by looking up every USB serial tty in sysfs and reporting its
+// node in `/dev` as the port
+func (ttyClassBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	return hostSysfsScan(false).list(ctx, f, o)
+}
+
+// lookupPort reads the device behind a port from sysfs
+func (ttyClassBackend) lookupPort(ctx context.Context, port string, o options) (SerialDeviceInfo, bool, error) {
+	return lookupHostSysfsPort(ctx, port, false, o)
+}
@@ -0,0 +1,166 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// udevDataDir is where udev stores the properties of every device it has processed
+const udevDataDir = "/run/udev/data"
+
+func init() {
+	platformBackends[BackendUdev] = func() backend { return udevBackend{} }
+}
+
+// udevBackend finds devices through sysfs and enriches them with the normalized
+// properties recorded in the udev database
+type udevBackend struct{}
+
+// list retrieves USB serial ttys from sysfs and overlays the udev properties of each one.
+// The port is the `/dev/serial/by-id` link udev created for the device, if any.
+func (udevBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+	var nodes []string // device node of each entry in devices
+
+	if _, err := os.Stat(udevDataDir); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir("/sys/class/tty")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !hasAnyPrefix(entry.Name(), usbTTYPrefixes) {
+			continue
+		}
+
+		devicePath := filepath.Join("/dev", entry.Name())
+		device, ok, err := readSysfsDevice(hostSysfs{}, f, devicePath)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		device.Port = devicePath
+
+		props, links := readUdevData(entry.Name())
+		applyUdevProperties(&device, props)
+		for _, link := range links {
+			switch {
+			case strings.HasPrefix(link, "serial/by-id/") && device.Port == devicePath:
+				device.Port = filepath.Join("/dev", link)
+			case strings.HasPrefix(link, "serial/by-path/") && device.ByPath == "":
+				device.ByPath = filepath.Join("/dev", link)
+			}
+		}
+
+		devices = append(devices, device)
+		nodes = append(nodes, devicePath)
+	}
+
+	devices, nodes, err = appendNonUSB(ctx, f, o, devices, nodes)
+	if err != nil {
+		return nil, err
+	}
+	addExtendedInfo(devices, nodes, o)
+
+	if o.checkInUse {
+		markInUse(ctx, devices, nodes)
+	}
+
+	return devices, nil
+}
+
+// readUdevData returns the properties (E: lines) and symlinks (S: lines) udev recorded for
+// the tty, looked up by the major:minor number sysfs reports for it
+func readUdevData(ttyName string) (map[string]string, []string) {
+	dev, err := os.ReadFile(filepath.Join("/sys/class/tty", ttyName, "dev"))
+	if err != nil {
+		return nil, nil
+	}
+
+	file, err := os.Open(filepath.Join(udevDataDir, "c"+strings.TrimSpace(string(dev))))
+	if err != nil {
+		return nil, nil
+	}
+	defer file.Close()
+
+	props := make(map[string]string)
+	var links []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "E:"):
+			if key, value, ok := strings.Cut(line[2:], "="); ok {
+				props[key] = value
+			}
+		case strings.HasPrefix(line, "S:"):
+			links = append(links, line[2:])
+		}
+	}
+	return props, links
+}
+
+// udevMappedProperties are the udev properties applyUdevProperties reads into fields, left
+// out of Attributes
+var udevMappedProperties = map[string]bool{
+	"ID_SERIAL_SHORT": true, "ID_VENDOR": true, "ID_VENDOR_ENC": true,
+	"ID_MODEL": true, "ID_MODEL_ENC": true, "ID_USB_INTERFACE_NUM": true,
+}
+
+// applyUdevProperties overlays the udev properties on the attributes read from sysfs, and
+// reports the others in Attributes
+func applyUdevProperties(device *SerialDeviceInfo, props map[string]string) {
+	for key, value := range props {
+		if !udevMappedProperties[key] {
+			device.setAttribute(key, value)
+		}
+	}
+	if serial := props["ID_SERIAL_SHORT"]; serial != "" && device.SerialNumber == "" {
+		device.SerialNumber = serial
+	}
+	if vendor := decodeUdevString(props["ID_VENDOR_ENC"]); vendor != "" {
+		device.Manufacturer = vendor
+	} else if vendor := props["ID_VENDOR"]; vendor != "" {
+		device.Manufacturer = vendor
+	}
+	if model := decodeUdevString(props["ID_MODEL_ENC"]); model != "" {
+		device.Product = model
+	} else if model := props["ID_MODEL"]; model != "" {
+		device.Product = model
+	}
+	if iface := props["ID_USB_INTERFACE_NUM"]; iface != "" && device.Interface == "" {
+		device.Interface = strings.ToUpper(iface)
+	}
+}
+
+// decodeUdevString decodes the \xHH escapes udev uses in *_ENC properties and trims the
+// padding some devices include in their string descriptors
+func decodeUdevString(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+3 < len(value) && value[i+1] == 'x' {
+			if c, err := strconv.ParseUint(value[i+2:i+4], 16, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(value[i])
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,124 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"os"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Netlink multicast groups of the uevent socket: the kernel announces devices first and
+// udev repeats the announcement once its rules have run and the /dev links exist
+const (
+	ueventGroupKernel = 1
+	ueventGroupUdev   = 2
+)
+
+// uevent is a device event read from the kernel uevent netlink socket, reduced to what tells
+// whether the devices must be scanned again. The scan reads the devices from sysfs itself.
+type uevent struct {
+	Action    string // add, remove, change, bind, ...
+	Subsystem string
+}
+
+// ueventSubsystems are the subsystems whose events may change the list of serial devices
+var ueventSubsystems = map[string]bool{"tty": true, "usb": true, "usb-serial": true}
+
+// ueventChanges returns a channel that receives a value whenever a tty or USB device is
+// added or removed, coalescing bursts into a single pending value. It returns nil when the
+// uevent socket cannot be opened, as in unprivileged containers or under some SELinux
+// policies on Android.
+func ueventChanges(ctx context.Context) <-chan struct{} {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC|unix.SOCK_NONBLOCK, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil
+	}
+	addr := &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: ueventGroupKernel | ueventGroupUdev}
+	if err := unix.Bind(fd, addr); err != nil {
+		unix.Close(fd)
+		return nil
+	}
+
+	// A non-blocking descriptor is read through the runtime poller, so closing the file
+	// interrupts a pending read
+	socket := os.NewFile(uintptr(fd), "uevent")
+	go func() {
+		<-ctx.Done()
+		socket.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		buf := make([]byte, 64*1024)
+		for {
+			n, err := socket.Read(buf)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				// ENOBUFS means events were dropped; rescanning catches up with them
+				if errors.Is(err, unix.ENOBUFS) {
+					notifyChange(changes)
+					continue
+				}
+				return
+			}
+			event, ok := parseUevent(buf[:n])
+			if !ok || !ueventSubsystems[event.Subsystem] {
+				continue
+			}
+			if event.Action == "add" || event.Action == "remove" || event.Action == "bind" || event.Action == "unbind" {
+				notifyChange(changes)
+			}
+		}
+	}()
+	return changes
+}
+
+// parseUevent decodes a uevent message, either from the kernel ("add@/devices/...\0KEY=value\0...")
+// or from udev ("libudev\0" followed by a binary header pointing at the properties)
+func parseUevent(msg []byte) (uevent, bool) {
+	var properties []byte
+	if bytes.HasPrefix(msg, []byte("libudev\x00")) {
+		// Header: prefix[8], magic, header size, properties offset, properties length, ...
+		if len(msg) < 24 {
+			return uevent{}, false
+		}
+		offset := int(binary.NativeEndian.Uint32(msg[16:]))
+		length := int(binary.NativeEndian.Uint32(msg[20:]))
+		if offset+length > len(msg) {
+			return uevent{}, false
+		}
+		properties = msg[offset : offset+length]
+	} else {
+		// Skip the "action@devpath" summary
+		i := bytes.IndexByte(msg, 0)
+		if i < 0 || !bytes.Contains(msg[:i], []byte("@")) {
+			return uevent{}, false
+		}
+		properties = msg[i+1:]
+	}
+
+	var event uevent
+	for _, field := range bytes.Split(properties, []byte{0}) {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "ACTION":
+			event.Action = value
+		case "SUBSYSTEM":
+			event.Subsystem = value
+		}
+	}
+	return event, event.Action != ""
+}
@@ -0,0 +1,61 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// ueventProperties are the properties the kernel sends when an FTDI adapter is plugged in
+var ueventProperties = []string{
+	"ACTION=add",
+	"DEVPATH=/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0/tty/ttyUSB0",
+	"SUBSYSTEM=tty",
+	"MAJOR=188",
+	"MINOR=0",
+	"DEVNAME=ttyUSB0",
+	"SEQNUM=4497",
+}
+
+// udevMessage builds a message as udev sends it after running its rules: the libudev header
+// followed by the properties
+func udevMessage(properties []string) []byte {
+	body := []byte(strings.Join(properties, "\x00") + "\x00")
+	const headerSize = 40
+	header := make([]byte, headerSize)
+	copy(header, "libudev\x00")
+	binary.BigEndian.PutUint32(header[8:], 0xfeedcafe)
+	binary.NativeEndian.PutUint32(header[12:], headerSize)
+	binary.NativeEndian.PutUint32(header[16:], headerSize)
+	binary.NativeEndian.PutUint32(header[20:], uint32(len(body)))
+	return append(header, body...)
+}
+
+func TestParseUevent(t *testing.T) {
+	kernel := []byte("add@/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0/ttyUSB0/tty/ttyUSB0\x00" + strings.Join(ueventProperties, "\x00") + "\x00")
+	truncated := udevMessage(ueventProperties)
+	truncated = truncated[:len(truncated)-10]
+
+	for _, tc := range []struct {
+		name string
+		msg  []byte
+		want uevent
+		ok   bool
+	}{
+		{"kernel", kernel, uevent{Action: "add", Subsystem: "tty"}, true},
+		{"udev", udevMessage(ueventProperties), uevent{Action: "add", Subsystem: "tty"}, true},
+		{"udev unbind", udevMessage([]string{"ACTION=unbind", "DEVPATH=/devices/pci0000:00/0000:00:14.0/usb1/1-2/1-2:1.0", "SUBSYSTEM=usb", "DEVTYPE=usb_interface", "SEQNUM=4512"}), uevent{Action: "unbind", Subsystem: "usb"}, true},
+		{"truncated udev", truncated, uevent{}, false},
+		{"short udev header", []byte("libudev\x00\xfe\xed\xca\xfe"), uevent{}, false},
+		{"no summary", []byte("ACTION=add\x00SUBSYSTEM=tty\x00"), uevent{}, false},
+		{"no action", []byte("add@/devices/virtual/tty/ttyS0\x00SUBSYSTEM=tty\x00"), uevent{Subsystem: "tty"}, false},
+	} {
+		event, ok := parseUevent(tc.msg)
+		if ok != tc.ok || event != tc.want {
+			t.Errorf("%s: parseUevent = %+v, %v, want %+v, %v", tc.name, event, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package serialfinder
+
+import "context"
+
+// unsupportedBackend is the default backend of platforms serialfinder cannot scan
+type unsupportedBackend struct{}
+
+// defaultBackend returns the backend used when no other backend is selected
+func defaultBackend() backend {
+	return unsupportedBackend{}
+}
+
+// list always fails with ErrUnsupportedPlatform
+func (unsupportedBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	return nil, ErrUnsupportedPlatform
+}
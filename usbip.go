@@ -0,0 +1,65 @@
+package serialfinder
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// usbipStateDir is where the usbip tool records the remote end of each attached port
+const usbipStateDir = "/var/run/vhci_hcd"
+
+// readUSBIPRemote reports whether the USB device at usbDir is attached through the USB/IP
+// virtual host controller, and the host exporting it when the usbip tool recorded it
+func readUSBIPRemote(sys sysfsFS, usbDir string) (remote bool, host string) {
+	// Devices attached over USB/IP sit below /sys/devices/platform/vhci_hcd.N
+	vhciDir := usbDir
+	for !strings.HasPrefix(path.Base(vhciDir), "vhci_hcd") {
+		parent := path.Dir(vhciDir)
+		if parent == vhciDir {
+			return false, ""
+		}
+		vhciDir = parent
+	}
+
+	// The status files list one port per line:
+	//   hub port sta spd dev      sockfd local_busid
+	//   hs  0000 006 002 00040002 000003 3-1
+	// and `usbip attach` writes "host port busid" to /var/run/vhci_hcd/port<port>
+	busID := path.Base(usbDir)
+	entries, _ := sys.ReadDir(vhciDir)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "status") {
+			continue
+		}
+		status, err := sys.ReadFile(path.Join(vhciDir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(status))
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 7 || fields[6] != busID {
+				continue
+			}
+			var port int
+			if _, err := fmt.Sscanf(fields[1], "%d", &port); err != nil {
+				continue
+			}
+			record, err := sys.ReadFile(path.Join(usbipStateDir, fmt.Sprintf("port%d", port)))
+			if err == nil {
+				if recordFields := strings.Fields(string(record)); len(recordFields) > 0 {
+					host = recordFields[0]
+				}
+			}
+			break
+		}
+		if host != "" {
+			break
+		}
+	}
+
+	return true, host
+}
@@ -0,0 +1,76 @@
+package serialfinder
+
+import "context"
+
+// WaitForDevice blocks until a device matching the filter is present and returns it. See
+// Finder.WaitForDevice.
+func WaitForDevice(ctx context.Context, filter Filter, opts ...Option) (SerialDeviceInfo, error) {
+	return NewFinder(opts...).WaitForDevice(ctx, filter)
+}
+
+// WaitForDevice blocks until a device matching the filter is present and returns it. A
+// device already attached is returned immediately; otherwise it waits for the next one to
+// be attached, or returns ctx.Err() when the context ends first.
+func (f *Finder) WaitForDevice(ctx context.Context, filter Filter) (SerialDeviceInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := f.Watch(ctx, filter)
+	if err != nil {
+		return SerialDeviceInfo{}, err
+	}
+	for event := range events {
+		if event.Type == EventAdded {
+			return event.Device, nil
+		}
+	}
+	return SerialDeviceInfo{}, ctx.Err()
+}
+
+// WaitForRemoval blocks until the device is gone. See Finder.WaitForRemoval.
+func WaitForRemoval(ctx context.Context, device string, opts ...Option) error {
+	return NewFinder(opts...).WaitForRemoval(ctx, device)
+}
+
+// WaitForRemoval blocks until no device with the given StableID or port is attached, for
+// workflows that ask the user to unplug a device before continuing. It returns immediately
+// if the device is not attached, and ctx.Err() if the context ends first.
+func (f *Finder) WaitForRemoval(ctx context.Context, device string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	initial, events, err := f.watch(ctx, Filter{}, false, nil)
+	if err != nil {
+		return err
+	}
+
+	present := make(DeviceSet)
+	for id, d := range initial {
+		if matchesDevice(d, device) {
+			present[id] = d
+		}
+	}
+
+	// Track every match by ID: a port can be taken over by another device attached right
+	// after the first one leaves, and both may show up in the same scan
+	for len(present) > 0 {
+		event, ok := <-events
+		if !ok {
+			return ctx.Err()
+		}
+		if event.Type == EventResync || !matchesDevice(event.Device, device) {
+			continue
+		}
+		if event.Type == EventRemoved {
+			present.Remove(event.Device.StableID())
+		} else {
+			present.Add(event.Device)
+		}
+	}
+	return nil
+}
+
+// matchesDevice reports whether the device has the given StableID or port
+func matchesDevice(d SerialDeviceInfo, device string) bool {
+	return string(d.StableID()) == device || d.Port == device || (d.DialinPort != "" && d.DialinPort == device)
+}
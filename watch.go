@@ -0,0 +1,212 @@
+package serialfinder
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// EventType tells whether a device appeared or disappeared
+type EventType int
+
+const (
+	// EventAdded is sent for each device attached while watching, and for the devices
+	// present when the watch starts
+	EventAdded EventType = iota + 1
+	// EventRemoved is sent for each device detached while watching
+	EventRemoved
+	// EventResync is sent, without a device, when the watcher recovers from a failed
+	// notification source or failed scans. Changes may have been missed meanwhile; the
+	// events that follow it bring the state up to date.
+	EventResync
+)
+
+// String returns "added", "removed" or "resync"
+func (t EventType) String() string {
+	switch t {
+	case EventAdded:
+		return "added"
+	case EventRemoved:
+		return "removed"
+	case EventResync:
+		return "resync"
+	}
+	return "unknown"
+}
+
+// Event reports a device that was attached or detached. Device is the full description of
+// the device; for removals it is the last one seen before the device went away.
+type Event struct {
+	Type   EventType
+	Device SerialDeviceInfo
+}
+
+// defaultPollInterval is how often Watch rescans unless WithPollInterval says otherwise
+const defaultPollInterval = time.Second
+
+// Watch reports the devices matching the filter as they are attached and detached, until
+// the context is canceled. See Finder.Watch.
+func Watch(ctx context.Context, filter Filter, opts ...Option) (<-chan Event, error) {
+	return NewFinder(opts...).Watch(ctx, filter)
+}
+
+// Watch reports the devices matching the filter as they are attached and detached. The
+// devices present when it starts are sent first as EventAdded. Devices are told apart by
+// their StableID. The channel is closed when the context is canceled.
+//
+// On Linux, changes are announced by the kernel uevent netlink socket, or by inotify on
+// /dev where the socket is blocked, and on macOS built with cgo by IOKit notifications, so
+// they arrive within milliseconds. Elsewhere, or when
+// notifications are unavailable, the devices are rescanned at the interval set with
+// WithPollInterval.
+//
+// It returns an error if the first scan fails. Later failures do not end the watch: failed
+// scans are retried at the poll interval, a notification source that dies is subscribed
+// again, and an EventResync followed by the changes missed meanwhile is sent on recovery.
+func (f *Finder) Watch(ctx context.Context, filter Filter) (<-chan Event, error) {
+	_, events, err := f.watch(ctx, filter, true, nil)
+	return events, err
+}
+
+// watch implements Watch and returns the devices found by the first scan. Unless announce
+// is set, only the changes after that scan are sent. A non-nil scanned is called with the
+// devices of every successful scan, the first one before watch returns, including the
+// devices whose attributes changed while they stayed attached, which no event reports.
+func (f *Finder) watch(ctx context.Context, filter Filter, announce bool, scanned func(DeviceSet)) (DeviceSet, <-chan Event, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Subscribe before the first scan so no change falls between the two
+	changes := f.deviceChanges(ctx)
+
+	initial, err := f.watchScan(ctx, filter)
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if scanned != nil {
+		scanned(initial)
+	}
+	current := initial
+
+	interval := f.opts.pollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer cancel()
+		defer close(events)
+
+		if announce && !sendEvents(ctx, events, DeviceSet{}, current) {
+			return
+		}
+
+		// Platforms that announce device changes are rescanned on each announcement. The
+		// others are polled, as are all platforms while their notification source is down,
+		// to rescan and to try to subscribe again, and after a failed scan, to retry it.
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lost, failed, resync bool
+		for {
+			var tick <-chan time.Time
+			if changes == nil || failed {
+				tick = ticker.C
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick:
+				if changes == nil {
+					if changes = f.deviceChanges(ctx); changes != nil && lost {
+						lost, resync = false, true
+					}
+				}
+			case _, ok := <-changes:
+				if !ok {
+					// The source died; subscribe again and rescan, as changes may be lost
+					lost = true
+					if changes = f.deviceChanges(ctx); changes != nil {
+						lost, resync = false, true
+					}
+				}
+			}
+
+			next, err := f.watchScan(ctx, filter)
+			if err != nil {
+				failed = true
+				continue
+			}
+			if failed {
+				failed, resync = false, true
+			}
+			if scanned != nil {
+				scanned(next)
+			}
+			if resync {
+				resync = false
+				select {
+				case events <- Event{Type: EventResync}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if !sendEvents(ctx, events, current, next) {
+				return
+			}
+			current = next
+		}
+	}()
+	return initial, events, nil
+}
+
+// deviceChanges subscribes to the notification source of the platform, or to the one
+// set in the options
+func (f *Finder) deviceChanges(ctx context.Context) <-chan struct{} {
+	if f.opts.changeSource != nil {
+		return f.opts.changeSource(ctx)
+	}
+	return deviceChanges(ctx, f.opts)
+}
+
+// watchScan lists the devices matching the filter as a set. A WSL system without attached
+// devices is simply empty while watching.
+func (f *Finder) watchScan(ctx context.Context, filter Filter) (DeviceSet, error) {
+	devices, err := f.List(ctx, filter)
+	if err != nil && !errors.Is(err, ErrNoDevicesInWSL) {
+		return nil, err
+	}
+	return NewDeviceSet(devices...), nil
+}
+
+// sendEvents sends the removals and additions between two snapshots, in ID order. It
+// returns false if the context was canceled first.
+func sendEvents(ctx context.Context, events chan<- Event, before, after DeviceSet) bool {
+	var pending []Event
+	removed := before.Difference(after)
+	for _, id := range removed.IDs() {
+		pending = append(pending, Event{Type: EventRemoved, Device: removed[id]})
+	}
+	added := after.Difference(before)
+	for _, id := range added.IDs() {
+		pending = append(pending, Event{Type: EventAdded, Device: added[id]})
+	}
+
+	for _, event := range pending {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// notifyChange marks a change as pending on a deviceChanges channel without blocking
+func notifyChange(changes chan<- struct{}) {
+	select {
+	case changes <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,129 @@
+//go:build darwin && cgo
+// +build darwin,cgo
+
+package serialfinder
+
+/*
+#cgo LDFLAGS: -framework IOKit -framework CoreFoundation
+#include <CoreFoundation/CoreFoundation.h>
+#include <IOKit/IOKitLib.h>
+#include <IOKit/serial/IOSerialKeys.h>
+#include <stdint.h>
+
+extern void serialfinderDeviceChanged(uintptr_t handle);
+
+typedef struct {
+	IONotificationPortRef port;
+	io_iterator_t published;
+	io_iterator_t terminated;
+} serialWatcher;
+
+// drainIterator releases the objects of a notification, which re-arms it
+static void drainIterator(io_iterator_t iterator) {
+	io_object_t object;
+	while ((object = IOIteratorNext(iterator))) {
+		IOObjectRelease(object);
+	}
+}
+
+static void serialChanged(void *refcon, io_iterator_t iterator) {
+	drainIterator(iterator);
+	serialfinderDeviceChanged((uintptr_t)refcon);
+}
+
+// startSerialWatcher registers for IOSerialBSDClient publish and terminate notifications on
+// the run loop of the calling thread
+static int startSerialWatcher(serialWatcher *w, uintptr_t handle) {
+	// MACH_PORT_NULL selects the default main port on every macOS release
+	w->port = IONotificationPortCreate(MACH_PORT_NULL);
+	if (w->port == NULL) {
+		return -1;
+	}
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(w->port), kCFRunLoopDefaultMode);
+
+	// Each registration consumes one reference to the matching dictionary
+	CFMutableDictionaryRef matching = IOServiceMatching(kIOSerialBSDServiceValue);
+	if (matching == NULL) {
+		IONotificationPortDestroy(w->port);
+		return -1;
+	}
+	CFRetain(matching);
+	if (IOServiceAddMatchingNotification(w->port, kIOPublishNotification, matching, serialChanged, (void *)handle, &w->published) != KERN_SUCCESS) {
+		CFRelease(matching);
+		IONotificationPortDestroy(w->port);
+		return -1;
+	}
+	if (IOServiceAddMatchingNotification(w->port, kIOTerminatedNotification, matching, serialChanged, (void *)handle, &w->terminated) != KERN_SUCCESS) {
+		IOObjectRelease(w->published);
+		IONotificationPortDestroy(w->port);
+		return -1;
+	}
+
+	// The iterators list the existing clients and only fire once drained
+	drainIterator(w->published);
+	drainIterator(w->terminated);
+	return 0;
+}
+
+// runSerialWatcher delivers notifications for a short while, so the caller can check
+// whether to stop between runs
+static void runSerialWatcher(void) {
+	CFRunLoopRunInMode(kCFRunLoopDefaultMode, 0.25, false);
+}
+
+static void closeSerialWatcher(serialWatcher *w) {
+	IOObjectRelease(w->published);
+	IOObjectRelease(w->terminated);
+	IONotificationPortDestroy(w->port);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"runtime"
+	"runtime/cgo"
+)
+
+// serialfinderDeviceChanged is called from the run loop when a serial client is published
+// or terminated
+//
+//export serialfinderDeviceChanged
+func serialfinderDeviceChanged(handle C.uintptr_t) {
+	notifyChange(cgo.Handle(handle).Value().(chan struct{}))
+}
+
+// deviceChanges returns a channel that receives a value whenever an IOSerialBSDClient is
+// published or terminated, coalescing bursts into a single pending value. The notifications
+// run on a dedicated thread, so no ioreg process is spawned until something changes. It
+// returns nil, and callers fall back to polling, when the notifications cannot be set up.
+func deviceChanges(ctx context.Context, o options) <-chan struct{} {
+	changes := make(chan struct{}, 1)
+	handle := cgo.NewHandle(changes)
+	started := make(chan bool)
+
+	go func() {
+		// Run loops belong to a thread, so the goroutine must stay on one
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		defer handle.Delete()
+
+		var w C.serialWatcher
+		if C.startSerialWatcher(&w, C.uintptr_t(handle)) != 0 {
+			started <- false
+			return
+		}
+		started <- true
+
+		for ctx.Err() == nil {
+			C.runSerialWatcher()
+		}
+		C.closeSerialWatcher(&w)
+		close(changes)
+	}()
+
+	if !<-started {
+		return nil
+	}
+	return changes
+}
@@ -0,0 +1,75 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// deviceChanges returns a channel that receives a value whenever a serial device may have
+// been added or removed, from the uevent netlink socket or, when WithInotifyWatch is set or
+// the socket is blocked, from inotify. It returns nil when neither is available.
+func deviceChanges(ctx context.Context, o options) <-chan struct{} {
+	if !o.inotifyWatch {
+		if changes := ueventChanges(ctx); changes != nil {
+			return changes
+		}
+	}
+	return inotifyChanges(ctx)
+}
+
+// inotifyDirs are watched for device nodes and links coming and going. /dev/serial/by-id is
+// removed with its last link and created again with the next one, so its parents are
+// watched too and it is added back when it reappears.
+var inotifyDirs = []string{"/dev", "/dev/serial", "/dev/serial/by-id"}
+
+// inotifyChanges returns a channel that receives a value whenever an entry is created or
+// deleted in one of inotifyDirs, coalescing bursts into a single pending value. It returns
+// nil when inotify is unavailable or /dev cannot be watched.
+func inotifyChanges(ctx context.Context) <-chan struct{} {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil
+	}
+	// Adding a directory that is already watched keeps its watch, so every directory is
+	// simply added again after each batch of events
+	watch := func() bool {
+		ok := true
+		for _, dir := range inotifyDirs {
+			_, err := unix.InotifyAddWatch(fd, dir, unix.IN_CREATE|unix.IN_DELETE|unix.IN_MOVED_TO|unix.IN_MOVED_FROM)
+			ok = ok && (err == nil || dir != "/dev")
+		}
+		return ok
+	}
+	if !watch() {
+		unix.Close(fd)
+		return nil
+	}
+
+	// A non-blocking descriptor is read through the runtime poller, so closing the file
+	// interrupts a pending read
+	file := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer close(changes)
+		buf := make([]byte, 64*1024)
+		for {
+			// Which entry changed does not matter, since any change leads to a rescan
+			if _, err := file.Read(buf); err != nil {
+				return
+			}
+			watch()
+			notifyChange(changes)
+		}
+	}()
+	return changes
+}
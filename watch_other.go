@@ -0,0 +1,12 @@
+//go:build !linux && !(darwin && cgo)
+// +build !linux
+// +build !darwin !cgo
+
+package serialfinder
+
+import "context"
+
+// deviceChanges returns nil, so Watch relies on polling alone
+func deviceChanges(ctx context.Context, o options) <-chan struct{} {
+	return nil
+}
@@ -0,0 +1,138 @@
+//go:build windows
+// +build windows
+
+package serialfinder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	platformBackends[BackendWMI] = func() backend { return wmiBackend{} }
+}
+
+// wmiQuery lists the present devices of the Ports class ({4d36e978-...}) as a JSON array
+const wmiQuery = `ConvertTo-Json -Compress -InputObject @(Get-CimInstance -ClassName Win32_PnPEntity ` +
+	`-Filter "ClassGuid='{4d36e978-e325-11ce-bfc1-08002be10318}'" | ` +
+	`Select-Object DeviceID,Name,Manufacturer,Status,Service)`
+
+// wmiBackend finds devices by querying WMI through PowerShell
+type wmiBackend struct{}
+
+// wmiEntity holds the Win32_PnPEntity properties selected by wmiQuery
+type wmiEntity struct {
+	DeviceID     string
+	Name         string
+	Manufacturer string
+	Status       string
+	Service      string
+}
+
+// rePortName extracts the COM port from an entity name like "USB Serial Port (COM3)"
+var rePortName = regexp.MustCompile(`\((COM\d+)\)`)
+
+// list retrieves serial ports on Windows from WMI, filtering by VID and PID
+func (wmiBackend) list(ctx context.Context, f Filter, o options) ([]SerialDeviceInfo, error) {
+	var devices []SerialDeviceInfo
+
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", wmiQuery)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	err := runCommand(ctx, cmd, o.toolTimeout())
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query WMI: %w, output: %s", err, stderr.String())
+	}
+
+	var entities []wmiEntity
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &entities); err != nil {
+		return nil, fmt.Errorf("error parsing WMI output: %v", err)
+	}
+
+	for _, entity := range entities {
+		// Devices with a problem (driver missing, disabled, ...) cannot be opened
+		if entity.Status != "" && entity.Status != "OK" {
+			continue
+		}
+		match := rePortName.FindStringSubmatch(entity.Name)
+		if match == nil {
+			continue
+		}
+
+		device := parseInstanceIDWindows(entity.DeviceID)
+		if !f.matchIDs(device.Vid, device.Pid) {
+			continue
+		}
+		device.Port = match[1]
+		device.Manufacturer = entity.Manufacturer
+		device.Description = entity.Name
+		device.Driver = entity.Service
+		devices = append(devices, device)
+	}
+
+	return devices, nil
+}
+
+// parseInstanceIDWindows extracts what a device instance ID such as
+// USB\VID_0403&PID_6001\A50285BI tells about the device: VID, PID, serial number, interface
+// and the transport implied by its enumerator
+func parseInstanceIDWindows(instanceID string) SerialDeviceInfo {
+	parts := strings.Split(instanceID, `\`)
+	device := SerialDeviceInfo{
+		Transport: transportFromEnumeratorWindows(parts[0]),
+		Location:  instanceID,
+	}
+	if len(parts) < 3 {
+		return device
+	}
+
+	// The FTDI VCP driver names every instance 0000 and keeps the serial number in the
+	// device ID, with the channel letter appended
+	if strings.EqualFold(parts[0], "FTDIBUS") {
+		if vid, pid, serial, iface, ok := parseFTDIBusDeviceIDWindows(parts[1]); ok {
+			device.Vid, device.Pid, device.SerialNumber, device.Interface = vid, pid, serial, iface
+		}
+		return device
+	}
+
+	// Some other enumerators separate the fields with '+' instead of '&'
+	deviceID := strings.ReplaceAll(parts[1], "+", "&")
+	if vid, pid, ok := parseDeviceIDWindows(deviceID); ok {
+		device.Vid = vid
+		device.Pid = pid
+		device.Interface = interfaceFromDeviceIDWindows(deviceID)
+	}
+
+	// Instance IDs containing '&' are generated by Windows for devices without a serial number
+	if !strings.Contains(parts[2], "&") {
+		device.SerialNumber = parts[2]
+	}
+	return device
+}
+
+// transportFromEnumeratorWindows maps the enumerator part of an instance ID to a transport
+func transportFromEnumeratorWindows(enumerator string) TransportType {
+	switch strings.ToUpper(enumerator) {
+	case "USB", "FTDIBUS", "USBSER":
+		return TransportUSB
+	case "BTHENUM", "BTHLEDEVICE":
+		return TransportBluetooth
+	case "PCI", "MF":
+		return TransportPCI
+	case "ACPI":
+		return TransportPlatform
+	case "ROOT", "COM0COM", "SWD":
+		return TransportVirtual
+	default:
+		return TransportUnknown
+	}
+}
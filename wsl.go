@@ -0,0 +1,8 @@
+package serialfinder
+
+import "errors"
+
+// ErrNoDevicesInWSL is returned on WSL when no USB device has been attached to the VM.
+// WSL does not see the USB devices of the Windows host until they are shared with
+// `usbipd bind` and attached with `usbipd attach --wsl` from Windows.
+var ErrNoDevicesInWSL = errors.New("serialfinder: no USB devices are attached to WSL; attach them from Windows with `usbipd attach --wsl --busid <busid>`")
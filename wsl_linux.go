@@ -0,0 +1,40 @@
+//go:build linux
+// +build linux
+
+package serialfinder
+
+import (
+	"os"
+	"strings"
+)
+
+// IsWSL reports whether the program runs under the Windows Subsystem for Linux
+func IsWSL() bool {
+	if os.Getenv("WSL_DISTRO_NAME") != "" {
+		return true
+	}
+	// The WSL kernels are built with "microsoft" in their release, e.g. 5.15.90.1-microsoft-standard-WSL2
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft")
+}
+
+// checkWSLDevices returns ErrNoDevicesInWSL when a scan found nothing under WSL and no USB
+// device is attached at all, so callers can tell the user to share one from Windows
+func checkWSLDevices(devices []SerialDeviceInfo) error {
+	if len(devices) > 0 || !IsWSL() {
+		return nil
+	}
+
+	entries, err := os.ReadDir("/sys/bus/usb/devices")
+	if err != nil {
+		return ErrNoDevicesInWSL
+	}
+	for _, entry := range entries {
+		// Devices are named like 1-1 and their interfaces like 1-1:1.0; the usbN entries
+		// are the root hubs of the host controllers
+		if !strings.HasPrefix(entry.Name(), "usb") && !strings.Contains(entry.Name(), ":") {
+			return nil
+		}
+	}
+	return ErrNoDevicesInWSL
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+// +build !linux
+
+package serialfinder
+
+// IsWSL reports whether the program runs under the Windows Subsystem for Linux
+func IsWSL() bool {
+	return false
+}